@@ -0,0 +1,16 @@
+package fileplatform
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	events, _ := newStores(t, dir)
+	platformtesting.TestCache(nil, t, events)
+}
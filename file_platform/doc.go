@@ -0,0 +1,13 @@
+/*
+Package fileplatform - implementation for platform code backed by files on local disk
+
+The events of each property are kept in an append-only log, one file per property. Each
+append is a single checksummed frame that is synced to disk before the call returns, so a
+crash part way through a write leaves at most a torn last frame, which is dropped the next
+time the log is read. A corrupt frame anywhere else is reported as an error and the log is
+left untouched, rather than dropping the events after it. The property list and the email store are small and are rewritten
+atomically (write to a temp file, sync, rename) on every change.
+
+The cache is kept in memory, a restart simply starts with an empty cache.
+*/
+package fileplatform
@@ -0,0 +1,210 @@
+package fileplatform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	uuid "github.com/satori/go.uuid"
+)
+
+const emailsFileName = "emails.gob"
+
+// PersistedEmail is the structure used to store an email address and other PII information
+type PersistedEmail struct {
+	EmailID    string
+	Email      string
+	PropertyID string
+	// Contacts by notification channel, ex. a phone number
+	Contacts map[platform.NotificationChannel]string
+}
+
+type fileEmailImpl struct {
+	fileName string
+	mutex    sync.RWMutex
+	// emails by property id and then email id
+	emails map[string]map[string]PersistedEmail
+}
+
+// NewPersistedEmailStore is the factory method to create an email store in dir
+func NewPersistedEmailStore(dir string) (platform.PersistedEmailStore, error) {
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return nil, err
+	}
+	r := &fileEmailImpl{fileName: filepath.Join(dir, emailsFileName)}
+	if _, err := readGobFile(r.fileName, &r.emails); err != nil {
+		return nil, err
+	}
+	if r.emails == nil {
+		r.emails = make(map[string]map[string]PersistedEmail)
+	}
+	return r, nil
+}
+
+// update applies the change to a copy of the emails and persists the copy before using it
+func (r *fileEmailImpl) update(change func(emails map[string]map[string]PersistedEmail)) error {
+	emails := make(map[string]map[string]PersistedEmail)
+	for propertyID, records := range r.emails {
+		emails[propertyID] = make(map[string]PersistedEmail)
+		for emailID, record := range records {
+			emails[propertyID][emailID] = record
+		}
+	}
+
+	change(emails)
+
+	if err := writeGobFile(r.fileName, emails); err != nil {
+		return err
+	}
+	r.emails = emails
+	return nil
+}
+
+func (r *fileEmailImpl) CreateEmail(ctx context.Context, propertyID string, email string) (string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	emailID := uuid.Must(uuid.NewV4()).String()
+	err := r.update(func(emails map[string]map[string]PersistedEmail) {
+		if _, ok := emails[propertyID]; !ok {
+			emails[propertyID] = make(map[string]PersistedEmail)
+		}
+		emails[propertyID][emailID] = PersistedEmail{EmailID: emailID, Email: email, PropertyID: propertyID}
+	})
+	if err != nil {
+		return "", err
+	}
+	return emailID, nil
+}
+
+func (r *fileEmailImpl) GetPropertiesByEmail(ctx context.Context, email string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	properties := []string{}
+	for propertyID, records := range r.emails {
+		for _, record := range records {
+			if record.Email == email {
+				properties = append(properties, propertyID)
+				break
+			}
+		}
+	}
+	return properties, nil
+}
+
+func (r *fileEmailImpl) GetEmailMap(ctx context.Context, propertyID string) (map[string]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	records, ok := r.emails[propertyID]
+	if !ok {
+		return nil, nil
+	}
+	emailMap := make(map[string]string)
+	for _, record := range records {
+		emailMap[record.EmailID] = record.Email
+	}
+	return emailMap, nil
+}
+
+func (r *fileEmailImpl) RestoreEmails(ctx context.Context, propertyID string, persistedEmails map[string]string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.update(func(emails map[string]map[string]PersistedEmail) {
+		if _, ok := emails[propertyID]; !ok {
+			emails[propertyID] = make(map[string]PersistedEmail)
+		}
+		for emailID, email := range persistedEmails {
+			emails[propertyID][emailID] = PersistedEmail{EmailID: emailID, Email: email, PropertyID: propertyID}
+		}
+	})
+}
+
+func (r *fileEmailImpl) GetEmail(ctx context.Context, propertyID string, email string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, record := range r.emails[propertyID] {
+		if record.Email == email {
+			return record.EmailID, nil
+		}
+	}
+	return "", nil
+}
+
+func (r *fileEmailImpl) EmailExists(ctx context.Context, propertyID string, email string) (*bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	exists := false
+	for _, record := range r.emails[propertyID] {
+		if record.Email == email {
+			exists = true
+			break
+		}
+	}
+	return &exists, nil
+}
+
+func (r *fileEmailImpl) DeleteEmails(ctx context.Context, propertyID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.emails[propertyID]; !ok {
+		return nil
+	}
+	return r.update(func(emails map[string]map[string]PersistedEmail) {
+		delete(emails, propertyID)
+	})
+}
+
+func (r *fileEmailImpl) DeleteEmail(ctx context.Context, propertyID string, emailID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.emails[propertyID][emailID]; !ok {
+		return nil
+	}
+	return r.update(func(emails map[string]map[string]PersistedEmail) {
+		delete(emails[propertyID], emailID)
+	})
+}
+
+func (r *fileEmailImpl) SetContact(ctx context.Context, propertyID string, emailID string, channel platform.NotificationChannel, contact string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.emails[propertyID][emailID]; !ok {
+		return errors.New("email does not exist")
+	}
+	return r.update(func(emails map[string]map[string]PersistedEmail) {
+		record := emails[propertyID][emailID]
+		contacts := make(map[platform.NotificationChannel]string)
+		for existingChannel, existingContact := range record.Contacts {
+			contacts[existingChannel] = existingContact
+		}
+		if contact == "" {
+			delete(contacts, channel)
+		} else {
+			contacts[channel] = contact
+		}
+		record.Contacts = contacts
+		emails[propertyID][emailID] = record
+	})
+}
+
+func (r *fileEmailImpl) GetContacts(ctx context.Context, propertyID string, emailID string) (map[platform.NotificationChannel]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	contacts := make(map[platform.NotificationChannel]string)
+	for channel, contact := range r.emails[propertyID][emailID].Contacts {
+		contacts[channel] = contact
+	}
+	return contacts, nil
+}
@@ -0,0 +1,49 @@
+package fileplatform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func newEmailStore(t *testing.T, dir string) platform.PersistedEmailStore {
+	store, err := NewPersistedEmailStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestEmail(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	platformtesting.TestCreateEmail(nil, t, newEmailStore(t, filepath.Join(dir, "create")))
+	platformtesting.TestDeleteSingleEmail(nil, t, newEmailStore(t, filepath.Join(dir, "single")))
+	platformtesting.TestDeleteEmail(nil, t, newEmailStore(t, filepath.Join(dir, "delete")))
+	platformtesting.TestRestoreEmail(nil, t, newEmailStore(t, filepath.Join(dir, "restore")))
+	platformtesting.TestContacts(nil, t, newEmailStore(t, filepath.Join(dir, "contacts")))
+}
+
+func TestEmailSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	emailID, err := newEmailStore(t, dir).CreateEmail(ctx, "property", "test@testing.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emailMap, err := newEmailStore(t, dir).GetEmailMap(ctx, "property")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emailMap[emailID] != "test@testing.com" {
+		t.Fatalf("expected the email after a restart but got %+v", emailMap)
+	}
+}
@@ -0,0 +1,319 @@
+package fileplatform
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// each frame in a property log is a header followed by the gob encoded events of one append
+const frameHeaderSize = 8
+
+const eventsDirName = "events"
+const eventsFileSuffix = ".events"
+
+type cacheRecord struct {
+	Version int
+	Value   []byte
+}
+
+type fileEventsImpl struct {
+	dir   string
+	mutex sync.Mutex
+
+	cacheMutex sync.RWMutex
+	cache      map[string]cacheRecord
+}
+
+// NewPersistedVersionedEvents is the factory method to create a persisted events store in dir
+func NewPersistedVersionedEvents(dir string) (platform.PersistedVersionedEvents, error) {
+	eventsDir := filepath.Join(dir, eventsDirName)
+	if err := os.MkdirAll(eventsDir, dirPermissions); err != nil {
+		return nil, err
+	}
+	return &fileEventsImpl{dir: eventsDir, cache: make(map[string]cacheRecord)}, nil
+}
+
+func (r *fileEventsImpl) fileName(propertyID string) (string, error) {
+	// property ids are uuids, but never allow a path outside of the events directory
+	if propertyID == "" || filepath.Base(propertyID) != propertyID || propertyID == "." || propertyID == ".." {
+		return "", fmt.Errorf("invalid property id: %v", propertyID)
+	}
+	return filepath.Join(r.dir, propertyID+eventsFileSuffix), nil
+}
+
+func encodeFrame(events []platform.VersionedEvent) ([]byte, error) {
+	stream := &bytes.Buffer{}
+	en := gob.NewEncoder(stream)
+	if err := en.Encode(events); err != nil {
+		return nil, err
+	}
+	payload := stream.Bytes()
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[frameHeaderSize:], payload)
+	return frame, nil
+}
+
+// readLog returns the events in the log and the size of the valid part of the file.
+// Only the last frame may be torn (a crash during an append), in which case it is ignored
+// and overwritten by the next append. A corrupt frame followed by more data is an error,
+// since dropping it would also drop every later event.
+func (r *fileEventsImpl) readLog(propertyID string) ([]platform.VersionedEvent, int64, error) {
+	fileName, err := r.fileName(propertyID)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, 0, fmt.Errorf("property %v does not exist", propertyID)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events := []platform.VersionedEvent{}
+	offset := int64(0)
+	for offset < int64(len(data)) {
+		remaining := data[offset:]
+		if len(remaining) < frameHeaderSize {
+			logging.LogWarningf("property %v log has a torn frame header at offset %v", propertyID, offset)
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(remaining[0:4]))
+		checksum := binary.BigEndian.Uint32(remaining[4:8])
+		if int64(len(remaining)-frameHeaderSize) < size {
+			logging.LogWarningf("property %v log has a torn frame at offset %v", propertyID, offset)
+			break
+		}
+		payload := remaining[frameHeaderSize : frameHeaderSize+size]
+		last := int64(len(remaining)) == frameHeaderSize+size
+		if crc32.ChecksumIEEE(payload) != checksum {
+			if !last {
+				return nil, 0, fmt.Errorf("property %v log has a corrupt frame at offset %v", propertyID, offset)
+			}
+			logging.LogWarningf("property %v log has a torn last frame at offset %v", propertyID, offset)
+			break
+		}
+
+		frameEvents := []platform.VersionedEvent{}
+		dec := gob.NewDecoder(bytes.NewBuffer(payload))
+		if err := dec.Decode(&frameEvents); err != nil {
+			return nil, 0, fmt.Errorf("property %v log has an undecodable frame at offset %v: %v", propertyID, offset, err)
+		}
+		events = append(events, frameEvents...)
+		offset += frameHeaderSize + size
+	}
+
+	return events, offset, nil
+}
+
+// appendLog writes a frame after the valid part of the log and syncs it to disk
+func (r *fileEventsImpl) appendLog(propertyID string, validSize int64, frame []byte) error {
+	fileName, err := r.fileName(propertyID)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(fileName, os.O_WRONLY, filePermissions)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// drop any torn frame left behind by a crash
+	if err := file.Truncate(validSize); err != nil {
+		return err
+	}
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := file.Write(frame); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+func (r *fileEventsImpl) CreateProperty(ctx context.Context, propertyID string, events []platform.VersionedEvent, persistedPropertyList platform.PersistedPropertyList, nextPropertyListIndex int) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fileName, err := r.fileName(propertyID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(fileName); err == nil {
+		return 0, fmt.Errorf("property %v already exists", propertyID)
+	}
+
+	for index, event := range events {
+		event.SetEventVersion(index)
+	}
+	frame, err := encodeFrame(events)
+	if err != nil {
+		return 0, err
+	}
+
+	// write the events before listing the property, a crash in between leaves an unlisted log
+	if err := writeFileAtomic(fileName, frame); err != nil {
+		return 0, err
+	}
+
+	if persistedPropertyList != nil {
+		err := persistedPropertyList.CreateProperty(ctx, propertyID, nextPropertyListIndex)
+		if err != nil {
+			os.Remove(fileName)
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *fileEventsImpl) DeleteProperty(ctx context.Context, propertyID string, persistedPropertyList platform.PersistedPropertyList) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if persistedPropertyList != nil {
+		err := persistedPropertyList.DeleteProperty(ctx, propertyID)
+		if err != nil {
+			return err
+		}
+	}
+
+	fileName, err := r.fileName(propertyID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	r.cacheMutex.Lock()
+	for key := range r.cache {
+		if strings.HasPrefix(key, propertyID+cacheKeyDelimiter) {
+			delete(r.cache, key)
+		}
+	}
+	r.cacheMutex.Unlock()
+
+	return syncDir(r.dir)
+}
+
+func (r *fileEventsImpl) NewPropertyEvents(ctx context.Context, propertyID string, transactionKey int, events []platform.VersionedEvent, inTransaction bool) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	records, validSize, err := r.readLog(propertyID)
+	if err != nil {
+		return -1, err
+	}
+
+	// optimistic concurrency, the caller must have seen all the current events
+	length := len(records)
+	if transactionKey != length {
+		return 0, errors.New("NewPropertyEvents transactionIndex is wrong")
+	}
+	for index, event := range events {
+		event.SetEventVersion(length + index)
+	}
+
+	frame, err := encodeFrame(events)
+	if err != nil {
+		return -1, err
+	}
+	if err := r.appendLog(propertyID, validSize, frame); err != nil {
+		return -1, err
+	}
+
+	return length + len(events), nil
+}
+
+func (r *fileEventsImpl) GetNextEventID(ctx context.Context, propertyID string, inTransaction bool) (int, error) {
+	return r.NumRecords(ctx, propertyID)
+}
+
+func (r *fileEventsImpl) NumRecords(ctx context.Context, propertyID string) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	records, _, err := r.readLog(propertyID)
+	if err != nil {
+		return -1, err
+	}
+	return len(records), nil
+}
+
+func (r *fileEventsImpl) GetEvents(ctx context.Context, propertyID string) ([]platform.VersionedEvent, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	records, _, err := r.readLog(propertyID)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+const cacheKeyDelimiter = "_"
+
+func generateCacheKey(propertyID string, key string) string {
+	return fmt.Sprintf("%s%s%s", propertyID, cacheKeyDelimiter, key)
+}
+
+func (r *fileEventsImpl) CacheWrite(ctx context.Context, propertyID string, version int, key string, value []byte) error {
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+
+	r.cache[generateCacheKey(propertyID, key)] = cacheRecord{Version: version, Value: value}
+	return nil
+}
+
+func (r *fileEventsImpl) CacheDelete(ctx context.Context, propertyID string, key string) error {
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+
+	delete(r.cache, generateCacheKey(propertyID, key))
+	return nil
+}
+
+func (r *fileEventsImpl) CacheRead(ctx context.Context, propertyID string, keys []string) (int, map[string][]byte, error) {
+	r.cacheMutex.RLock()
+	defer r.cacheMutex.RUnlock()
+
+	version := 0
+	data := make(map[int]map[string][]byte)
+
+	// default to empty set for default version 0
+	data[version] = make(map[string][]byte)
+
+	for _, key := range keys {
+		record, ok := r.cache[generateCacheKey(propertyID, key)]
+		if !ok {
+			continue
+		}
+
+		if _, ok := data[record.Version]; !ok {
+			data[record.Version] = make(map[string][]byte)
+		}
+		data[record.Version][key] = record.Value
+
+		if version < record.Version {
+			version = record.Version
+		}
+	}
+
+	return version, data[version], nil
+}
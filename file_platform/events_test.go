@@ -0,0 +1,182 @@
+package fileplatform
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fileplatform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func newStores(t *testing.T, dir string) (platform.PersistedVersionedEvents, platform.PersistedPropertyList) {
+	events, err := NewPersistedVersionedEvents(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := NewPersistedPropertyList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events, list
+}
+
+func TestEvents(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	events, list := newStores(t, filepath.Join(dir, "create"))
+	platformtesting.TestEventsCreate(nil, t, events, list)
+	events, list = newStores(t, filepath.Join(dir, "delete"))
+	platformtesting.TestEventsDelete(nil, t, events, list)
+}
+
+type restartEvent struct {
+	Value        int
+	EventVersion int
+}
+
+func (r *restartEvent) GetEventVersion() int {
+	return r.EventVersion
+}
+
+func (r *restartEvent) SetEventVersion(Version int) {
+	r.EventVersion = Version
+}
+
+func TestEventsSurviveRestart(t *testing.T) {
+	gob.Register(&restartEvent{})
+
+	ctx := context.Background()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	events, list := newStores(t, dir)
+	next, err := events.CreateProperty(ctx, "first", []platform.VersionedEvent{&restartEvent{Value: 1}}, list, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := events.CreateProperty(ctx, "second", []platform.VersionedEvent{&restartEvent{Value: 10}}, list, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := events.NewPropertyEvents(ctx, "first", next, []platform.VersionedEvent{&restartEvent{Value: 2}}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("a stale transaction key is rejected")
+	if _, err := events.NewPropertyEvents(ctx, "first", next, []platform.VersionedEvent{&restartEvent{Value: 3}}, false); err == nil {
+		t.Fatal("expected a stale transaction key to fail")
+	}
+
+	t.Log("simulate a crash part way through an append")
+	file, err := os.OpenFile(filepath.Join(dir, eventsDirName, "first"+eventsFileSuffix), os.O_WRONLY|os.O_APPEND, filePermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte{0, 0, 1})
+	file.Close()
+
+	t.Log("reopen the stores")
+	events, list = newStores(t, dir)
+
+	ids, err := list.GetProperties(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 properties but got %+v", ids)
+	}
+
+	first, err := events.GetEvents(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[1].(*restartEvent).Value != 2 || first[1].GetEventVersion() != 1 {
+		t.Fatalf("expected 2 events for the first property but got %+v", first)
+	}
+
+	second, err := events.GetEvents(ctx, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].(*restartEvent).Value != 10 {
+		t.Fatalf("properties should not overwrite each other, got %+v", second)
+	}
+
+	t.Log("appends after the torn frame are readable")
+	if _, err := events.NewPropertyEvents(ctx, "first", 2, []platform.VersionedEvent{&restartEvent{Value: 3}}, false); err != nil {
+		t.Fatal(err)
+	}
+	first, _ = events.GetEvents(ctx, "first")
+	if len(first) != 3 {
+		t.Fatalf("expected 3 events but got %+v", len(first))
+	}
+
+	t.Log("a deleted property does not free up its list index")
+	if err := events.DeleteProperty(ctx, "second", list); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = NewPersistedPropertyList(dir)
+	if nextIndex, _ := list.GetNextVersion(ctx); nextIndex != 2 {
+		t.Fatalf("expected next index 2 but got %+v", nextIndex)
+	}
+	if _, err := events.GetEvents(ctx, "second"); err == nil {
+		t.Fatal("expected the deleted property to be gone")
+	}
+}
+
+func TestEventsCorruptFrame(t *testing.T) {
+	gob.Register(&restartEvent{})
+
+	ctx := context.Background()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	events, list := newStores(t, dir)
+	next, err := events.CreateProperty(ctx, "corrupt", []platform.VersionedEvent{&restartEvent{Value: 1}}, list, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := events.NewPropertyEvents(ctx, "corrupt", next, []platform.VersionedEvent{&restartEvent{Value: 2}}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("flip a payload byte of the first frame, which has another frame after it")
+	fileName := filepath.Join(dir, eventsDirName, "corrupt"+eventsFileSuffix)
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[frameHeaderSize] ^= 0xff
+	if err := ioutil.WriteFile(fileName, data, filePermissions); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := events.GetEvents(ctx, "corrupt"); err == nil {
+		t.Fatal("expected reading a log with a corrupt frame to fail")
+	}
+
+	t.Log("an append is refused and nothing is truncated")
+	if _, err := events.NewPropertyEvents(ctx, "corrupt", 1, []platform.VersionedEvent{&restartEvent{Value: 3}}, false); err == nil {
+		t.Fatal("expected an append to a log with a corrupt frame to fail")
+	}
+	after, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after, data) {
+		t.Fatalf("expected the log to be unchanged, had %+v bytes and now has %+v", len(data), len(after))
+	}
+}
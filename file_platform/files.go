@@ -0,0 +1,78 @@
+package fileplatform
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const filePermissions = 0600
+const dirPermissions = 0700
+
+// writeFileAtomic replaces the file with the data, readers either see the old or the new contents
+func writeFileAtomic(fileName string, data []byte) error {
+	dir := filepath.Dir(fileName)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, fileName); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir makes file creates, renames and removes in the directory durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// writeGobFile atomically writes the gob encoding of value to the file
+func writeGobFile(fileName string, value interface{}) error {
+	stream := &bytes.Buffer{}
+	en := gob.NewEncoder(stream)
+	if err := en.Encode(value); err != nil {
+		return err
+	}
+	return writeFileAtomic(fileName, stream.Bytes())
+}
+
+// readGobFile decodes the file into value, returns false if the file does not exist yet
+func readGobFile(fileName string, value interface{}) (bool, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	dec := gob.NewDecoder(bytes.NewBuffer(data))
+	if err := dec.Decode(value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
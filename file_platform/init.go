@@ -0,0 +1,7 @@
+package fileplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/logger"
+)
+
+var logging = logger.New()
@@ -0,0 +1,90 @@
+package fileplatform
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+const propertyListFileName = "properties.gob"
+
+// persistedPropertyList is the record written to the property list file
+type persistedPropertyList struct {
+	// NextVersion only increases, so a deleted property does not free up its index
+	NextVersion  int
+	PropertyList []string
+}
+
+type filePropertyListImpl struct {
+	fileName string
+	mutex    sync.Mutex
+	list     persistedPropertyList
+}
+
+// NewPersistedPropertyList is the factory method to create a property list store in dir
+func NewPersistedPropertyList(dir string) (platform.PersistedPropertyList, error) {
+	if err := os.MkdirAll(dir, dirPermissions); err != nil {
+		return nil, err
+	}
+	r := &filePropertyListImpl{fileName: filepath.Join(dir, propertyListFileName)}
+	if _, err := readGobFile(r.fileName, &r.list); err != nil {
+		return nil, err
+	}
+	if r.list.PropertyList == nil {
+		r.list.PropertyList = []string{}
+	}
+	return r, nil
+}
+
+func (r *filePropertyListImpl) CreateProperty(ctx context.Context, propertyID string, idx int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if idx != r.list.NextVersion {
+		return errors.New("CreatePropertyRecords wrong idx")
+	}
+	list := persistedPropertyList{
+		NextVersion:  r.list.NextVersion + 1,
+		PropertyList: append(append([]string{}, r.list.PropertyList...), propertyID),
+	}
+	if err := writeGobFile(r.fileName, &list); err != nil {
+		return err
+	}
+	r.list = list
+	return nil
+}
+
+func (r *filePropertyListImpl) DeleteProperty(ctx context.Context, propertyID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	list := persistedPropertyList{NextVersion: r.list.NextVersion, PropertyList: []string{}}
+	for _, property := range r.list.PropertyList {
+		if property != propertyID {
+			list.PropertyList = append(list.PropertyList, property)
+		}
+	}
+	if err := writeGobFile(r.fileName, &list); err != nil {
+		return err
+	}
+	r.list = list
+	return nil
+}
+
+func (r *filePropertyListImpl) GetNextVersion(ctx context.Context) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.list.NextVersion, nil
+}
+
+func (r *filePropertyListImpl) GetProperties(ctx context.Context) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.list.PropertyList...), nil
+}
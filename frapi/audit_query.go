@@ -0,0 +1,547 @@
+package frapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+const auditLogGQL = `
+
+enum AuditEventType {
+	NEW_PROPERTY
+	UPDATE_SETTINGS
+	NEW_USER
+	UPDATE_USER
+	UPDATE_SYSTEM_USER
+	ACCEPT_INVITATION
+	ERASE_USER
+	NEW_RESERVATION
+	CANCEL_RESERVATION
+	NEW_RESTRICTION
+	UPDATE_MEMBERSHIP
+	UPDATE_BALANCE
+	NEW_NOTIFICATION
+	NOTIFICATION_DELIVERY
+	NOTIFICATION_COMMENT
+	NOTIFICATION_READ
+	ALL_NOTIFICATIONS_READ
+	UPDATE_NOTIFICATION_PREFERENCE
+	NEW_CONTENT
+	NEW_PAYMENT_PLAN
+	CANCEL_PAYMENT_PLAN
+	UPDATE_BANK_IMPORT_MAPPING
+	REVERSE_LEDGER_ENTRY
+}
+
+# a single value that changed as a result of an event
+type AuditChange {
+	field: String!
+	before: String!
+	after: String!
+}
+
+type AuditEntry {
+	eventVersion: Int!
+	eventType: AuditEventType!
+	# the user that made the change
+	authorUserId: String!
+	authorNickname: String!
+	eventDateTime: String!
+	# the user the change was made for, if any
+	targetUserId: String
+	description: String!
+	# before and after values, only for settings and user updates
+	changes: [AuditChange!]!
+}
+
+type AuditLog {
+	entries: [AuditEntry!]!
+	# true if there are more entries, use nextFromVersion to get the next page
+	hasMore: Boolean!
+	nextFromVersion: Int
+}
+`
+
+// AuditEventType is the type of an audit log entry, exported for GQL
+type AuditEventType string
+
+const (
+	newPropertyAuditEvent          AuditEventType = "NEW_PROPERTY"
+	updateSettingsAuditEvent       AuditEventType = "UPDATE_SETTINGS"
+	newUserAuditEvent              AuditEventType = "NEW_USER"
+	updateUserAuditEvent           AuditEventType = "UPDATE_USER"
+	updateSystemUserAuditEvent     AuditEventType = "UPDATE_SYSTEM_USER"
+	acceptInvitationAuditEvent     AuditEventType = "ACCEPT_INVITATION"
+	eraseUserAuditEvent            AuditEventType = "ERASE_USER"
+	newReservationAuditEvent       AuditEventType = "NEW_RESERVATION"
+	cancelReservationAuditEvent    AuditEventType = "CANCEL_RESERVATION"
+	newRestrictionAuditEvent       AuditEventType = "NEW_RESTRICTION"
+	updateMembershipAuditEvent     AuditEventType = "UPDATE_MEMBERSHIP"
+	updateBalanceAuditEvent        AuditEventType = "UPDATE_BALANCE"
+	newNotificationAuditEvent      AuditEventType = "NEW_NOTIFICATION"
+	notificationDeliveryAuditEvent AuditEventType = "NOTIFICATION_DELIVERY"
+	notificationCommentAuditEvent  AuditEventType = "NOTIFICATION_COMMENT"
+	notificationReadAuditEvent     AuditEventType = "NOTIFICATION_READ"
+	allNotificationsReadAuditEvent AuditEventType = "ALL_NOTIFICATIONS_READ"
+	updatePreferenceAuditEvent     AuditEventType = "UPDATE_NOTIFICATION_PREFERENCE"
+	newContentAuditEvent           AuditEventType = "NEW_CONTENT"
+	newPaymentPlanAuditEvent       AuditEventType = "NEW_PAYMENT_PLAN"
+	cancelPaymentPlanAuditEvent    AuditEventType = "CANCEL_PAYMENT_PLAN"
+	bankImportMappingAuditEvent    AuditEventType = "UPDATE_BANK_IMPORT_MAPPING"
+	reverseLedgerEntryAuditEvent   AuditEventType = "REVERSE_LEDGER_ENTRY"
+)
+
+// default and max number of entries returned in a single audit log page
+const auditLogDefaultPageSize = 50
+const auditLogMaxPageSize = 500
+
+type auditLogArgs struct {
+	FromVersion *int32
+	ToVersion   *int32
+	UserID      *string
+	EventType   *AuditEventType
+	First       *int32
+}
+
+// AuditLog is called by gql to list the property events as human readable entries
+func (r *PropertyResolver) AuditLog(args *auditLogArgs) (*AuditLogResolver, error) {
+
+	// validate
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, viewAuditLogPermission); err != nil {
+		return nil, err
+	}
+
+	if args.FromVersion != nil && args.ToVersion != nil && *args.FromVersion > *args.ToVersion {
+		return nil, fmt.Errorf("fromVersion (%+v) must be <= toVersion (%+v)", *args.FromVersion, *args.ToVersion)
+	}
+
+	pageSize := auditLogDefaultPageSize
+	if args.First != nil {
+		if *args.First <= 0 || *args.First > auditLogMaxPageSize {
+			return nil, fmt.Errorf("first (%+v) must be > 0 and <= %+v", *args.First, auditLogMaxPageSize)
+		}
+		pageSize = int(*args.First)
+	}
+
+	// rollup, settings and users are used for before and after values
+	r.rollupSettings()
+	r.rollupUsers()
+
+	// resolve
+	resolver := &AuditLogResolver{entries: []*AuditEntryResolver{}}
+	for _, event := range r.property.Events {
+		version := int32(event.GetEventVersion())
+		if args.FromVersion != nil && version < *args.FromVersion {
+			continue
+		}
+		if args.ToVersion != nil && version > *args.ToVersion {
+			break
+		}
+
+		entry := r.auditEntry(event)
+		if entry == nil {
+			continue
+		}
+		if args.EventType != nil && entry.eventType != *args.EventType {
+			continue
+		}
+		if args.UserID != nil && entry.authorUserID != *args.UserID &&
+			(entry.targetUserID == nil || *entry.targetUserID != *args.UserID) {
+			continue
+		}
+
+		if len(resolver.entries) == pageSize {
+			resolver.hasMore = true
+			resolver.nextFromVersion = &version
+			break
+		}
+		resolver.entries = append(resolver.entries, entry)
+	}
+
+	return resolver, nil
+}
+
+// AuditLogResolver resolves a page of audit log entries
+type AuditLogResolver struct {
+	entries         []*AuditEntryResolver
+	hasMore         bool
+	nextFromVersion *int32
+}
+
+// Entries are the audit log entries in event order
+func (r *AuditLogResolver) Entries() []*AuditEntryResolver {
+	return r.entries
+}
+
+// HasMore is true if there are more entries after this page
+func (r *AuditLogResolver) HasMore() bool {
+	return r.hasMore
+}
+
+// NextFromVersion is the fromVersion to use to get the next page
+func (r *AuditLogResolver) NextFromVersion() *int32 {
+	return r.nextFromVersion
+}
+
+// AuditEntryResolver resolves a single audit log entry
+type AuditEntryResolver struct {
+	eventVersion   int32
+	eventType      AuditEventType
+	authorUserID   string
+	authorNickname string
+	eventDateTime  string
+	targetUserID   *string
+	description    string
+	changes        []*AuditChangeResolver
+}
+
+// EventVersion is the version of the event
+func (r *AuditEntryResolver) EventVersion() int32 {
+	return r.eventVersion
+}
+
+// EventType is the type of the event
+func (r *AuditEntryResolver) EventType() AuditEventType {
+	return r.eventType
+}
+
+// AuthorUserID is the id of the user that made the change
+func (r *AuditEntryResolver) AuthorUserID() string {
+	return r.authorUserID
+}
+
+// AuthorNickname is the nickname of the user that made the change
+func (r *AuditEntryResolver) AuthorNickname() string {
+	return r.authorNickname
+}
+
+// EventDateTime is the time of the event
+func (r *AuditEntryResolver) EventDateTime() string {
+	return r.eventDateTime
+}
+
+// TargetUserID is the id of the user the change was made for
+func (r *AuditEntryResolver) TargetUserID() *string {
+	return r.targetUserID
+}
+
+// Description is the human readable description of the event
+func (r *AuditEntryResolver) Description() string {
+	return r.description
+}
+
+// Changes are the before and after values of the event
+func (r *AuditEntryResolver) Changes() []*AuditChangeResolver {
+	return r.changes
+}
+
+// AuditChangeResolver resolves a single changed value
+type AuditChangeResolver struct {
+	field  string
+	before string
+	after  string
+}
+
+// Field is the name of the changed value
+func (r *AuditChangeResolver) Field() string {
+	return r.field
+}
+
+// Before is the value before the event
+func (r *AuditChangeResolver) Before() string {
+	return r.before
+}
+
+// After is the value after the event
+func (r *AuditChangeResolver) After() string {
+	return r.after
+}
+
+// auditEntry converts an event to an audit entry, nil is returned for internal events
+func (r *PropertyResolver) auditEntry(event interface{}) *AuditEntryResolver {
+	entry := &AuditEntryResolver{changes: []*AuditChangeResolver{}}
+
+	switch event := event.(type) {
+	case *models.NewPropertyInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newPropertyAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("created property %v", event.PropertyName)
+		entry.changes = r.settingsAuditChanges(event.EventVersion, true)
+	case *models.UpdateSettingsInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updateSettingsAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = "updated settings"
+		entry.changes = r.settingsAuditChanges(event.EventVersion, false)
+	case *models.NewUserInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newUserAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UserId
+		entry.description = fmt.Sprintf("created user %v", r.auditNickname(event.UserId))
+		entry.changes = r.userAuditChanges(event.UserId, event.EventVersion)
+	case *models.UpdateUserInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updateUserAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UserId
+		entry.description = fmt.Sprintf("updated user %v", r.auditNickname(event.UserId))
+		entry.changes = r.userAuditChanges(event.UserId, event.EventVersion)
+	case *models.UpdateSystemUserInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updateSystemUserAuditEvent
+		entry.authorUserID = event.AuthorUserID
+		entry.targetUserID = &event.UserID
+		entry.description = fmt.Sprintf("updated system user %v", event.Nickname)
+		entry.changes = r.userAuditChanges(event.UserID, event.EventVersion)
+	case *models.AcceptInvitationInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = acceptInvitationAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.AuthorUserId
+		if event.Accept {
+			entry.description = "accepted invitation"
+		} else {
+			entry.description = "declined invitation"
+		}
+		entry.changes = r.userAuditChanges(event.AuthorUserId, event.EventVersion)
+	case *models.EraseUserInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = eraseUserAuditEvent
+		entry.authorUserID = event.AuthorUserID
+		entry.targetUserID = &event.UserID
+		entry.description = "erased user"
+		entry.changes = r.userAuditChanges(event.UserID, event.EventVersion)
+	case *models.NewReservationInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newReservationAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.ReservedForUserId
+		entry.description = fmt.Sprintf("created reservation %v to %v for %v",
+			event.StartDate, event.EndDate, r.auditNickname(event.ReservedForUserId))
+	case *models.CancelReservationInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = cancelReservationAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.ReservedForUserId
+		entry.description = fmt.Sprintf("canceled reservation %v for %v",
+			event.ReservationId, r.auditNickname(event.ReservedForUserId))
+	case *models.NewRestrictionInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newRestrictionAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("created restriction %v", event.Description)
+	case *models.UpdateMembershipStatusInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updateMembershipAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UpdateForUserId
+		action := "opted out of"
+		if event.Purchase {
+			action = "purchased"
+		}
+		entry.description = fmt.Sprintf("%v membership %v for %v",
+			action, event.RestrictionId, r.auditNickname(event.UpdateForUserId))
+		if event.ChargedAmount != nil {
+			entry.description += fmt.Sprintf(" charged %v", (&amountResolver{*event.ChargedAmount, r.moneyFormat()}).Decimal())
+		}
+	case *models.UpdateBalanceInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updateBalanceAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UpdateForUserId
+		action := "decreased"
+		if event.Increase {
+			action = "increased"
+		}
+		entry.description = fmt.Sprintf("%v balance of %v by %v: %v", action,
+			r.auditNickname(event.UpdateForUserId), (&amountResolver{event.Amount, r.moneyFormat()}).Decimal(), event.Description)
+		if event.BankTransactionId != "" {
+			entry.description += fmt.Sprintf(" (bank transfer %v)", event.BankTransactionId)
+		}
+		if event.ExpenseId != "" {
+			entry.description += fmt.Sprintf(" (split expense %v)", event.ExpenseId)
+		}
+	case *models.NewNotificationInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newNotificationAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("sent notification %v", event.TemplateName)
+	case *models.NotificationDeliveryInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = notificationDeliveryAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		if event.Delivered {
+			entry.description = fmt.Sprintf("delivered notification %v", event.NotificationId)
+		} else {
+			entry.description = fmt.Sprintf("failed to deliver notification %v: %v", event.NotificationId, event.Error)
+		}
+	case *models.NotificationCommentInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = notificationCommentAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("replied to notification %v", event.NotificationId)
+	case *models.NotificationReadInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = notificationReadAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("read notification %v", event.NotificationId)
+	case *models.AllNotificationsReadInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = allNotificationsReadAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = "read all notifications"
+	case *models.UpdateNotificationPreferenceInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = updatePreferenceAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("set %v notifications to %v", event.TemplateName, event.Preference)
+	case *models.NewContentInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newContentAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = fmt.Sprintf("updated content %v", event.Name)
+	case *models.NewPaymentPlanInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = newPaymentPlanAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UserId
+		entry.description = fmt.Sprintf("created payment plan %v with %v installments for %v",
+			event.Description, len(event.Installments), r.auditNickname(event.UserId))
+	case *models.CancelPaymentPlanInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = cancelPaymentPlanAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UserId
+		entry.description = fmt.Sprintf("canceled payment plan %v for %v",
+			event.PaymentPlanId, r.auditNickname(event.UserId))
+	case *models.BankImportMappingInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = bankImportMappingAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.description = "updated bank import mapping"
+	case *models.ReverseLedgerEntryInput:
+		entry.eventVersion = event.EventVersion
+		entry.eventType = reverseLedgerEntryAuditEvent
+		entry.authorUserID = event.AuthorUserId
+		entry.targetUserID = &event.UpdateForUserId
+		entry.description = fmt.Sprintf("reversed payment %v of %v by %v: %v", event.PaymentId,
+			r.auditNickname(event.UpdateForUserId), (&amountResolver{event.Amount, r.moneyFormat()}).Decimal(), event.Description)
+	default:
+		return nil
+	}
+
+	entry.eventDateTime = eventDateTime(event)
+	entry.authorNickname = r.auditNickname(entry.authorUserID)
+	return entry
+}
+
+// auditNickname returns the current nickname of a user, or the id if the user is unknown
+func (r *PropertyResolver) auditNickname(userID string) string {
+	users := r.Users(&usersArgs{UserID: &userID})
+	if len(users) != 1 {
+		return userID
+	}
+	return users[0].Nickname()
+}
+
+type auditField struct {
+	name  string
+	value string
+}
+
+func settingsAuditFields(settings *SettingsRollup) []auditField {
+	money := newMoneyFormat(settings.Currency, settings.Locale)
+	mandatory := []string{}
+	for _, name := range settings.MandatoryNotifications {
+		mandatory = append(mandatory, string(name))
+	}
+	return []auditField{
+		{"propertyName", settings.PropertyName},
+		{"currency", string(settings.Currency)},
+		{"memberRate", (&amountResolver{settings.MemberRate, money}).Decimal()},
+		{"allowNonMembers", strconv.FormatBool(settings.AllowNonMembers)},
+		{"nonMemberRate", (&amountResolver{settings.NonMemberRate, money}).Decimal()},
+		{"timezone", settings.Timezone},
+		{"minBalance", (&amountResolver{settings.MinBalance, money}).Decimal()},
+		{"maxOutDays", strconv.Itoa(int(settings.MaxOutDays))},
+		{"minInDays", strconv.Itoa(int(settings.MinInDays))},
+		{"reservationReminderDaysBefore", strconv.Itoa(int(settings.ReservationReminderDaysBefore))},
+		{"balanceReminderIntervalDays", strconv.Itoa(int(settings.BalanceReminderIntervalDays))},
+		{"membershipReminderDaysBefore", strconv.Itoa(int(settings.MembershipReminderDaysBefore))},
+		{"locale", settings.Locale},
+		{"mandatoryNotifications", strings.Join(mandatory, ",")},
+	}
+}
+
+func (r *PropertyResolver) userAuditFields(user *UserRollup) []auditField {
+	roles := []string{}
+	for _, role := range user.Roles {
+		roles = append(roles, string(role))
+	}
+	return []auditField{
+		{"nickname", user.Nickname},
+		{"email", r.property.EmailMap[user.EmailID]},
+		{"isAdmin", strconv.FormatBool(user.IsAdmin)},
+		{"isMember", strconv.FormatBool(user.IsMember)},
+		{"state", string(user.State)},
+		{"roles", strings.Join(roles, ",")},
+	}
+}
+
+// diffAuditFields returns the changed fields, a nil before means everything is new
+func diffAuditFields(before []auditField, after []auditField) []*AuditChangeResolver {
+	changes := []*AuditChangeResolver{}
+	for i, field := range after {
+		beforeValue := ""
+		if before != nil {
+			beforeValue = before[i].value
+		}
+		if beforeValue != field.value {
+			changes = append(changes, &AuditChangeResolver{field.name, beforeValue, field.value})
+		}
+	}
+	return changes
+}
+
+// settingsAuditChanges returns the settings changes, the initial settings have no version so created is used instead
+func (r *PropertyResolver) settingsAuditChanges(version int32, created bool) []*AuditChangeResolver {
+	id := settingsID
+	previousVersion := version - 1
+
+	var before []auditField
+	if !created {
+		if rollups := r.getRollups(&rollupArgs{id: &id, maxVersion: &previousVersion}, settingsRollupType); len(rollups) > 0 {
+			before = settingsAuditFields(rollups[0].(*SettingsRollup))
+		}
+	}
+
+	rollups := r.getRollups(&rollupArgs{id: &id, maxVersion: &version}, settingsRollupType)
+	if len(rollups) == 0 {
+		return []*AuditChangeResolver{}
+	}
+	return diffAuditFields(before, settingsAuditFields(rollups[0].(*SettingsRollup)))
+}
+
+func (r *PropertyResolver) userAuditChanges(userID string, version int32) []*AuditChangeResolver {
+	previousVersion := version - 1
+
+	var before []auditField
+	if rollups := r.getRollups(&rollupArgs{id: &userID, maxVersion: &previousVersion}, userRollupType); len(rollups) > 0 {
+		before = r.userAuditFields(rollups[0].(*UserRollup))
+	}
+
+	rollups := r.getRollups(&rollupArgs{id: &userID, maxVersion: &version}, userRollupType)
+	if len(rollups) == 0 {
+		return []*AuditChangeResolver{}
+	}
+	return diffAuditFields(before, r.userAuditFields(rollups[0].(*UserRollup)))
+}
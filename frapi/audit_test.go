@@ -0,0 +1,107 @@
+package frapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	t.Log("update the settings")
+	settings, _ := property.Settings(&settingsArgs{})
+	currency, _ := settings.Currency(ctx, &struct{ Format currencyFormat }{Format: acronym})
+	property, err := resolver.UpdateSettings(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateSettingsInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.UpdateSettingsInput{
+			ForVersion:                    property.EventVersion(),
+			PropertyName:                  "audited",
+			Currency:                      models.Currency(currency),
+			MemberRate:                    settings.memberRateInternal(),
+			AllowNonMembers:               settings.AllowNonMembers(),
+			NonMemberRate:                 settings.nonMemberRateInternal(),
+			Timezone:                      settings.Timezone(),
+			MinBalance:                    settings.minBalanceInternal().Raw(),
+			MaxOutDays:                    settings.MaxOutDays(),
+			MinInDays:                     settings.MinInDays(),
+			ReservationReminderDaysBefore: settings.ReservationReminderDaysBefore(),
+			BalanceReminderIntervalDays:   settings.BalanceReminderIntervalDays(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("add a reservation and a payment")
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	property = createPayment(ctx, t, resolver, property, 200, true, property.EventVersion())
+
+	t.Log("check the settings update shows before and after values")
+	eventType := updateSettingsAuditEvent
+	auditLog, err := property.AuditLog(&auditLogArgs{EventType: &eventType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auditLog.Entries()) != 1 {
+		t.Fatalf("expected 1 settings entry but got %+v", len(auditLog.Entries()))
+	}
+	changes := auditLog.Entries()[0].Changes()
+	if len(changes) != 1 || changes[0].Field() != "propertyName" || changes[0].After() != "audited" {
+		t.Fatalf("expected a single propertyName change but got %+v", changes)
+	}
+	if auditLog.Entries()[0].AuthorUserID() != me.UserID() {
+		t.Fatalf("expected author to be me")
+	}
+
+	t.Log("check the user filter")
+	userID := me.UserID()
+	auditLog, err = property.AuditLog(&auditLogArgs{UserID: &userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range auditLog.Entries() {
+		if entry.AuthorUserID() != userID && (entry.TargetUserID() == nil || *entry.TargetUserID() != userID) {
+			t.Fatalf("unexpected entry for user filter: %+v", entry.Description())
+		}
+	}
+
+	t.Log("check pagination covers all the entries")
+	auditLog, err = property.AuditLog(&auditLogArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(auditLog.Entries())
+
+	one := int32(1)
+	count := 0
+	var fromVersion *int32
+	for {
+		page, err := property.AuditLog(&auditLogArgs{FromVersion: fromVersion, First: &one})
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(page.Entries())
+		if !page.HasMore() {
+			break
+		}
+		fromVersion = page.NextFromVersion()
+	}
+	if count != total {
+		t.Fatalf("expected %+v paged entries but got %+v", total, count)
+	}
+
+	t.Log("check a member cannot view the audit log")
+	memberEmail := "member@test.com"
+	createUser(ctx, t, resolver, property, memberEmail, "member")
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, err := property.AuditLog(&auditLogArgs{}); err == nil {
+		t.Fatal("expected member audit log to fail")
+	}
+}
@@ -0,0 +1,147 @@
+package frapi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// bankMatchThreshold is the lowest score of a proposed payer match
+const bankMatchThreshold = 80
+
+// minMatchNameLength is the shortest nickname or email name that is matched, shorter ones match too many words
+const minMatchNameLength = 3
+
+// bankMatch is the user a bank transfer most likely came from
+type bankMatch struct {
+	user  *UserResolver
+	score int32
+}
+
+// matchPayer finds the user whose nickname or email best matches the payer name or the reference of a
+// transfer, nil if no user scores at least bankMatchThreshold or two users score the same
+func matchPayer(users []*UserResolver, payer string, reference string) *bankMatch {
+	var best *bankMatch
+	tie := false
+	for _, user := range users {
+		score := payerScore(user, payer, reference)
+		if score < bankMatchThreshold {
+			continue
+		}
+		switch {
+		case best == nil || score > best.score:
+			best = &bankMatch{user: user, score: score}
+			tie = false
+		case score == best.score:
+			tie = true
+		}
+	}
+	if tie {
+		return nil
+	}
+	return best
+}
+
+// payerScore is 100 when the nickname, email or the name part of the email is in the payer name or the
+// reference, otherwise how closely the words of the nickname match the words of the payer name or reference
+func payerScore(user *UserResolver, payer string, reference string) int32 {
+	email := strings.ToLower(user.Email())
+	names := []string{normalizeName(user.Nickname())}
+	if at := strings.Index(email, "@"); at > 0 {
+		names = append(names, normalizeName(email[:at]))
+	}
+
+	score := int32(0)
+	for _, text := range []string{payer, reference} {
+		if email != "" && strings.Contains(strings.ToLower(text), email) {
+			return 100
+		}
+		text = normalizeName(text)
+		if text == "" {
+			continue
+		}
+		for _, name := range names {
+			if len(name) < minMatchNameLength {
+				continue
+			}
+			if strings.Contains(" "+text+" ", " "+name+" ") {
+				return 100
+			}
+			if wordScore := wordsScore(name, text); wordScore > score {
+				score = wordScore
+			}
+		}
+	}
+	return score
+}
+
+// normalizeName lower cases the letters and digits of a name and separates the words with single spaces
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// wordsScore is the average similarity (0 to 100) of each word of the name to the closest word of the text
+func wordsScore(name string, text string) int32 {
+	nameWords := strings.Fields(name)
+	textWords := strings.Fields(text)
+	if len(nameWords) == 0 || len(textWords) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, nameWord := range nameWords {
+		best := 0
+		for _, textWord := range textWords {
+			if similarity := wordSimilarity(nameWord, textWord); similarity > best {
+				best = similarity
+			}
+		}
+		total += best
+	}
+	return int32(total / len(nameWords))
+}
+
+// wordSimilarity is 100 for the same words, less by the share of characters that must be edited to match
+func wordSimilarity(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 100
+	}
+	return 100 - 100*editDistance(ra, rb)/longest
+}
+
+// editDistance is the Levenshtein distance of two words
+func editDistance(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
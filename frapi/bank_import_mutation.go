@@ -0,0 +1,366 @@
+package frapi
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/templates"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+// the largest bank statement and the most transfers that can be imported at once
+const (
+	maxBankStatementSize = 1 << 20
+	maxBankTransfers     = 500
+)
+
+// defaultBankTransferDescription is the proposed description of a transfer without a usable reference
+const defaultBankTransferDescription = "Bank transfer"
+
+// UpdateBankImportMapping is called by an admin or treasurer to save how bank statement columns map to payments
+func (r *Resolver) UpdateBankImportMapping(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.BankImportMappingInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Update Bank Import Mapping")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	if args.Input.Delimiter != nil {
+		if utf8.RuneCountInString(*args.Input.Delimiter) != 1 || strings.ContainsAny(*args.Input.Delimiter, "\"\r\n") {
+			return nil, fmt.Errorf("invalid delimiter %+v", *args.Input.Delimiter)
+		}
+	}
+
+	for _, column := range []*string{&args.Input.TransactionIdColumn, &args.Input.AmountColumn, &args.Input.PayerColumn} {
+		trimmed, err := trim(*column)
+		if err != nil {
+			return nil, errors.New("the transaction id, amount and payer columns are required")
+		}
+		*column = *trimmed
+	}
+	for _, column := range []**string{&args.Input.DateColumn, &args.Input.ReferenceColumn} {
+		if *column != nil {
+			*column, _ = trim(**column)
+		}
+	}
+
+	// input looks good, now add extra internal values
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserId = me.UserID()
+
+	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
+}
+
+// ProposeBankImport is called by an admin or treasurer with a bank statement CSV, the transfers are returned
+// with the users they most likely came from for review, nothing is saved
+func (r *Resolver) ProposeBankImport(ctx context.Context, args *struct {
+	PropertyID string
+	File       Upload
+}) ([]*BankImportProposalResolver, error) {
+	Logger.LogDebugf("Propose Bank Import")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	mapping, err := property.bankImportMapping()
+	if err != nil {
+		return nil, err
+	}
+	if mapping == nil {
+		return nil, errors.New("save a bank import mapping first")
+	}
+
+	file, err := args.File.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	money := property.moneyFormat()
+	transfers, err := readBankStatement(io.LimitReader(file, maxBankStatementSize), mapping, money)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, err := property.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*UserResolver{}
+	for _, user := range property.Users(&usersArgs{}) {
+		if !user.IsSystem() && !user.isErased() {
+			users = append(users, user)
+		}
+	}
+	imported := property.bankTransactionIDs()
+
+	proposals := []*BankImportProposalResolver{}
+	for _, transfer := range transfers {
+		reference := ""
+		if transfer.reference != nil {
+			reference = *transfer.reference
+		}
+		proposals = append(proposals, &BankImportProposalResolver{
+			transfer:    transfer,
+			match:       matchPayer(users, transfer.payer, reference),
+			description: bankTransferDescription(reference, constraints),
+			imported:    imported[transfer.transactionID],
+			money:       money,
+		})
+	}
+	return proposals, nil
+}
+
+// CommitBankImport is called by an admin or treasurer with the reviewed transfers of a bank statement,
+// the balances are updated in a single commit, transfers imported before are skipped
+func (r *Resolver) CommitBankImport(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.BankImportInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Commit Bank Import")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	if len(args.Input.Entries) > maxBankTransfers {
+		return nil, fmt.Errorf("at most %v transfers can be imported at once", maxBankTransfers)
+	}
+
+	constraints, err := property.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	imported := property.bankTransactionIDs()
+	events := []platform.VersionedEvent{}
+	notificationIDs := []string{}
+	createDateTime := frdate.CreateDateTimeUTC()
+	for _, entry := range args.Input.Entries {
+		transactionID := strings.TrimSpace(entry.TransactionId)
+		if transactionID == "" {
+			return nil, errors.New("transaction id is required")
+		}
+		if imported[transactionID] {
+			Logger.LogInfof("CommitBankImport: transaction %v already imported", transactionID)
+			continue
+		}
+		imported[transactionID] = true
+
+		if entry.Amount < constraints.AmountMin() {
+			return nil, fmt.Errorf("amount of transaction %v too small", transactionID)
+		}
+		if entry.Amount > constraints.AmountMax() {
+			return nil, fmt.Errorf("amount of transaction %v too big", transactionID)
+		}
+
+		description := strings.TrimSpace(entry.Description)
+		if len(description) < int(constraints.DescriptionMin()) {
+			return nil, fmt.Errorf("description of transaction %v too small", transactionID)
+		}
+		if len(description) > int(constraints.DescriptionMax()) {
+			return nil, fmt.Errorf("description of transaction %v too big", transactionID)
+		}
+
+		userID := entry.UpdateForUserId
+		users := property.Users(&usersArgs{UserID: &userID})
+		if len(users) != 1 || users[0].IsSystem() {
+			return nil, fmt.Errorf("user of transaction %v does not exist", transactionID)
+		}
+
+		updateBalanceInput := &models.UpdateBalanceInput{
+			UpdateForUserId:   userID,
+			Amount:            entry.Amount,
+			Description:       description,
+			Increase:          entry.Increase,
+			CreateDateTime:    createDateTime,
+			AuthorUserId:      me.UserID(),
+			PaymentId:         utilities.NewGUID(),
+			BankTransactionId: transactionID,
+		}
+
+		paramGroup := templates.Ledger
+		newNotificationInput := createNotificationRecord(notificationTargetMember, property, templates.BalanceChangeNotification,
+			&userID, &paramGroup, &userID)
+
+		events = append(events, updateBalanceInput, newNotificationInput)
+		notificationIDs = append(notificationIDs, newNotificationInput.NotificationId)
+	}
+
+	if len(events) == 0 {
+		return property, nil
+	}
+
+	property, err = commitChanges(ctx, args.PropertyID, property.EventVersion(), events...)
+	if err != nil {
+		return nil, err
+	}
+
+	// send the email notifications
+	for _, notificationID := range notificationIDs {
+		property = deliverNotification(ctx, property, notificationID)
+	}
+	return property, nil
+}
+
+// bankTransactionIDs returns the bank transfers already imported
+func (r *PropertyResolver) bankTransactionIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, event := range r.property.Events {
+		if updateBalanceInput, ok := event.(*models.UpdateBalanceInput); ok && updateBalanceInput.BankTransactionId != "" {
+			ids[updateBalanceInput.BankTransactionId] = true
+		}
+	}
+	return ids
+}
+
+// readBankStatement reads the transfers of a bank statement CSV with a header row, rows without
+// a transaction id or amount (ex. opening balance lines) are skipped
+func readBankStatement(reader io.Reader, mapping *BankImportMappingResolver, money *moneyFormat) ([]*bankTransfer, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma, _ = utf8.DecodeRuneInString(mapping.Delimiter())
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("bank statement header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	column := func(name *string) (int, error) {
+		if name == nil {
+			return -1, nil
+		}
+		i, ok := columns[strings.ToLower(*name)]
+		if !ok {
+			return -1, fmt.Errorf("bank statement has no %v column", *name)
+		}
+		return i, nil
+	}
+
+	transactionIDColumn, err := column(&mapping.input.TransactionIdColumn)
+	if err != nil {
+		return nil, err
+	}
+	amountColumn, err := column(&mapping.input.AmountColumn)
+	if err != nil {
+		return nil, err
+	}
+	payerColumn, err := column(&mapping.input.PayerColumn)
+	if err != nil {
+		return nil, err
+	}
+	dateColumn, err := column(mapping.input.DateColumn)
+	if err != nil {
+		return nil, err
+	}
+	referenceColumn, err := column(mapping.input.ReferenceColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	optionalField := func(record []string, i int) *string {
+		if value := field(record, i); value != "" {
+			return &value
+		}
+		return nil
+	}
+
+	transfers := []*bankTransfer{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bank statement: %v", err)
+		}
+
+		transactionID := field(record, transactionIDColumn)
+		amountText := field(record, amountColumn)
+		if transactionID == "" || amountText == "" {
+			continue
+		}
+		amount, err := money.parseAmount(amountText)
+		if err != nil {
+			return nil, fmt.Errorf("bank statement transaction %v: %v", transactionID, err)
+		}
+		if amount == 0 {
+			continue
+		}
+
+		transfers = append(transfers, &bankTransfer{
+			transactionID: transactionID,
+			date:          optionalField(record, dateColumn),
+			payer:         field(record, payerColumn),
+			reference:     optionalField(record, referenceColumn),
+			amount:        amount,
+		})
+		if len(transfers) > maxBankTransfers {
+			return nil, fmt.Errorf("a bank statement can have at most %v transfers", maxBankTransfers)
+		}
+	}
+	return transfers, nil
+}
+
+// bankTransferDescription proposes the reference as the ledger description, shortened to fit
+func bankTransferDescription(reference string, constraints *UpdateBalanceConstraints) string {
+	description := strings.Join(strings.Fields(reference), " ")
+	for len(description) > int(constraints.DescriptionMax()) {
+		_, size := utf8.DecodeLastRuneInString(description)
+		description = strings.TrimSpace(description[:len(description)-size])
+	}
+	if len(description) < int(constraints.DescriptionMin()) {
+		return defaultBankTransferDescription
+	}
+	return description
+}
@@ -0,0 +1,235 @@
+package frapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+const bankImportGQL = `
+# a file sent in a multipart request
+scalar Upload
+
+# how the columns of a bank statement CSV map to payments
+type BankImportMapping {
+	delimiter: String!
+	transactionIdColumn: String!
+	dateColumn: String
+	amountColumn: String!
+	payerColumn: String!
+	referenceColumn: String
+	author: User!
+	createDateTime: String!
+}
+
+# a transfer read from a bank statement, to be reviewed before it is imported with commitBankImport
+type BankImportProposal {
+	transactionId: String!
+	date: String
+	payer: String!
+	reference: String
+	amount(format: AmountFormat = DECIMAL): String!
+	# true for money received, false for money paid out
+	increase: Boolean!
+	# the user who most likely made the transfer, null if no user matched well enough
+	user: User
+	# how well the payer or reference matched the user, from 0 to 100
+	matchScore: Int!
+	description: String!
+	# true if the transfer was imported before, commitBankImport skips it
+	imported: Boolean!
+}
+`
+
+// Upload is a file sent in a multipart GQL request, the upload handler saves the file
+// and passes the file name and path as the value of the variable
+type Upload struct {
+	FileName string `json:"filename"`
+	MIMEType string `json:"mimetype"`
+	FilePath string `json:"filepath"`
+}
+
+// ImplementsGraphQLType maps the go type to the GQL scalar
+func (Upload) ImplementsGraphQLType(name string) bool {
+	return name == "Upload"
+}
+
+// UnmarshalGraphQL is called by the GQL framework with the variable set by the upload handler
+func (r *Upload) UnmarshalGraphQL(input interface{}) error {
+	fields, ok := input.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("upload must be a file, not %T", input)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, r)
+}
+
+// open returns the content of the uploaded file
+func (r *Upload) open() (io.ReadCloser, error) {
+	if r.FilePath == "" {
+		return nil, errors.New("no file uploaded")
+	}
+	return os.Open(r.FilePath)
+}
+
+// BankImportMapping is called by gql to get the saved bank statement mapping, nil if there is none
+func (r *PropertyResolver) BankImportMapping() (*BankImportMappingResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if !me.hasPermission(viewLedgersPermission) {
+		return nil, errors.New("user must be admin or treasurer to view the bank import mapping")
+	}
+	return r.bankImportMapping()
+}
+
+func (r *PropertyResolver) bankImportMapping() (*BankImportMappingResolver, error) {
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if settings.settings.BankImportMapping == nil {
+		return nil, nil
+	}
+	return &BankImportMappingResolver{input: settings.settings.BankImportMapping, property: r}, nil
+}
+
+// BankImportMappingResolver resolves the bank statement mapping
+type BankImportMappingResolver struct {
+	input    *models.BankImportMappingInput
+	property *PropertyResolver
+}
+
+// Delimiter separates the columns
+func (r *BankImportMappingResolver) Delimiter() string {
+	if r.input.Delimiter == nil {
+		return ","
+	}
+	return *r.input.Delimiter
+}
+
+// TransactionIDColumn is the header of the bank transfer id column
+func (r *BankImportMappingResolver) TransactionIDColumn() string {
+	return r.input.TransactionIdColumn
+}
+
+// DateColumn is the header of the transfer date column
+func (r *BankImportMappingResolver) DateColumn() *string {
+	return r.input.DateColumn
+}
+
+// AmountColumn is the header of the amount column
+func (r *BankImportMappingResolver) AmountColumn() string {
+	return r.input.AmountColumn
+}
+
+// PayerColumn is the header of the payer name column
+func (r *BankImportMappingResolver) PayerColumn() string {
+	return r.input.PayerColumn
+}
+
+// ReferenceColumn is the header of the reference column
+func (r *BankImportMappingResolver) ReferenceColumn() *string {
+	return r.input.ReferenceColumn
+}
+
+// Author is the admin who saved the mapping
+func (r *BankImportMappingResolver) Author() *UserResolver {
+	users := r.property.Users(&usersArgs{UserID: &r.input.AuthorUserId})
+	return users[0]
+}
+
+// CreateDateTime is when the mapping was saved
+func (r *BankImportMappingResolver) CreateDateTime() string {
+	return r.input.CreateDateTime
+}
+
+// bankTransfer is a row of a bank statement
+type bankTransfer struct {
+	transactionID string
+	date          *string
+	payer         string
+	reference     *string
+	// in minor units, negative for money paid out
+	amount int32
+}
+
+// BankImportProposalResolver resolves a proposed balance update for a bank transfer
+type BankImportProposalResolver struct {
+	transfer    *bankTransfer
+	match       *bankMatch
+	description string
+	imported    bool
+	money       *moneyFormat
+}
+
+// TransactionID is the id of the transfer at the bank
+func (r *BankImportProposalResolver) TransactionID() string {
+	return r.transfer.transactionID
+}
+
+// Date is the date of the transfer as written in the statement
+func (r *BankImportProposalResolver) Date() *string {
+	return r.transfer.date
+}
+
+// Payer is the account holder who made the transfer
+func (r *BankImportProposalResolver) Payer() string {
+	return r.transfer.payer
+}
+
+// Reference is the text entered with the transfer
+func (r *BankImportProposalResolver) Reference() *string {
+	return r.transfer.reference
+}
+
+// Amount is the size of the transfer, see Increase for the direction
+func (r *BankImportProposalResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.amount(), args.Format)
+}
+
+func (r *BankImportProposalResolver) amount() int32 {
+	if r.transfer.amount < 0 {
+		return -r.transfer.amount
+	}
+	return r.transfer.amount
+}
+
+// Increase is true for money received
+func (r *BankImportProposalResolver) Increase() bool {
+	return r.transfer.amount > 0
+}
+
+// User is the matched user, nil if none
+func (r *BankImportProposalResolver) User() *UserResolver {
+	if r.match == nil {
+		return nil
+	}
+	return r.match.user
+}
+
+// MatchScore is how well the user matched
+func (r *BankImportProposalResolver) MatchScore() int32 {
+	if r.match == nil {
+		return 0
+	}
+	return r.match.score
+}
+
+// Description is the proposed ledger description
+func (r *BankImportProposalResolver) Description() string {
+	return r.description
+}
+
+// Imported is true if the transfer is already in a ledger
+func (r *BankImportProposalResolver) Imported() bool {
+	return r.imported
+}
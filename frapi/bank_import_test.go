@@ -0,0 +1,152 @@
+package frapi
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func TestBankImport(t *testing.T) {
+	property, ctx, resolver, _, _ := initAndCreateTestProperty(context.Background(), t)
+	property, jane := createUser(ctx, t, resolver, property, "jane.doe@example.com", "Jane Doe")
+	property, bob := createUser(ctx, t, resolver, property, "robert@example.com", "Bob Smith")
+
+	file, err := ioutil.TempFile("", "bankimport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("\ufeffDate;Transaction ID;Name;Reference;Amount\n" +
+		"2026-10-01;T1;JANE DOE;October;25,00\n" +
+		"2026-10-02;T2;Bob Smyth;\"Rent; October\";1.234,50\n" +
+		"2026-10-03;T3;Acme Bank;Account fee;-3,50\n" +
+		"2026-10-04;T4;Unknown Person;;10,00\n" +
+		";;;Closing balance;\n")
+	file.Close()
+
+	proposeBankImport := func() ([]*BankImportProposalResolver, error) {
+		return resolver.ProposeBankImport(ctx, &struct {
+			PropertyID string
+			File       Upload
+		}{
+			PropertyID: property.PropertyID(),
+			File:       Upload{FileName: "statement.csv", FilePath: file.Name()},
+		})
+	}
+	commitBankImport := func(entries ...models.BankImportEntryInput) (*PropertyResolver, error) {
+		return resolver.CommitBankImport(ctx, &struct {
+			PropertyID string
+			Input      *models.BankImportInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input:      &models.BankImportInput{Entries: entries},
+		})
+	}
+
+	t.Log("a mapping must be saved first")
+	if _, err := proposeBankImport(); err == nil {
+		t.Fatal("expected an error without a mapping")
+	}
+
+	delimiter := ";"
+	dateColumn := "date"
+	referenceColumn := "Reference"
+	property, err = resolver.UpdateBankImportMapping(ctx, &struct {
+		PropertyID string
+		Input      *models.BankImportMappingInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.BankImportMappingInput{
+			ForVersion:          property.EventVersion(),
+			Delimiter:           &delimiter,
+			TransactionIdColumn: "Transaction ID",
+			DateColumn:          &dateColumn,
+			AmountColumn:        "Amount",
+			PayerColumn:         "Name",
+			ReferenceColumn:     &referenceColumn,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := property.BankImportMapping()
+	if err != nil || mapping == nil || mapping.Delimiter() != ";" {
+		t.Fatalf("expected the saved mapping: %+v %v", mapping, err)
+	}
+
+	t.Log("the transfers are proposed with the matching users")
+	proposals, err := proposeBankImport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 4 {
+		t.Fatalf("expected 4 proposals but got %v", len(proposals))
+	}
+	expected := []struct {
+		userID      string
+		amount      string
+		increase    bool
+		description string
+	}{
+		{jane.UserID(), "25.00", true, "October"},
+		{bob.UserID(), "1234.50", true, "Rent; October"},
+		{"", "3.50", false, "Account fee"},
+		{"", "10.00", true, defaultBankTransferDescription},
+	}
+	for i, proposal := range proposals {
+		userID := ""
+		if proposal.User() != nil {
+			userID = proposal.User().UserID()
+		}
+		amount, _ := proposal.Amount(&struct{ Format amountFormat }{})
+		if userID != expected[i].userID || amount != expected[i].amount || proposal.Increase() != expected[i].increase ||
+			proposal.Description() != expected[i].description || proposal.Imported() {
+			t.Fatalf("unexpected proposal %v: user %v amount %v increase %v description %v", proposal.TransactionID(),
+				userID, amount, proposal.Increase(), proposal.Description())
+		}
+	}
+	if proposals[0].MatchScore() != 100 || proposals[1].MatchScore() < bankMatchThreshold {
+		t.Fatalf("unexpected match scores %v %v", proposals[0].MatchScore(), proposals[1].MatchScore())
+	}
+
+	t.Log("the approved transfers are imported")
+	property, err = commitBankImport(
+		models.BankImportEntryInput{TransactionId: "T1", UpdateForUserId: jane.UserID(), Amount: 2500, Increase: true, Description: "October"},
+		models.BankImportEntryInput{TransactionId: "T4", UpdateForUserId: bob.UserID(), Amount: 1000, Increase: true, Description: "Bank transfer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLedger(ctx, t, property, jane.UserID(), 2, paymentLedgerEvent, 2500, 2500)
+	checkLedger(ctx, t, property, bob.UserID(), 2, paymentLedgerEvent, 1000, 1000)
+
+	proposals, err = proposeBankImport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proposals[0].Imported() || proposals[1].Imported() || !proposals[3].Imported() {
+		t.Fatal("expected T1 and T4 to be imported")
+	}
+
+	t.Log("imported transfers are skipped")
+	version := property.EventVersion()
+	property, err = commitBankImport(
+		models.BankImportEntryInput{TransactionId: "T1", UpdateForUserId: jane.UserID(), Amount: 2500, Increase: true, Description: "October"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if property.EventVersion() != version {
+		t.Fatal("expected the imported transfer to be skipped")
+	}
+
+	t.Log("the whole batch is rejected for a bad entry")
+	if _, err := commitBankImport(
+		models.BankImportEntryInput{TransactionId: "T2", UpdateForUserId: bob.UserID(), Amount: 5000, Increase: true, Description: "Rent"},
+		models.BankImportEntryInput{TransactionId: "T3", UpdateForUserId: "nobody", Amount: 350, Increase: false, Description: "Account fee"}); err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	checkLedger(ctx, t, property, bob.UserID(), 2, paymentLedgerEvent, 1000, 1000)
+}
@@ -0,0 +1,49 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// maxContactLength limits the size of a push subscription
+const maxContactLength = 2000
+
+// UpdateNotificationContact is called to set or clear (empty contact) my contact for a notification channel,
+// contacts are personal information so they are kept in the email store and not in the events
+func (r *Resolver) UpdateNotificationContact(ctx context.Context, args *struct {
+	PropertyID string
+	Channel    platform.NotificationChannel
+	Contact    string
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Update notification contact")
+
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := channelNotifier(args.Channel)
+	if notifier == nil {
+		return nil, fmt.Errorf("notification channel %v is not available", args.Channel)
+	}
+
+	contact := strings.TrimSpace(args.Contact)
+	if contact != "" {
+		if len(contact) > maxContactLength {
+			return nil, errors.New("contact is too long")
+		}
+		if err := notifier.ValidateContact(contact); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := PersistedEmailStore.SetContact(ctx, args.PropertyID, me.emailID(), args.Channel, contact); err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
@@ -0,0 +1,84 @@
+package frapi
+
+import (
+	"sort"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+const contactGQL = `
+enum NotificationChannel {
+	SMS
+	PUSH
+}
+
+# a way to reach me other than email, used for urgent notifications
+type NotificationContact {
+	channel: NotificationChannel!
+	# a phone number or the JSON of a browser push subscription
+	contact: String!
+}
+`
+
+// channelNotifier returns the notifier for the channel, nil if the channel is not configured
+func channelNotifier(channel platform.NotificationChannel) platform.Notifier {
+	for _, notifier := range Notifiers {
+		if notifier.Channel() == channel {
+			return notifier
+		}
+	}
+	return nil
+}
+
+// NotificationChannels is called by the GQL framework, returns the channels configured for the service
+func (r *PropertyResolver) NotificationChannels() []platform.NotificationChannel {
+	channels := []platform.NotificationChannel{}
+	for _, notifier := range Notifiers {
+		channels = append(channels, notifier.Channel())
+	}
+	return channels
+}
+
+// PushPublicKey is called by the GQL framework, returns the applicationServerKey for subscribing to web push
+func (r *PropertyResolver) PushPublicKey() *string {
+	if PushPublicKey == "" || channelNotifier(platform.PushChannel) == nil {
+		return nil
+	}
+	return &PushPublicKey
+}
+
+// NotificationContacts is called by the GQL framework, contacts are personal information so only mine are returned
+func (r *PropertyResolver) NotificationContacts() ([]*NotificationContactResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+
+	contacts, err := PersistedEmailStore.GetContacts(r.ctx, r.PropertyID(), me.emailID())
+	if err != nil {
+		return nil, err
+	}
+
+	l := []*NotificationContactResolver{}
+	for channel, contact := range contacts {
+		l = append(l, &NotificationContactResolver{channel: channel, contact: contact})
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].channel < l[j].channel })
+	return l, nil
+}
+
+// NotificationContactResolver resolves a contact for a notification channel
+type NotificationContactResolver struct {
+	channel platform.NotificationChannel
+	contact string
+}
+
+// Channel is called by the GQL framework, see contactGQL
+func (r *NotificationContactResolver) Channel() platform.NotificationChannel {
+	return r.channel
+}
+
+// Contact is called by the GQL framework, see contactGQL
+func (r *NotificationContactResolver) Contact() string {
+	return r.contact
+}
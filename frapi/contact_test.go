@@ -0,0 +1,142 @@
+package frapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// testNotifier records the notifications sent on a channel
+type testNotifier struct {
+	channel platform.NotificationChannel
+	sent    []*platform.Notification
+	// invalid is the contact rejected by ValidateContact
+	invalid string
+	// err is returned by Notify, after recording the notification
+	err error
+}
+
+func (r *testNotifier) Channel() platform.NotificationChannel {
+	return r.channel
+}
+
+func (r *testNotifier) ValidateContact(contact string) error {
+	if contact == r.invalid {
+		return fmt.Errorf("invalid %v contact", r.channel)
+	}
+	return nil
+}
+
+func (r *testNotifier) Notify(ctx context.Context, notification *platform.Notification) error {
+	r.sent = append(r.sent, notification)
+	return r.err
+}
+
+func updateNotificationContact(ctx context.Context, resolver *Resolver, property *PropertyResolver, channel platform.NotificationChannel, contact string) (*PropertyResolver, error) {
+	return resolver.UpdateNotificationContact(ctx, &struct {
+		PropertyID string
+		Channel    platform.NotificationChannel
+		Contact    string
+	}{
+		PropertyID: property.PropertyID(),
+		Channel:    channel,
+		Contact:    contact,
+	})
+}
+
+func TestNotificationContacts(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	phone := "+15555550100"
+
+	t.Log("a channel that is not configured cannot be set")
+	if _, err := updateNotificationContact(ctx, resolver, property, platform.SMSChannel, phone); err == nil {
+		t.Fatal("expected an unconfigured channel to fail")
+	}
+
+	sms := &testNotifier{channel: platform.SMSChannel, invalid: "555-0100"}
+	push := &testNotifier{channel: platform.PushChannel, invalid: `{"endpoint": "http://push.example.com/abc"}`}
+	Notifiers = []platform.Notifier{sms, push}
+	defer func() { Notifiers = nil }()
+
+	if len(property.NotificationChannels()) != 2 {
+		t.Fatalf("expected 2 channels but got %+v", property.NotificationChannels())
+	}
+
+	t.Log("contacts are validated")
+	invalid := map[platform.NotificationChannel]string{
+		platform.SMSChannel:  sms.invalid,
+		platform.PushChannel: push.invalid,
+	}
+	for channel, contact := range invalid {
+		if _, err := updateNotificationContact(ctx, resolver, property, channel, contact); err == nil {
+			t.Fatalf("expected %v contact %v to fail", channel, contact)
+		}
+	}
+
+	property, err := updateNotificationContact(ctx, resolver, property, platform.SMSChannel, phone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := `{"endpoint": "https://push.example.com/abc", "keys": {"p256dh": "key", "auth": "secret"}}`
+	if property, err = updateNotificationContact(ctx, resolver, property, platform.PushChannel, subscription); err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := property.NotificationContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 2 || contacts[1].Channel() != platform.SMSChannel || contacts[1].Contact() != phone {
+		t.Fatalf("unexpected contacts %+v", contacts)
+	}
+
+	t.Log("cancelling a stay later on is not urgent")
+	property, reservations := createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(10).ToString(), today.AddDays(12).ToString())
+	property, _ = cancelReservation(ctx, t, resolver, property, reservations[0].ReservationID(), true, property.EventVersion())
+	if len(sms.sent) != 0 || len(push.sent) != 0 {
+		t.Fatalf("expected no urgent notifications but got %+v", sms.sent)
+	}
+
+	t.Log("cancelling a stay tomorrow is urgent")
+	property, reservations = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	// reservations are in descending order
+	property, _ = cancelReservation(ctx, t, resolver, property, reservations[len(reservations)-1].ReservationID(), true, property.EventVersion())
+	if len(sms.sent) != 1 || len(push.sent) != 1 {
+		t.Fatalf("expected an sms and a push notification but got %+v and %+v", sms.sent, push.sent)
+	}
+	if sms.sent[0].To != phone || push.sent[0].To != subscription {
+		t.Fatalf("unexpected recipients %v and %v", sms.sent[0].To, push.sent[0].To)
+	}
+	if !strings.Contains(sms.sent[0].Body, today.AddDays(1).ToString()) {
+		t.Fatalf("expected the check in date in %v", sms.sent[0].Body)
+	}
+
+	t.Log("an expired push subscription is removed")
+	push.err = platform.ErrSubscriptionExpired
+	property, reservations = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	// the stay cancelled above has the same dates
+	for _, reservation := range reservations {
+		if !reservation.Canceled() && reservation.StartDate() == today.AddDays(1).ToString() {
+			property, _ = cancelReservation(ctx, t, resolver, property, reservation.ReservationID(), true, property.EventVersion())
+		}
+	}
+	if len(push.sent) != 2 {
+		t.Fatalf("expected a second push notification but got %+v", push.sent)
+	}
+	contacts, _ = property.NotificationContacts()
+	if len(contacts) != 1 || contacts[0].Channel() != platform.SMSChannel {
+		t.Fatalf("expected only the sms contact but got %+v", contacts)
+	}
+
+	t.Log("an empty contact clears the channel")
+	if property, err = updateNotificationContact(ctx, resolver, property, platform.SMSChannel, ""); err != nil {
+		t.Fatal(err)
+	}
+	contacts, _ = property.NotificationContacts()
+	if len(contacts) != 0 {
+		t.Fatalf("expected 1 contact but got %+v", contacts)
+	}
+}
@@ -28,8 +28,8 @@ func (r *Resolver) CreateContent(ctx context.Context, args *struct {
 		return nil, err
 	}
 
-	if !me.IsAdmin() {
-		return nil, fmt.Errorf("admin required for this call")
+	if err := authorize(me, manageContentPermission); err != nil {
+		return nil, err
 	}
 
 	if args.Input == nil {
@@ -48,6 +48,22 @@ func (r *Resolver) CreateContent(ctx context.Context, args *struct {
 	}
 	args.Input.Comment = *stringArg
 
+	if args.Input.Subject != nil {
+		if !isNotificationContent(args.Input.Name) {
+			return nil, fmt.Errorf("subject is only valid for notification contents")
+		}
+		stringArg, err = trim(*args.Input.Subject)
+		if err != nil {
+			return nil, err
+		}
+		args.Input.Subject = stringArg
+	}
+
+	// dry run the templates against sample data so a bad template is never sent
+	if _, _, err := property.renderContent(args.Input.Name, args.Input.Subject, args.Input.Template); err != nil {
+		return nil, fmt.Errorf("invalid content: %v", err)
+	}
+
 	// input looks good, now add extra internal values
 	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
 	args.Input.AuthorUserId = me.UserID()
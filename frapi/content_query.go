@@ -14,11 +14,15 @@ import (
 const contentGQL = `
 type Content {
 	name: ContentName!
+	# rendered with sample data for notification contents
 	rendered: String!
+	# null for the home page contents
+	subject: String
 	template: String!
 	comment: String!
 	createDateTime: String!
 	author: User!
+	defaultSubject: String
 	defaultTemplate: String!
 	default: Boolean!
 }
@@ -26,73 +30,103 @@ type Content {
 enum ContentName {
 	ADMIN_HOME
 	MEMBER_HOME
+	NOTIFICATION_NEW_PROPERTY
+	NEW_RESERVATION
+	CANCEL_RESERVATION
+	BALANCE_INCREASE
+	BALANCE_NOTIFICATION
+	DIGEST
+	MEMBERSHIP_PREPAY_OPEN
+	MEMBERSHIP_RENEWAL_REMINDER
+	MEMBERSHIP_GRACE_PERIOD_ENDING
+	PAYMENT_PLAN_MISSED
+}
+`
+
+const contentPreviewGQL = `
+type ContentPreview {
+	subject: String
+	body: String!
+	htmlBody: String
 }
 `
 
 // Contents is called by the GQL framework to retrieve all the display content strings
 func (r *PropertyResolver) Contents() ([]*ContentResolver, error) {
+	return r.contentsHelper([]models.ContentName{models.ADMIN_HOME, models.MEMBER_HOME}), nil
+}
 
-	r.rollupContent()
-
-	// default home page content
-	adminHome := &ContentResolver{name: models.ADMIN_HOME, property: r}
-	memberHome := &ContentResolver{name: models.MEMBER_HOME, property: r}
-
-	// get rollups
-	ifaces := r.getRollups(&rollupArgs{}, contentsRollupType)
-	for _, iface := range ifaces {
-		rollup := iface.(*ContentRollup)
-		if rollup.Input.Name == models.ADMIN_HOME {
-			adminHome.rollup = rollup
-		} else {
-			memberHome.rollup = rollup
-		}
+// NotificationContents is called by the GQL framework to retrieve the notification email contents
+func (r *PropertyResolver) NotificationContents() ([]*ContentResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, manageContentPermission); err != nil {
+		return nil, err
 	}
 
-	l := []*ContentResolver{}
-	l = append(l, adminHome)
-	l = append(l, memberHome)
-
-	return l, nil
+	return r.contentsHelper(models.NotificationContentNames), nil
 }
 
-// ContentResolver resolves a single content string
-type ContentResolver struct {
-	rollup   *ContentRollup
-	name     models.ContentName
-	property *PropertyResolver
+func (r *PropertyResolver) contentsHelper(names []models.ContentName) []*ContentResolver {
+	l := []*ContentResolver{}
+	for _, name := range names {
+		l = append(l, &ContentResolver{name: name, property: r, rollup: r.contentOverride(name, nil)})
+	}
+	return l
 }
 
-// Name is called by the GQL framework, see contentGQL
-func (r *ContentResolver) Name() models.ContentName {
-	return r.name
-}
+// contentOverride returns the content set by an admin as of the max version, nil if the default is used
+func (r *PropertyResolver) contentOverride(name models.ContentName, maxVersion *int32) *ContentRollup {
+	r.rollupContent()
 
-// Rendered is called by the GQL framework, see contentGQL
-func (r *ContentResolver) Rendered() (string, error) {
-	// get defaults
-	member, admin, templateParamGroups := templates.GetNotificationTemplate(templates.CurrentTemplateVersion, templates.HomePageContents)
+	id := string(name)
+	ifaces := r.getRollups(&rollupArgs{id: &id, maxVersion: maxVersion}, contentsRollupType)
+	if len(ifaces) == 0 {
+		return nil
+	}
+	return ifaces[0].(*ContentRollup)
+}
 
-	templateText := member
-	if r.name == models.ADMIN_HOME {
-		templateText = admin
+// isNotificationContent returns true if the content overrides a notification email template
+func isNotificationContent(name models.ContentName) bool {
+	for _, notificationName := range models.NotificationContentNames {
+		if name == notificationName {
+			return true
+		}
 	}
+	return false
+}
 
-	// override default if set by user
-	if r.rollup != nil {
-		templateText = r.rollup.Input.Template
+// defaultContent returns the default subject, template and params of a content,
+// subject is nil for the home page contents
+func defaultContent(name models.ContentName) (*string, string, []templates.TemplateParamGroup) {
+	if isNotificationContent(name) {
+		subject, body, templateParamGroups := templates.GetNotificationTemplate(templates.CurrentTemplateVersion, templates.TemplateName(name))
+		return &subject, body, templateParamGroups
 	}
 
-	template, err := template.New("").Parse(templateText)
-	if err != nil {
-		return "", err
+	member, admin, templateParamGroups := templates.GetNotificationTemplate(templates.CurrentTemplateVersion, templates.HomePageContents)
+	if name == models.ADMIN_HOME {
+		return nil, admin, templateParamGroups
 	}
+	return nil, member, templateParamGroups
+}
 
+// contentTemplateParams returns the params for rendering content, notification contents
+// are rendered against sample data for me
+func (r *PropertyResolver) contentTemplateParams(templateParamGroups []templates.TemplateParamGroup) (map[string]interface{}, error) {
 	// the params to pass into the template
 	paramsMap := make(map[string]interface{})
-	me, err := r.property.Me()
+	me, err := r.Me()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
 	}
 
 	for _, paramGroupName := range templateParamGroups {
@@ -103,37 +137,111 @@ func (r *ContentResolver) Rendered() (string, error) {
 		case templates.Ledger:
 			last := int32(1)
 			userID := me.UserID()
-			userRecords, _ := r.property.Ledgers(&ledgersArgs{UserID: &userID, Last: &last})
+			userRecords, _ := r.Ledgers(&ledgersArgs{UserID: &userID, Last: &last})
+			if len(userRecords) != 1 {
+				return nil, errors.New("expected a ledger")
+			}
 
 			ledgers := userRecords[0].Records()
-
-			// ledgers, err := r.property.Ledgers(&struct {
-			// 	UserId  string
-			// 	Last    *int32
-			// 	Reverse *bool
-			// }{
-			// 	UserId: me.UserId(),
-			// 	Last:   &last,
-			// })
-			// if err != nil {
-			// 	return "", err
-			// }
 			if len(ledgers) != 1 {
-				return "", errors.New("expected a ledger")
+				return nil, errors.New("expected a ledger")
 			}
 
 			paramsMap[string(paramGroupName)] = ledgers[0]
+			paramsMap["User"] = me
+
 		case templates.Settings:
-			settings, err := r.property.Settings(&settingsArgs{})
-			if err != nil {
-				return "", err
-			}
 			paramsMap[string(paramGroupName)] = settings
 
 		case templates.Decimal:
 			paramsMap[string(paramGroupName)] = &struct{ Format amountFormat }{Format: decimal}
 
+		case templates.Amount:
+			paramsMap[string(paramGroupName)] = &struct{ Format amountFormat }{Format: localizedSymbol}
+
+		case templates.Reservation:
+			today := frdate.MustNewDateBuilder(settings.Timezone()).Today()
+			paramsMap[string(paramGroupName)] = &ReservationResolver{
+				rollup: &ReservationRollup{
+					Input: &models.NewReservationInput{
+						ReservationId:     "sample",
+						ReservedForUserId: me.UserID(),
+						StartDate:         today.AddDays(1).ToString(),
+						EndDate:           today.AddDays(3).ToString(),
+						Member:            true,
+						CreateDateTime:    frdate.CreateDateTimeUTC(),
+						AuthorUserId:      me.UserID(),
+					},
+				},
+				property: r,
+				args:     &reservationsArgs{},
+			}
+
+		case templates.Links:
+			paramsMap[string(paramGroupName)] = notificationLinks()
+
+		case templates.Digest:
+			summary, err := r.digestSummary(me.UserID(), frdate.CreateDateTimeUTC(), nil)
+			if err != nil {
+				return nil, err
+			}
+			paramsMap[string(paramGroupName)] = summary
+
+		case templates.Membership:
+			first, last := frdate.MustNewDateBuilder(settings.Timezone()).Today().AddDays(365).YearInOut()
+			paramsMap[string(paramGroupName)] = &membershipNotice{
+				Description:        "sample",
+				PrePayStartDate:    first.AddDays(-30).ToString(),
+				InDate:             first.ToString(),
+				OutDate:            last.ToString(),
+				GracePeriodOutDate: first.AddDays(30).ToString(),
+			}
+
+		case templates.PaymentPlan:
+			paramsMap[string(paramGroupName)] = &paymentPlanNotice{
+				Description: "sample",
+				DueDate:     frdate.MustNewDateBuilder(settings.Timezone()).Today().AddDays(-1).ToString(),
+				Amount:      "100.00",
+				Paid:        "200.00",
+				Total:       "600.00",
+			}
+		}
+	}
+
+	return paramsMap, nil
+}
+
+// renderContent renders the subject (if any) and template of content, missing params are an error
+// so that content is validated before it is used
+func (r *PropertyResolver) renderContent(name models.ContentName, subject *string, templateText string) (*string, string, error) {
+	_, _, templateParamGroups := defaultContent(name)
+
+	paramsMap, err := r.contentTemplateParams(templateParamGroups)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var renderedSubject *string
+	if subject != nil {
+		rendered, err := executeContentTemplate(*subject, paramsMap)
+		if err != nil {
+			return nil, "", fmt.Errorf("subject: %v", err)
 		}
+		renderedSubject = &rendered
+	}
+
+	body, err := executeContentTemplate(templateText, paramsMap)
+	if err != nil {
+		return nil, "", fmt.Errorf("template: %v", err)
+	}
+
+	return renderedSubject, body, nil
+}
+
+func executeContentTemplate(templateText string, paramsMap map[string]interface{}) (string, error) {
+	template, err := template.New("").Option("missingkey=error").Parse(templateText)
+	if err != nil {
+		return "", err
 	}
 
 	var buffer bytes.Buffer
@@ -144,6 +252,107 @@ func (r *ContentResolver) Rendered() (string, error) {
 	return buffer.String(), nil
 }
 
+type previewContentArgs struct {
+	Name     models.ContentName
+	Subject  *string
+	Template string
+}
+
+// PreviewContent is called by the GQL framework, renders content against sample data before it is saved
+func (r *PropertyResolver) PreviewContent(args *previewContentArgs) (*ContentPreviewResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, manageContentPermission); err != nil {
+		return nil, err
+	}
+
+	if args.Subject != nil && !isNotificationContent(args.Name) {
+		return nil, fmt.Errorf("subject is only valid for notification contents")
+	}
+
+	// notification contents without a subject keep the default subject
+	subject := args.Subject
+	if subject == nil {
+		subject, _, _ = defaultContent(args.Name)
+	}
+
+	renderedSubject, body, err := r.renderContent(args.Name, subject, args.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ContentPreviewResolver{subject: renderedSubject, body: body}
+	if isNotificationContent(args.Name) {
+		paramsMap, err := r.contentTemplateParams([]templates.TemplateParamGroup{templates.Settings, templates.Links})
+		if err != nil {
+			return nil, err
+		}
+		htmlBody, err := customNotificationHTMLBody(body, paramsMap)
+		if err != nil {
+			return nil, err
+		}
+		preview.htmlBody = &htmlBody
+	}
+
+	return preview, nil
+}
+
+// ContentPreviewResolver resolves a content preview
+type ContentPreviewResolver struct {
+	subject  *string
+	body     string
+	htmlBody *string
+}
+
+// Subject is called by the GQL framework, see contentPreviewGQL
+func (r *ContentPreviewResolver) Subject() *string {
+	return r.subject
+}
+
+// Body is called by the GQL framework, see contentPreviewGQL
+func (r *ContentPreviewResolver) Body() string {
+	return r.body
+}
+
+// HTMLBody is called by the GQL framework, see contentPreviewGQL
+func (r *ContentPreviewResolver) HTMLBody() *string {
+	return r.htmlBody
+}
+
+// ContentResolver resolves a single content string
+type ContentResolver struct {
+	rollup   *ContentRollup
+	name     models.ContentName
+	property *PropertyResolver
+}
+
+// Name is called by the GQL framework, see contentGQL
+func (r *ContentResolver) Name() models.ContentName {
+	return r.name
+}
+
+// Rendered is called by the GQL framework, see contentGQL
+func (r *ContentResolver) Rendered() (string, error) {
+	_, body, err := r.property.renderContent(r.name, nil, r.Template())
+	return body, err
+}
+
+// Subject is called by the GQL framework, see contentGQL
+func (r *ContentResolver) Subject() *string {
+	if r.rollup != nil && r.rollup.Input.Subject != nil {
+		return r.rollup.Input.Subject
+	}
+	return r.DefaultSubject()
+}
+
+// DefaultSubject is called by the GQL framework, see contentGQL
+func (r *ContentResolver) DefaultSubject() *string {
+	subject, _, _ := defaultContent(r.name)
+	return subject
+}
+
 // Template is called by the GQL framework, see contentGQL
 func (r *ContentResolver) Template() string {
 	if r.rollup != nil {
@@ -189,14 +398,8 @@ func (r *ContentResolver) Author() (*UserResolver, error) {
 
 // DefaultTemplate is called by the GQL framework, see contentGQL
 func (r *ContentResolver) DefaultTemplate() string {
-	member, admin, _ := templates.GetNotificationTemplate(templates.CurrentTemplateVersion, templates.HomePageContents)
-	switch r.name {
-	case models.ADMIN_HOME:
-		return admin
-	case models.MEMBER_HOME:
-		return member
-	}
-	return ""
+	_, templateText, _ := defaultContent(r.name)
+	return templateText
 }
 
 // Default is called by the GQL framework, see contentGQL
@@ -2,6 +2,7 @@ package frapi
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/bjorge/friendlyreservations/models"
@@ -111,3 +112,150 @@ func TestCustomContent(t *testing.T) {
 	}
 
 }
+
+func createNotificationContent(ctx context.Context, resolver *Resolver, property *PropertyResolver, name models.ContentName, subject *string, template string) (*PropertyResolver, error) {
+	return resolver.CreateContent(ctx, &struct {
+		PropertyID string
+		Input      *models.NewContentInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewContentInput{
+			ForVersion: property.EventVersion(),
+			Name:       name,
+			Subject:    subject,
+			Template:   template,
+			Comment:    "custom notification",
+		},
+	})
+}
+
+func TestNotificationContent(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	contents, err := property.NotificationContents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != len(models.NotificationContentNames) {
+		t.Fatalf("expected %+v notification contents but got %+v", len(models.NotificationContentNames), len(contents))
+	}
+	for _, content := range contents {
+		if !content.Default() || content.Subject() == nil {
+			t.Fatalf("expected a default subject for %+v", content.Name())
+		}
+		if _, err := content.Rendered(); err != nil {
+			t.Fatalf("expected default %+v to render: %+v", content.Name(), err)
+		}
+	}
+
+	t.Log("invalid templates are rejected")
+	for _, template := range []string{"{{.Reservation.Nope}}", "{{.Bogus}}", "{{if}}"} {
+		if _, err := createNotificationContent(ctx, resolver, property, models.NEW_RESERVATION, nil, template); err == nil {
+			t.Fatalf("expected template %+v to fail", template)
+		}
+	}
+	subject := "{{.Settings.Nope}}"
+	if _, err := createNotificationContent(ctx, resolver, property, models.NEW_RESERVATION, &subject, "body"); err == nil {
+		t.Fatal("expected invalid subject to fail")
+	}
+	subject = "home subject"
+	if _, err := createNotificationContent(ctx, resolver, property, models.ADMIN_HOME, &subject, "home"); err == nil {
+		t.Fatal("expected a home page subject to fail")
+	}
+
+	t.Log("preview renders with sample data")
+	subject = "Booked {{.Reservation.StartDate}}"
+	template := "Custom body for {{.Reservation.ReservedFor.Nickname}}"
+	preview, err := property.PreviewContent(&previewContentArgs{Name: models.NEW_RESERVATION, Subject: &subject, Template: template})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Body() != "Custom body for "+me.Nickname() {
+		t.Fatalf("unexpected preview body %+v", preview.Body())
+	}
+	if preview.Subject() == nil || *preview.Subject() != "Booked "+today.AddDays(1).ToString() {
+		t.Fatalf("unexpected preview subject %+v", preview.Subject())
+	}
+	if preview.HTMLBody() == nil || !strings.Contains(*preview.HTMLBody(), preview.Body()) {
+		t.Fatal("expected the preview html to contain the body")
+	}
+
+	t.Log("notifications use the custom content")
+	property, err = createNotificationContent(ctx, resolver, property, models.NEW_RESERVATION, &subject, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(5).ToString(), today.AddDays(6).ToString())
+
+	notifications, _ := property.Notifications(&notificationArgs{Reverse: &[]bool{true}[0]})
+	notification := notifications[0]
+	if notification.DefaultTemplate() {
+		t.Fatal("expected a custom template")
+	}
+	notificationSubject, _ := notification.Subject()
+	if notificationSubject != "Booked "+today.AddDays(5).ToString() {
+		t.Fatalf("unexpected subject %+v", notificationSubject)
+	}
+	body, _ := notification.Body()
+	if body != "Custom body for "+me.Nickname() {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	htmlBody, err := notification.HTMLBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htmlBody == nil || !strings.Contains(*htmlBody, body) {
+		t.Fatal("expected the html body to contain the custom body")
+	}
+
+	t.Log("sent notifications keep the content they were sent with")
+	property, err = createNotificationContent(ctx, resolver, property, models.NEW_RESERVATION, nil, "changed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications, _ = property.Notifications(&notificationArgs{Reverse: &[]bool{true}[0]})
+	body, _ = notifications[0].Body()
+	if body != "Custom body for "+me.Nickname() {
+		t.Fatalf("expected the original body but got %+v", body)
+	}
+}
+
+func TestNotificationContentEscaping(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	t.Log("name the property with characters that html escapes")
+	input := currentSettingsInput(property)
+	input.PropertyName = "Smith & O'Neil"
+	property, err := updateSettings(ctx, resolver, property, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// escaped once by html/template
+	escaped := "Smith &amp; O&#39;Neil"
+	template := "Welcome to {{.Settings.PropertyName}}"
+
+	t.Log("the preview html is escaped once")
+	preview, err := property.PreviewContent(&previewContentArgs{Name: models.NEW_RESERVATION, Template: template})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.HTMLBody() == nil || !strings.Contains(*preview.HTMLBody(), "Welcome to "+escaped) || strings.Contains(*preview.HTMLBody(), "&amp;amp;") {
+		t.Fatalf("expected the preview html to be escaped once but got %+v", preview.HTMLBody())
+	}
+
+	t.Log("the notification html is escaped once")
+	property, err = createNotificationContent(ctx, resolver, property, models.NEW_RESERVATION, nil, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(5).ToString(), today.AddDays(6).ToString())
+	notifications, _ := property.Notifications(&notificationArgs{Reverse: &[]bool{true}[0]})
+	htmlBody, err := notifications[0].HTMLBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htmlBody == nil || !strings.Contains(*htmlBody, "Welcome to "+escaped) || strings.Contains(*htmlBody, "&amp;amp;") {
+		t.Fatalf("expected the notification html to be escaped once but got %+v", htmlBody)
+	}
+}
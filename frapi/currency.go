@@ -0,0 +1,246 @@
+package frapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+// currencyInfo is how an ISO 4217 currency is formatted, amounts are kept in the minor units of the currency
+type currencyInfo struct {
+	minorUnits int
+	symbol     string
+}
+
+// currencies are the currencies a property can use
+var currencies = map[models.Currency]currencyInfo{
+	models.USD: {2, "$"},
+	models.EUR: {2, "€"},
+	models.GBP: {2, "£"},
+	models.CHF: {2, "CHF"},
+	models.CAD: {2, "CA$"},
+	models.AUD: {2, "A$"},
+	models.NZD: {2, "NZ$"},
+	models.SEK: {2, "kr"},
+	models.NOK: {2, "kr"},
+	models.DKK: {2, "kr."},
+	models.PLN: {2, "zł"},
+	models.CZK: {2, "Kč"},
+	models.HUF: {2, "Ft"},
+	models.MXN: {2, "MX$"},
+	models.BRL: {2, "R$"},
+	models.ZAR: {2, "R"},
+	models.INR: {2, "₹"},
+	models.CNY: {2, "CN¥"},
+	models.HKD: {2, "HK$"},
+	models.SGD: {2, "S$"},
+	models.JPY: {0, "¥"},
+	models.KRW: {0, "₩"},
+	models.ISK: {0, "kr"},
+	models.KWD: {3, "KD"},
+	models.BHD: {3, "BD"},
+	models.OMR: {3, "OMR"},
+	models.JOD: {3, "JD"},
+}
+
+// localeInfo is how a locale writes amounts
+type localeInfo struct {
+	groupSeparator   string
+	decimalSeparator string
+	// the currency symbol goes before the amount
+	symbolFirst bool
+	// a space separates the currency symbol and the amount
+	symbolSpace bool
+}
+
+// defaultLocale is used by properties created before the locale setting
+const defaultLocale = "en-US"
+
+// locales are the locales a property can format amounts for
+var locales = map[string]localeInfo{
+	"en-US": {",", ".", true, false},
+	"en-GB": {",", ".", true, false},
+	"en-CA": {",", ".", true, false},
+	"en-AU": {",", ".", true, false},
+	"en-IE": {",", ".", true, false},
+	"de-DE": {".", ",", false, true},
+	"de-AT": {" ", ",", true, true},
+	"de-CH": {"'", ".", true, true},
+	"fr-FR": {" ", ",", false, true},
+	"fr-CA": {" ", ",", false, true},
+	"es-ES": {".", ",", false, true},
+	"es-MX": {",", ".", true, false},
+	"it-IT": {".", ",", false, true},
+	"nl-NL": {".", ",", true, true},
+	"pt-PT": {" ", ",", false, true},
+	"pt-BR": {".", ",", true, true},
+	"sv-SE": {" ", ",", false, true},
+	"nb-NO": {" ", ",", false, true},
+	"da-DK": {".", ",", false, true},
+	"pl-PL": {" ", ",", false, true},
+	"cs-CZ": {" ", ",", false, true},
+	"hu-HU": {" ", ",", false, true},
+	"ja-JP": {",", ".", true, false},
+	"ko-KR": {",", ".", true, false},
+	"zh-CN": {",", ".", true, false},
+}
+
+// localeNames returns the supported locales in order
+func localeNames() []string {
+	l := []string{}
+	for name := range locales {
+		l = append(l, name)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// validateMoneyFormat checks that the currency and the locale, if set, are supported
+func validateMoneyFormat(currency models.Currency, locale *string) error {
+	if _, ok := currencies[currency]; !ok {
+		return fmt.Errorf("unknown currency %+v", currency)
+	}
+	if locale != nil {
+		if _, ok := locales[*locale]; !ok {
+			return fmt.Errorf("unknown locale %+v", *locale)
+		}
+	}
+	return nil
+}
+
+// moneyFormat formats amounts in the minor units of a currency for a locale
+type moneyFormat struct {
+	currency currencyInfo
+	locale   localeInfo
+}
+
+func newMoneyFormat(currency models.Currency, locale string) *moneyFormat {
+	info, ok := currencies[currency]
+	if !ok {
+		info = currencyInfo{2, string(currency)}
+	}
+	localeInfo, ok := locales[locale]
+	if !ok {
+		localeInfo = locales[defaultLocale]
+	}
+	return &moneyFormat{currency: info, locale: localeInfo}
+}
+
+// moneyFormat returns the amount format of the current settings
+func (r *PropertyResolver) moneyFormat() *moneyFormat {
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		Logger.LogErrorf("money format settings error: %+v", err)
+		return newMoneyFormat(models.USD, defaultLocale)
+	}
+	return settings.moneyFormat()
+}
+
+func (m *moneyFormat) formatAmount(amount int32, format amountFormat) (string, error) {
+
+	if format == "" {
+		format = decimal
+	}
+
+	switch format {
+	case decimal:
+		return m.digits(amount, "", "."), nil
+	case nodecimal:
+		return strconv.Itoa(int(amount)), nil
+	case localized:
+		return m.digits(amount, m.locale.groupSeparator, m.locale.decimalSeparator), nil
+	case localizedSymbol:
+		sign := ""
+		if amount < 0 {
+			sign = "-"
+			amount = -amount
+		}
+		digits := m.digits(amount, m.locale.groupSeparator, m.locale.decimalSeparator)
+		space := ""
+		if m.locale.symbolSpace {
+			space = " "
+		}
+		if m.locale.symbolFirst {
+			return sign + m.currency.symbol + space + digits, nil
+		}
+		return sign + digits + " " + m.currency.symbol, nil
+	}
+	return "", fmt.Errorf("unknown format %+v", format)
+}
+
+// csvAmount writes the amount for spreadsheets of the locale, without group separators
+func (m *moneyFormat) csvAmount(amount int32) string {
+	return m.digits(amount, "", m.locale.decimalSeparator)
+}
+
+// digits writes the amount with the decimals of the currency
+func (m *moneyFormat) digits(amount int32, groupSeparator string, decimalSeparator string) string {
+	sign := ""
+	value := int64(amount)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	minorUnits := m.currency.minorUnits
+	units := strconv.FormatInt(value, 10)
+	if len(units) <= minorUnits {
+		units = strings.Repeat("0", minorUnits-len(units)+1) + units
+	}
+	whole, fraction := units[:len(units)-minorUnits], units[len(units)-minorUnits:]
+
+	if groupSeparator != "" {
+		for i := len(whole) - 3; i > 0; i -= 3 {
+			whole = whole[:i] + groupSeparator + whole[i:]
+		}
+	}
+
+	if minorUnits == 0 {
+		return sign + whole
+	}
+	return sign + whole + decimalSeparator + fraction
+}
+
+// parseAmount reads an amount written with either separator convention, ex. 1,234.50 or -1.234,50 or (12.50),
+// into minor units, a separator is the decimal separator when followed by no more digits than the currency has
+func (m *moneyFormat) parseAmount(text string) (int32, error) {
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-") || strings.HasSuffix(text, "-") ||
+		strings.HasPrefix(text, "−") || (strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")"))
+
+	number := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, text)
+	if strings.IndexAny(number, "0123456789") < 0 {
+		return 0, fmt.Errorf("invalid amount %+v", text)
+	}
+
+	whole, fraction := number, ""
+	if i := strings.LastIndexAny(number, ".,"); i >= 0 {
+		decimals := len(number) - i - 1
+		separator := number[i : i+1]
+		if decimals <= m.currency.minorUnits && (decimals != 3 || separator == m.locale.decimalSeparator) {
+			whole, fraction = number[:i], number[i+1:]
+		}
+	}
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+	if strings.ContainsAny(fraction, ".,") {
+		return 0, fmt.Errorf("invalid amount %+v", text)
+	}
+	fraction += strings.Repeat("0", m.currency.minorUnits-len(fraction))
+
+	value, err := strconv.ParseInt(whole+fraction, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %+v", text)
+	}
+	if negative {
+		value = -value
+	}
+	return int32(value), nil
+}
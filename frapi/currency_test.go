@@ -0,0 +1,178 @@
+package frapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		currency models.Currency
+		locale   string
+		amount   int32
+		format   amountFormat
+		expected string
+	}{
+		{models.USD, "en-US", 123456, decimal, "1234.56"},
+		{models.USD, "en-US", -5, decimal, "-0.05"},
+		{models.USD, "en-US", -150, decimal, "-1.50"},
+		{models.USD, "en-US", 123456, nodecimal, "123456"},
+		{models.USD, "en-US", 123456, localized, "1,234.56"},
+		{models.USD, "en-US", -123456, localizedSymbol, "-$1,234.56"},
+		{models.EUR, "de-DE", 123456789, localized, "1.234.567,89"},
+		{models.EUR, "de-DE", 123456, localizedSymbol, "1.234,56 €"},
+		{models.EUR, "nl-NL", 123456, localizedSymbol, "€ 1.234,56"},
+		{models.CHF, "de-CH", 123456, localizedSymbol, "CHF 1'234.56"},
+		{models.JPY, "ja-JP", 1234567, decimal, "1234567"},
+		{models.JPY, "ja-JP", 1234567, localizedSymbol, "¥1,234,567"},
+		{models.KWD, "en-US", 1234567, decimal, "1234.567"},
+		{models.KWD, "en-US", 5, localizedSymbol, "KD0.005"},
+	}
+
+	for _, test := range tests {
+		amount, err := newMoneyFormat(test.currency, test.locale).formatAmount(test.amount, test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount != test.expected {
+			t.Fatalf("%v %v %v %v: expected %v but got %v", test.currency, test.locale, test.amount, test.format, test.expected, amount)
+		}
+	}
+
+	if csv := newMoneyFormat(models.EUR, "fr-FR").csvAmount(123456); csv != "1234,56" {
+		t.Fatalf("expected a csv amount without group separators but got %v", csv)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		currency models.Currency
+		locale   string
+		text     string
+		expected int32
+	}{
+		{models.EUR, "en-US", "25.00", 2500},
+		{models.EUR, "en-US", "1,234.5", 123450},
+		{models.EUR, "de-DE", "1.234,56", 123456},
+		{models.EUR, "de-DE", "-12,50", -1250},
+		{models.EUR, "en-US", "(12.50)", -1250},
+		{models.EUR, "en-US", "12.50-", -1250},
+		{models.EUR, "en-US", "€ 1,234", 123400},
+		{models.JPY, "ja-JP", "1,234", 1234},
+		{models.KWD, "en-US", "1.234", 1234},
+		{models.KWD, "de-DE", "1.234", 1234000},
+	}
+
+	for _, test := range tests {
+		amount, err := newMoneyFormat(test.currency, test.locale).parseAmount(test.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount != test.expected {
+			t.Fatalf("%v %v %v: expected %v but got %v", test.currency, test.locale, test.text, test.expected, amount)
+		}
+	}
+
+	for _, text := range []string{"", "abc", "99999999999"} {
+		if _, err := newMoneyFormat(models.EUR, "en-US").parseAmount(text); err == nil {
+			t.Fatalf("expected an error for %v", text)
+		}
+	}
+}
+
+func TestLocaleSettings(t *testing.T) {
+	property, ctx, resolver, me, _ := initAndCreateTestProperty(context.Background(), t)
+
+	settings, _ := property.Settings(&settingsArgs{})
+	if settings.Locale() != defaultLocale {
+		t.Fatalf("expected the default locale but got %v", settings.Locale())
+	}
+
+	updateSettings := func(currency models.Currency, locale string) error {
+		settings, _ := property.Settings(&settingsArgs{})
+		input := &models.UpdateSettingsInput{
+			ForVersion:                    property.EventVersion(),
+			PropertyName:                  settings.PropertyName(),
+			Currency:                      currency,
+			MemberRate:                    settings.memberRateInternal(),
+			AllowNonMembers:               settings.AllowNonMembers(),
+			NonMemberRate:                 settings.nonMemberRateInternal(),
+			Timezone:                      settings.Timezone(),
+			MinBalance:                    settings.minBalanceInternal().Raw(),
+			MaxOutDays:                    settings.MaxOutDays(),
+			MinInDays:                     settings.MinInDays(),
+			ReservationReminderDaysBefore: settings.ReservationReminderDaysBefore(),
+			BalanceReminderIntervalDays:   settings.BalanceReminderIntervalDays(),
+			Locale:                        &locale,
+		}
+		updated, err := resolver.UpdateSettings(ctx, &struct {
+			PropertyID string
+			Input      *models.UpdateSettingsInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input:      input,
+		})
+		if err == nil {
+			property = updated
+		}
+		return err
+	}
+
+	t.Log("unknown currencies and locales are rejected")
+	if err := updateSettings(models.Currency("XYZ"), "en-US"); err == nil {
+		t.Fatal("expected an error for an unknown currency")
+	}
+	if err := updateSettings(models.EUR, "xx-XX"); err == nil {
+		t.Fatal("expected an error for an unknown locale")
+	}
+
+	t.Log("amounts are formatted for the currency and locale")
+	if err := updateSettings(models.EUR, "de-DE"); err != nil {
+		t.Fatal(err)
+	}
+	property = createPayment(ctx, t, resolver, property, 98765, true, property.EventVersion())
+	settings, _ = property.Settings(&settingsArgs{})
+	if settings.Locale() != "de-DE" || settings.MinorUnits() != 2 {
+		t.Fatalf("expected the de-DE locale but got %v", settings.Locale())
+	}
+	userID := me.UserID()
+	last := int32(1)
+	ledgers, _ := property.Ledgers(&ledgersArgs{UserID: &userID, Last: &last})
+	balance, err := ledgers[0].Records()[0].Balance(&struct{ Format amountFormat }{Format: localizedSymbol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != "987,65 €" {
+		t.Fatalf("expected a de-DE balance but got %v", balance)
+	}
+
+	t.Log("the currency cannot change to other minor units after ledger entries are made")
+	if err := updateSettings(models.JPY, "ja-JP"); err == nil {
+		t.Fatal("expected an error for a currency without minor units")
+	}
+	if err := updateSettings(models.KWD, "en-US"); err == nil {
+		t.Fatal("expected an error for a currency with 3 minor units")
+	}
+	if err := updateSettings(models.USD, "en-US"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("currencies without minor units")
+	property, ctx, resolver, me, _ = initAndCreateTestProperty(context.Background(), t)
+	if err := updateSettings(models.JPY, "ja-JP"); err != nil {
+		t.Fatal(err)
+	}
+	property = createPayment(ctx, t, resolver, property, 98765, true, property.EventVersion())
+	userID = me.UserID()
+	ledgers, _ = property.Ledgers(&ledgersArgs{UserID: &userID, Last: &last})
+	balance, _ = ledgers[0].Records()[0].Balance(&struct{ Format amountFormat }{Format: decimal})
+	if balance != "98765" {
+		t.Fatalf("expected a JPY balance but got %v", balance)
+	}
+	settings, _ = property.Settings(&settingsArgs{})
+	if symbol, _ := settings.Currency(ctx, &struct{ Format currencyFormat }{Format: symbol}); symbol != "¥" {
+		t.Fatalf("expected the yen symbol but got %v", symbol)
+	}
+}
@@ -58,6 +58,9 @@ func DailyCron(ctx context.Context) error {
 			}
 		}
 
+		// retry notification emails that could not be sent
+		property = deliverPendingNotifications(ctx, property)
+
 		Logger.LogDebugf("check balance for propertyId: %+v", property.PropertyID())
 
 		// get the last notifications to make sure we don't send too many
@@ -97,13 +100,21 @@ func DailyCron(ctx context.Context) error {
 						Logger.LogErrorf("DailyCron error commit changes: %+v", err)
 					} else {
 						// send the email notification
-						notifications, _ := property.Notifications(&notificationArgs{notificationID: &newNotificationInput.NotificationId})
 						Logger.LogDebugf("DailyCron send notification email")
-						sendEmail(ctx, property, notifications[0])
+						property = deliverNotification(ctx, property, newNotificationInput.NotificationId)
 					}
 				}
 			}
 		}
+
+		// missed payment plan installments
+		property = sendPaymentPlanReminders(ctx, property, property.lastNotifications(dateBuilder))
+
+		// membership prepay, renewal and grace period reminders
+		property = sendMembershipReminders(ctx, property, today, settings, property.lastNotifications(dateBuilder))
+
+		// weekly digests for users who do not want every email
+		property = sendWeeklyDigests(ctx, property, today, property.lastNotifications(dateBuilder))
 	}
 	Logger.LogInfof("DailyCron end success")
 
@@ -159,3 +170,17 @@ func commitCronChanges(ctx context.Context, propertyID string,
 
 	return propertyResolver, err
 }
+
+// commitSystemChanges commits the events of the system user on top of eventVersion, the commit fails if
+// the property changed since, i.e. when the events depend on what the property contained at that version
+func commitSystemChanges(ctx context.Context, propertyID string, eventVersion int32,
+	events ...platform.VersionedEvent) (*PropertyResolver, error) {
+
+	_, err := PersistedVersionedEvents.NewPropertyEvents(ctx, propertyID, int(eventVersion)+1, events, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// get all events for the property
+	return currentBaseProperty(ctx, utilities.SystemEmail, propertyID)
+}
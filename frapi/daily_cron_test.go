@@ -2,10 +2,12 @@ package frapi
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
 	"github.com/bjorge/friendlyreservations/templates"
 	"github.com/bjorge/friendlyreservations/utilities"
 )
@@ -157,3 +159,196 @@ func logToday(t *testing.T, property *PropertyResolver) {
 	today := dateBuilder.MustNewDateTime(frdate.CreateDateTimeUTC())
 	t.Logf("today is: %+v", today.ToString())
 }
+
+func TestDailyCronDigest(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	t.Log("no digest without a digest preference")
+	settings, _ := property.Settings(&settingsArgs{})
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+	weekday := utilities.DigestWeekday
+	defer func() { utilities.DigestWeekday = weekday }()
+	utilities.DigestWeekday = time.Weekday(-1)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if dateBuilder.MustNewDateTime(frdate.CreateDateTimeUTC()).Format("Monday") == day.String() {
+			utilities.DigestWeekday = day
+		}
+	}
+
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	if err := DailyCron(ctx); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, ok := property.lastNotifications(dateBuilder)[templates.DigestNotification]; ok {
+		t.Fatal("expected no digest")
+	}
+
+	t.Log("a digest is sent with a digest preference")
+	property, err := updateNotificationPreference(ctx, resolver, property, templates.NewReservationNotification, models.EMAIL_DIGEST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(3).ToString(), today.AddDays(4).ToString())
+	if err := DailyCron(ctx); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+
+	notifications, _ := property.Notifications(&notificationArgs{Reverse: &[]bool{true}[0]})
+	digest := notifications[0]
+	if digest.TemplateName() != string(templates.DigestNotification) {
+		t.Fatalf("expected a digest but got %+v", digest.TemplateName())
+	}
+	if len(digest.rollup.Input.ToUserIds) != 1 || digest.rollup.Input.ToUserIds[0] != me.UserID() || len(digest.rollup.Input.CcUserIds) != 0 {
+		t.Fatalf("expected the digest to only be sent to me")
+	}
+	body, err := digest.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("digest: %+v", body)
+	if !strings.Contains(body, "New: "+me.Nickname()+" check in "+today.AddDays(1).ToString()) {
+		t.Fatal("expected the new reservation in the digest")
+	}
+	if !strings.Contains(body, "Unread notifications: 1") || !strings.Contains(body, "New reservation with check in on "+today.AddDays(3).ToString()) {
+		t.Fatal("expected the unread notification held for the digest")
+	}
+	if strings.Contains(body, "New reservation with check in on "+today.AddDays(1).ToString()) {
+		t.Fatal("expected the notification emailed before the digest preference to not be in the digest")
+	}
+	if htmlBody, err := digest.HTMLBody(); err != nil || htmlBody == nil {
+		t.Fatalf("expected an html digest: %+v", err)
+	}
+
+	t.Log("only one digest a week")
+	countDigests := func() int {
+		property = getUpdatedProperty(ctx, t, resolver)
+		notifications, _ := property.Notifications(&notificationArgs{})
+		count := 0
+		for _, notification := range notifications {
+			if notification.TemplateName() == string(templates.DigestNotification) {
+				count++
+			}
+		}
+		return count
+	}
+	if err := DailyCron(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if countDigests() != 1 {
+		t.Fatal("expected no second digest")
+	}
+
+	t.Log("no digest on other days")
+	utilities.DigestWeekday = (utilities.DigestWeekday + 1) % 7
+	timeOffset := digestIntervalDays
+	frdate.TestTimeOffsetDays = &timeOffset
+	defer func() { frdate.TestTimeOffsetDays = nil }()
+	if err := DailyCron(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if countDigests() != 1 {
+		t.Fatal("expected no digest on another day")
+	}
+}
+
+func TestDailyCronMembershipReminders(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	defer func() { frdate.TestTimeOffsetDays = nil }()
+
+	settings, _ := property.Settings(&settingsArgs{})
+	reminderDays := int(settings.MembershipReminderDaysBefore())
+	inDate := today.AddDays(reminderDays + 6)
+
+	property, err := resolver.CreateRestriction(ctx, &struct {
+		PropertyID string
+		Input      *models.NewRestrictionInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewRestrictionInput{
+			ForVersion:  property.EventVersion(),
+			Description: "next season",
+			Membership: &models.MembershipRestriction{
+				Amount:             30000,
+				PrePayStartDate:    today.AddDays(-1).ToString(),
+				InDate:             inDate.ToString(),
+				OutDate:            inDate.AddDays(10).ToString(),
+				GracePeriodOutDate: inDate.AddDays(20).ToString(),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reminders := func(offset int) []*NotificationResolver {
+		frdate.TestTimeOffsetDays = &offset
+		if err := DailyCron(ctx); err != nil {
+			t.Fatal(err)
+		}
+		property = getUpdatedProperty(ctx, t, resolver)
+		notifications, _ := property.Notifications(&notificationArgs{})
+		sent := []*NotificationResolver{}
+		for _, notification := range notifications {
+			if strings.HasPrefix(notification.TemplateName(), "MEMBERSHIP_") {
+				sent = append(sent, notification)
+			}
+		}
+		return sent
+	}
+
+	t.Log("the prepay window is open")
+	sent := reminders(0)
+	if len(sent) != 1 || sent[0].TemplateName() != string(templates.MembershipPrePayNotification) {
+		t.Fatalf("expected a prepay notification but got %+v", len(sent))
+	}
+	if sent[0].rollup.Input.ToUserIds[0] != me.UserID() || len(sent[0].rollup.Input.CcUserIds) != 0 {
+		t.Fatal("expected the reminder to only be sent to me")
+	}
+	body, err := sent[0].Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "next season membership for "+inDate.ToString()) {
+		t.Fatalf("expected the membership in the body: %v", body)
+	}
+	if htmlBody, err := sent[0].HTMLBody(); err != nil || htmlBody == nil {
+		t.Fatalf("expected an html reminder: %+v", err)
+	}
+
+	t.Log("each reminder is only sent once")
+	if sent = reminders(1); len(sent) != 1 {
+		t.Fatalf("expected no new reminder but got %+v", len(sent))
+	}
+
+	t.Log("the membership starts soon")
+	sent = reminders(reminderDays/2 + 6)
+	if len(sent) != 2 || sent[1].TemplateName() != string(templates.MembershipRenewalNotification) {
+		t.Fatalf("expected a renewal reminder but got %+v", len(sent))
+	}
+	if sent = reminders(reminderDays + 6); len(sent) != 2 {
+		t.Fatalf("expected no new reminder but got %+v", len(sent))
+	}
+
+	t.Log("the grace period ends soon")
+	sent = reminders(reminderDays + 6 + 20 - reminderDays/2)
+	if len(sent) != 3 || sent[2].TemplateName() != string(templates.MembershipGraceNotification) {
+		t.Fatalf("expected a grace period warning but got %+v", len(sent))
+	}
+	if _, err := sent[2].Body(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("no reminders once purchased")
+	membership := &UserMembershipRecord{
+		status:          PURCHASED,
+		prePayStartDate: today,
+		inDate:          today,
+		outDate:         today.AddDays(1),
+		graceOutDate:    today.AddDays(1),
+	}
+	if templateName, _ := membershipReminder(membership, today, reminderDays); templateName != "" {
+		t.Fatalf("expected no reminder but got %v", templateName)
+	}
+}
@@ -0,0 +1,173 @@
+package frapi
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/templates"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+// digestIntervalDays is the number of days summarized by a digest
+const digestIntervalDays = 7
+
+// maxDigestUnreadSubjects limits the number of unread notifications listed in a digest
+const maxDigestUnreadSubjects = 10
+
+// digestSummary is the Digest template param group, a summary of the week before a digest for a user
+type digestSummary struct {
+	ReservationChanges []*digestReservation
+	UpcomingStays      []*digestReservation
+	// the unread notifications the user gets in the digest instead of by email
+	UnreadCount    int
+	UnreadSubjects []string
+}
+
+// digestReservation is a reservation listed in a digest
+type digestReservation struct {
+	Nickname  string
+	StartDate string
+	EndDate   string
+	Canceled  bool
+}
+
+// digestSummary returns the summary for a digest created at the date time, using the events up to the max version
+func (r *PropertyResolver) digestSummary(userID string, createDateTime string, maxVersion *int32) (*digestSummary, error) {
+	settings, err := r.Settings(&settingsArgs{MaxVersion: maxVersion})
+	if err != nil {
+		return nil, err
+	}
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+	end := dateBuilder.MustNewDateTime(createDateTime)
+	start := end.AddDays(-digestIntervalDays)
+	today := end.ToDate()
+
+	summary := &digestSummary{
+		ReservationChanges: []*digestReservation{},
+		UpcomingStays:      []*digestReservation{},
+		UnreadSubjects:     []string{},
+	}
+
+	reservations, err := r.Reservations(&reservationsArgs{MaxVersion: maxVersion})
+	if err != nil {
+		return nil, err
+	}
+	inWeek := func(dateTime string) bool {
+		when := dateBuilder.MustNewDateTime(dateTime)
+		return when.After(start) && !when.After(end)
+	}
+	for _, reservation := range reservations {
+		item := &digestReservation{
+			Nickname:  reservationNickname(reservation),
+			StartDate: reservation.StartDate(),
+			EndDate:   reservation.EndDate(),
+		}
+
+		if inWeek(reservation.CreateDateTime()) {
+			summary.ReservationChanges = append(summary.ReservationChanges, item)
+		}
+
+		if reservation.Canceled() {
+			if inWeek(reservation.UpdateDateTime()) {
+				canceled := *item
+				canceled.Canceled = true
+				summary.ReservationChanges = append(summary.ReservationChanges, &canceled)
+			}
+			continue
+		}
+
+		startDate := dateBuilder.MustNewDate(reservation.StartDate())
+		if !startDate.Before(today) && startDate.Before(today.AddDays(digestIntervalDays)) {
+			summary.UpcomingStays = append(summary.UpcomingStays, item)
+		}
+	}
+
+	// unread notifications held for the digest instead of being emailed, newest first
+	r.rollupNotifications()
+	ifaces := r.getRollups(&rollupArgs{maxVersion: maxVersion}, notificationRollupType)
+	sort.Slice(ifaces, func(i, j int) bool {
+		return ifaces[i].(*NotificationRollup).Input.EventVersion > ifaces[j].(*NotificationRollup).Input.EventVersion
+	})
+	for _, iface := range ifaces {
+		rollup := iface.(*NotificationRollup)
+		if rollup.Input.TemplateName == templates.DigestNotification {
+			continue
+		}
+		if !inDigest(rollup.Input, userID) || rollup.ReaderUserIdsMap[userID] {
+			continue
+		}
+		summary.UnreadCount++
+		if len(summary.UnreadSubjects) < maxDigestUnreadSubjects {
+			notification := &NotificationResolver{rollup: rollup, property: r, args: &notificationArgs{UserID: &userID}}
+			subject, err := notification.Subject()
+			if err != nil {
+				return nil, err
+			}
+			summary.UnreadSubjects = append(summary.UnreadSubjects, subject)
+		}
+	}
+
+	return summary, nil
+}
+
+// inDigest returns true if the user gets the notification in a digest
+func inDigest(notification *models.NewNotificationInput, userID string) bool {
+	for _, digestUserID := range notification.DigestUserIds {
+		if digestUserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// reservationNickname returns the name of who the reservation is for, empty if the user no longer exists
+func reservationNickname(reservation *ReservationResolver) string {
+	if !reservation.Member() && reservation.NonMemberName() != nil {
+		return *reservation.NonMemberName()
+	}
+	if user := reservation.ReservedFor(); user != nil {
+		return user.Nickname()
+	}
+	return ""
+}
+
+// sendWeeklyDigests creates and emails a digest for each user who gets notifications in a digest,
+// called by the daily cron and only sends on the configured digest weekday
+func sendWeeklyDigests(ctx context.Context, property *PropertyResolver, today *frdate.DateTime,
+	lastNotifications map[templates.TemplateName]map[string]*frdate.DateTime) *PropertyResolver {
+
+	if today.Format("Monday") != utilities.DigestWeekday.String() {
+		return property
+	}
+
+	for userID := range property.lastActiveLedgerBalances() {
+		users := property.Users(&usersArgs{UserID: &userID})
+		if len(users) != 1 || !users[0].wantsDigest() {
+			continue
+		}
+
+		// at most one digest a week
+		if dateTime, ok := lastNotifications[templates.DigestNotification][userID]; ok {
+			if today.Before(dateTime.AddDays(digestIntervalDays - 1)) {
+				continue
+			}
+		}
+
+		Logger.LogDebugf("DailyCron commit digest for userId: %+v", userID)
+		paramGroup := templates.Digest
+		newNotificationInput := createNotificationRecord(notificationTargetUser, property, templates.DigestNotification,
+			&userID, &paramGroup, &userID)
+		newNotificationInput.TemplateParamData[templates.Ledger] = userID
+
+		updated, err := commitCronChanges(ctx, property.PropertyID(), newNotificationInput)
+		if err != nil {
+			Logger.LogErrorf("DailyCron error commit digest: %+v", err)
+			continue
+		}
+		property = deliverNotification(ctx, updated, newNotificationInput.NotificationId)
+	}
+
+	return property
+}
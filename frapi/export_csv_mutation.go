@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"net/mail"
 	"strconv"
 
 	"github.com/bjorge/friendlyreservations/frdate"
@@ -37,8 +38,8 @@ func (r *Resolver) ExportCSV(ctx context.Context, args *struct {
 	}
 
 	// check the input values
-	if !me.IsAdmin() {
-		return nil, errors.New("only admins can export csv files")
+	if err := authorize(me, exportCSVPermission); err != nil {
+		return nil, err
 	}
 
 	// create the email message for the csv export
@@ -75,15 +76,20 @@ func (r *Resolver) exportCSVInternal(ctx context.Context, property *PropertyReso
 		return nil, err
 	}
 
-	sender := fmt.Sprintf("%s <%s>", utilities.SystemName, utilities.SystemEmail)
-	to := []string{fmt.Sprintf("%s <%s>", me.Nickname(), me.Email())}
+	financialSummaryAttachment, err := r.exportFinancialSummary(ctx, property, me)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := (&mail.Address{Name: utilities.SystemName, Address: utilities.SystemEmail}).String()
+	to := []string{(&mail.Address{Name: me.Nickname(), Address: me.Email()}).String()}
 
 	msg := &platform.EmailMessage{
 		Sender:      sender,
 		To:          to,
 		Subject:     "CSV export",
 		Body:        "Attached are the CSV files",
-		Attachments: []platform.EmailAttachment{*ledgersAttachment, *reservationsAttachment, *paymentsAttachment, *membershipsAttachment},
+		Attachments: []platform.EmailAttachment{*ledgersAttachment, *reservationsAttachment, *paymentsAttachment, *membershipsAttachment, *financialSummaryAttachment},
 	}
 
 	return msg, err
@@ -94,6 +100,7 @@ func (r *Resolver) exportLedgers(ctx context.Context, property *PropertyResolver
 	// get the setting to find the property timezone
 	settings, _ := property.Settings(&settingsArgs{})
 	db := frdate.MustNewDateBuilder(settings.Timezone())
+	money := settings.moneyFormat()
 
 	// get the ledgers
 	ledgers, _ := property.Ledgers(&ledgersArgs{})
@@ -103,7 +110,7 @@ func (r *Resolver) exportLedgers(ctx context.Context, property *PropertyResolver
 	records = append(records, []string{"member", "year", "date", "amount", "balance", "event"})
 	for _, ledgerRecord := range ledgers {
 		member := ledgerRecord.User()
-		year, balance := 0, "0.00"
+		year, balance := 0, money.csvAmount(0)
 		for _, item := range ledgerRecord.Records() {
 			if member.IsSystem() {
 				continue
@@ -115,20 +122,20 @@ func (r *Resolver) exportLedgers(ctx context.Context, property *PropertyResolver
 				record = append(record, member.Nickname())
 				record = append(record, strconv.Itoa(year))
 				record = append(record, strconv.Itoa(year)+"-12-31")
-				record = append(record, "0.00")
+				record = append(record, money.csvAmount(0))
 				record = append(record, balance)
 				record = append(record, "FINAL_BALANCE")
 				records = append(records, record)
 			}
 			year = date.Year()
-			balance = item.balanceInternal().Decimal()
+			balance = money.csvAmount(item.balanceInternal().Raw())
 
 			record := []string{}
 			record = append(record, member.Nickname())
 			record = append(record, strconv.Itoa(date.Year()))
 			record = append(record, date.ToString())
-			record = append(record, item.amountInternal().Decimal())
-			record = append(record, item.balanceInternal().Decimal())
+			record = append(record, money.csvAmount(item.amountInternal().Raw()))
+			record = append(record, money.csvAmount(item.balanceInternal().Raw()))
 			record = append(record, string(eventName(item.Event())))
 			records = append(records, record)
 		}
@@ -137,7 +144,7 @@ func (r *Resolver) exportLedgers(ctx context.Context, property *PropertyResolver
 			record = append(record, member.Nickname())
 			record = append(record, strconv.Itoa(year))
 			record = append(record, strconv.Itoa(year)+"-12-31")
-			record = append(record, "0.00")
+			record = append(record, money.csvAmount(0))
 			record = append(record, balance)
 			record = append(record, "FINAL_BALANCE")
 			records = append(records, record)
@@ -170,6 +177,7 @@ func (r *Resolver) exportReservations(ctx context.Context, property *PropertyRes
 	// get the setting to find the property timezone
 	settings, _ := property.Settings(&settingsArgs{})
 	db := frdate.MustNewDateBuilder(settings.Timezone())
+	money := settings.moneyFormat()
 
 	// get the reservations
 	reservations, _ := property.Reservations(&reservationsArgs{})
@@ -228,9 +236,9 @@ func (r *Resolver) exportReservations(ctx context.Context, property *PropertyRes
 		record = append(record, checkinDate.ToString())
 		record = append(record, checkoutDate.ToString())
 		record = append(record, rateType)
-		record = append(record, currencyToString(int(rate)))
+		record = append(record, money.csvAmount(rate))
 		record = append(record, strconv.Itoa(checkoutDate.Sub(checkinDate)))
-		record = append(record, currencyToString(int(rate)*checkoutDate.Sub(checkinDate)))
+		record = append(record, money.csvAmount(rate*int32(checkoutDate.Sub(checkinDate))))
 		record = append(record, author)
 		record = append(record, strconv.Itoa(purchaseDate.ToDate().Year()))
 		record = append(record, purchaseDate.ToDate().ToString())
@@ -244,9 +252,9 @@ func (r *Resolver) exportReservations(ctx context.Context, property *PropertyRes
 			record = append(record, checkinDateSplit.ToString())
 			record = append(record, checkoutDateSplit.ToString())
 			record = append(record, rateType)
-			record = append(record, currencyToString(int(rate)))
+			record = append(record, money.csvAmount(rate))
 			record = append(record, strconv.Itoa(checkoutDateSplit.Sub(checkinDateSplit)))
-			record = append(record, currencyToString(int(rate)*checkoutDateSplit.Sub(checkinDateSplit)))
+			record = append(record, money.csvAmount(rate*int32(checkoutDateSplit.Sub(checkinDateSplit))))
 			record = append(record, author)
 			record = append(record, strconv.Itoa(purchaseDate.ToDate().Year()))
 			record = append(record, purchaseDate.ToDate().ToString())
@@ -281,6 +289,8 @@ func (r *Resolver) exportMemberships(ctx context.Context, property *PropertyReso
 	// settings, _ := property.Settings(&settingsArgs{})
 	// db := frdate.MustNewDateBuilder(settings.Timezone())
 
+	money := property.moneyFormat()
+
 	// get the memberships
 	memberships, _ := property.Memberships(&membershipsArgs{})
 
@@ -298,8 +308,8 @@ func (r *Resolver) exportMemberships(ctx context.Context, property *PropertyReso
 			record = append(record, membershipState.User().Nickname())
 			record = append(record, membershipState.State())
 			record = append(record, membershipRecord.Description())
-			record = append(record, currencyToString(int(membershipRecord.Amount())))
-			record = append(record, currencyToString(int(amountPurchased)))
+			record = append(record, money.csvAmount(membershipRecord.Amount()))
+			record = append(record, money.csvAmount(amountPurchased))
 			record = append(record, membershipRecord.PrePayStartDate())
 			record = append(record, membershipRecord.InDate())
 			record = append(record, membershipRecord.OutDate())
@@ -334,6 +344,7 @@ func (r *Resolver) exportPayments(ctx context.Context, property *PropertyResolve
 	// get the setting to find the property timezone
 	settings, _ := property.Settings(&settingsArgs{})
 	db := frdate.MustNewDateBuilder(settings.Timezone())
+	money := settings.moneyFormat()
 
 	// get the payments
 	ledgers, _ := property.Ledgers(&ledgersArgs{})
@@ -348,8 +359,22 @@ func (r *Resolver) exportPayments(ctx context.Context, property *PropertyResolve
 				continue
 			}
 
-			updateBalanceInput, ok := (*item.rollup.VersionedEvent).(*models.UpdateBalanceInput)
-			if !ok {
+			var amount int32
+			var description string
+			switch event := (*item.rollup.VersionedEvent).(type) {
+			case *models.UpdateBalanceInput:
+				amount = event.Amount
+				description = event.Description
+				if item.Reversed() {
+					description += " (reversed)"
+				}
+			case *models.ReverseLedgerEntryInput:
+				amount = event.Amount
+				description = event.Description
+				if original := property.balanceUpdate(event.PaymentId); original != nil {
+					description = fmt.Sprintf("reversal of %v: %v", original.Description, event.Description)
+				}
+			default:
 				continue
 			}
 
@@ -359,9 +384,9 @@ func (r *Resolver) exportPayments(ctx context.Context, property *PropertyResolve
 			record = append(record, member.Nickname())
 			record = append(record, strconv.Itoa(date.Year()))
 			record = append(record, date.ToString())
-			record = append(record, currencyToString(int(updateBalanceInput.Amount)))
+			record = append(record, money.csvAmount(amount))
 			record = append(record, string(item.Event()))
-			record = append(record, updateBalanceInput.Description)
+			record = append(record, description)
 			records = append(records, record)
 		}
 	}
@@ -387,20 +412,52 @@ func (r *Resolver) exportPayments(ctx context.Context, property *PropertyResolve
 
 }
 
-func currencyToString(value int) string {
-	if value == 0 {
-		return "0.00"
+func (r *Resolver) exportFinancialSummary(ctx context.Context, property *PropertyResolver, me *UserResolver) (*platform.EmailAttachment, error) {
+
+	summary, err := property.financialSummary(defaultFinancialSummaryMonths)
+	if err != nil {
+		return nil, err
 	}
-	if value < 10 {
-		return "0.0" + strconv.Itoa(value)
+	money := summary.money
+
+	var records [][]string
+
+	records = append(records, []string{"section", "period", "item", "amount"})
+	date := summary.Date()
+	records = append(records, []string{"POSITION", date, "TOTAL_BALANCE", money.csvAmount(summary.credit - summary.debt)})
+	records = append(records, []string{"POSITION", date, "OUTSTANDING_DEBT", money.csvAmount(summary.debt)})
+	records = append(records, []string{"POSITION", date, "TOTAL_CREDIT", money.csvAmount(summary.credit)})
+	for _, bucket := range summary.Aging() {
+		records = append(records, []string{"AGING", date, bucket.label() + " DAYS", money.csvAmount(bucket.amount)})
 	}
-	if value < 100 {
-		return "0." + strconv.Itoa(value)
+	for _, month := range summary.Months() {
+		period := month.Month()
+		records = append(records, []string{"MONTH", period, "RESERVATIONS", money.csvAmount(month.reservations)})
+		records = append(records, []string{"MONTH", period, "MEMBERSHIPS", money.csvAmount(month.memberships)})
+		records = append(records, []string{"MONTH", period, "EXPENSES", money.csvAmount(month.expenses)})
+		records = append(records, []string{"MONTH", period, "PAYMENTS", money.csvAmount(month.payments)})
+		records = append(records, []string{"MONTH", period, "BALANCE", money.csvAmount(month.balance)})
+		records = append(records, []string{"MONTH", period, "OUTSTANDING_DEBT", money.csvAmount(month.debt)})
 	}
-	p := strconv.Itoa(value)
-	index := len(p) - 2
-	q := p[:index] + "." + p[index:]
-	return q
+
+	// write the financial summary attachment
+	stream := &bytes.Buffer{}
+	w := csv.NewWriter(stream)
+	w.WriteAll(records) // calls Flush internally
+
+	Logger.LogDebugf("CSV financial summary:\n%+v", string(stream.Bytes()))
+
+	err = w.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := platform.EmailAttachment{}
+	attachment.Data = stream.Bytes()
+	attachment.Name = "financial_summary.csv"
+
+	return &attachment, err
+
 }
 
 func eventName(event LedgerEvent) string {
@@ -419,6 +476,8 @@ func eventName(event LedgerEvent) string {
 		return "MEMBERSHIP_OPTOUT"
 	case startLedgerEvent:
 		return "NEW_MEMBER"
+	case reversalLedgerEvent:
+		return "REVERSAL"
 	default:
 		panic("unknown event")
 	}
@@ -1,7 +1,10 @@
 package frapi
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"strings"
 	"testing"
 )
 
@@ -31,12 +34,41 @@ func TestExportCSV(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(msg.Attachments) != 2 {
-		t.Fatalf("expected 2 attachments")
+	if len(msg.Attachments) != 5 {
+		t.Fatalf("expected 5 attachments")
 	}
 
 	t.Logf("ledgers csv contents:\n%+v", string(msg.Attachments[0].Data))
 	t.Logf("reservations csv contents:\n%+v", string(msg.Attachments[1].Data))
+	t.Logf("financial summary csv contents:\n%+v", string(msg.Attachments[4].Data))
 
-	// t.Fatal("testing")
+	t.Logf("TestExportCSV: check the financial summary csv")
+	records, err := csv.NewReader(bytes.NewReader(msg.Attachments[4].Data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amounts := make(map[string]string)
+	for _, record := range records[1:] {
+		amounts[strings.Join(record[:3], ",")] = record[3]
+	}
+	date, month := today.ToString(), today.ToString()[:7]
+	for key, expected := range map[string]string{
+		"POSITION," + date + ",TOTAL_BALANCE":    "-378.00",
+		"POSITION," + date + ",OUTSTANDING_DEBT": "378.00",
+		"AGING," + date + ",0-30 DAYS":           "378.00",
+		"AGING," + date + ",31-60 DAYS":          "0.00",
+		"AGING," + date + ",91+ DAYS":            "0.00",
+		"MONTH," + month + ",RESERVATIONS":       "80.00",
+		"MONTH," + month + ",MEMBERSHIPS":        "300.00",
+		"MONTH," + month + ",PAYMENTS":           "2.00",
+		"MONTH," + month + ",BALANCE":            "-378.00",
+		"MONTH," + month + ",OUTSTANDING_DEBT":   "378.00",
+	} {
+		if amounts[key] != expected {
+			t.Fatalf("expected %v of %v but got %v", key, expected, amounts[key])
+		}
+	}
+	if len(records) != 1+3+len(agingBucketDays)+6*defaultFinancialSummaryMonths {
+		t.Fatalf("unexpected number of financial summary rows %v", len(records))
+	}
 }
@@ -65,7 +65,7 @@ func TestLoadExport(t *testing.T) {
 		t.Fatalf("wrong property id")
 	}
 
-	importedProperty, err := resolver.Property(ctx, &struct{ ID string }{ID: importedPropertyID})
+	importedProperty, err := resolver.Property(ctx, &propertyArgs{ID: importedPropertyID})
 
 	if err != nil {
 		t.Fatal(err)
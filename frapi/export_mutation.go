@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"net/mail"
+	"reflect"
+	"strings"
 
 	"github.com/bjorge/friendlyreservations/utilities"
 
@@ -39,8 +42,8 @@ func (r *Resolver) Export(ctx context.Context, args *struct {
 	}
 
 	// check the input values
-	if !me.IsAdmin() {
-		return nil, errors.New("only admins can export")
+	if err := authorize(me, exportPermission); err != nil {
+		return nil, err
 	}
 
 	msg, err := r.exportInternal(ctx, property, me)
@@ -72,8 +75,8 @@ func (r *Resolver) exportInternal(ctx context.Context, property *PropertyResolve
 		return nil, err
 	}
 
-	sender := fmt.Sprintf("%s <%s>", utilities.SystemName, utilities.SystemEmail)
-	to := []string{fmt.Sprintf("%s <%s>", me.Nickname(), me.Email())}
+	sender := (&mail.Address{Name: utilities.SystemName, Address: utilities.SystemEmail}).String()
+	to := []string{(&mail.Address{Name: me.Nickname(), Address: me.Email()}).String()}
 
 	attachment := platform.EmailAttachment{}
 	//attachment.ContentID = utilities.NewGuid()
@@ -90,3 +93,209 @@ func (r *Resolver) exportInternal(ctx context.Context, property *PropertyResolve
 
 	return msg, err
 }
+
+// personalDataEvent is a single event in the personal data export
+type personalDataEvent struct {
+	Type  string
+	Event map[string]interface{}
+}
+
+// personalDataAuthorFields are the event fields kept when the user only authored an event about someone else
+var personalDataAuthorFields = []string{"EventVersion", "CreateDateTime", "AuthorUserId", "AuthorUserID"}
+
+// personalDataFields returns the fields of an event that belong to the user, or nil if the event does not reference the user.
+// If the user is the subject of the event, for example the user a reservation is for, then the event is returned without
+// the ids of other users. If the user only authored the event, then only the author, version and date are returned,
+// since the rest of the event is about another user.
+func personalDataFields(event platform.VersionedEvent, userID string) (map[string]interface{}, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	isAuthor := false
+	isSubject := false
+	for key, value := range fields {
+		author := key == "AuthorUserId" || key == "AuthorUserID"
+		switch value := value.(type) {
+		case string:
+			if value == userID {
+				if author {
+					isAuthor = true
+				} else {
+					isSubject = true
+				}
+			}
+		case []interface{}:
+			for _, item := range value {
+				if item == userID {
+					isSubject = true
+				}
+			}
+		}
+	}
+
+	if isSubject {
+		for key, value := range fields {
+			if key == "AuthorUserId" || key == "AuthorUserID" {
+				if value != userID {
+					delete(fields, key)
+				}
+				continue
+			}
+			// lists of users, for example the recipients of a notification, are reduced to just the user
+			if list, ok := value.([]interface{}); ok && strings.HasSuffix(key, "UserIds") {
+				filtered := []interface{}{}
+				for _, item := range list {
+					if item == userID {
+						filtered = append(filtered, item)
+					}
+				}
+				fields[key] = filtered
+			}
+		}
+		return fields, nil
+	}
+
+	if isAuthor {
+		authored := make(map[string]interface{})
+		for _, key := range personalDataAuthorFields {
+			if value, ok := fields[key]; ok {
+				authored[key] = value
+			}
+		}
+		return authored, nil
+	}
+
+	return nil, nil
+}
+
+// personalDataNotification is a single notification in the personal data export
+type personalDataNotification struct {
+	NotificationID string
+	CreateDateTime string
+	Subject        string
+	Body           string
+}
+
+// personalDataExport defines the contents of the personal data export json file
+type personalDataExport struct {
+	UserID        string
+	Nickname      string
+	Email         string
+	Events        []personalDataEvent
+	Notifications []personalDataNotification
+}
+
+// PersonalDataExport emails a user their own data from the events and notifications that reference the user
+func (r *Resolver) PersonalDataExport(ctx context.Context, args *struct {
+	PropertyID string
+	UserID     string
+}) (*PropertyResolver, error) {
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a user can export their own data, otherwise only an admin can export the data of a user
+	if me.UserID() != args.UserID {
+		if err := authorize(me, manageUsersPermission); err != nil {
+			return nil, err
+		}
+	}
+
+	users := property.Users(&usersArgs{UserID: &args.UserID})
+	if len(users) == 0 {
+		return nil, errors.New("target user does not exist")
+	}
+
+	if users[0].isErased() || users[0].IsSystem() {
+		return nil, errors.New("cannot export data for this user")
+	}
+
+	msg, err := r.personalDataExportInternal(ctx, property, users[0])
+	if err != nil {
+		return nil, err
+	}
+
+	err = EmailSender.Send(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
+
+func (r *Resolver) personalDataExportInternal(ctx context.Context, property *PropertyResolver, user *UserResolver) (*platform.EmailMessage, error) {
+
+	export := &personalDataExport{
+		UserID:        user.UserID(),
+		Nickname:      user.Nickname(),
+		Email:         user.Email(),
+		Events:        []personalDataEvent{},
+		Notifications: []personalDataNotification{},
+	}
+
+	// user ids are guids, so an event references the user if the id is one of its fields
+	for _, event := range property.property.Events {
+		fields, err := personalDataFields(event, user.UserID())
+		if err != nil {
+			return nil, err
+		}
+		if fields != nil {
+			export.Events = append(export.Events, personalDataEvent{
+				Type:  reflect.TypeOf(event).Elem().Name(),
+				Event: fields,
+			})
+		}
+	}
+
+	id := user.UserID()
+	notifications, err := property.Notifications(&notificationArgs{UserID: &id})
+	if err != nil {
+		return nil, err
+	}
+	for _, notification := range notifications {
+		subject, err := notification.Subject()
+		if err != nil {
+			return nil, err
+		}
+		body, err := notification.Body()
+		if err != nil {
+			return nil, err
+		}
+		export.Notifications = append(export.Notifications, personalDataNotification{
+			NotificationID: notification.NotificationID(),
+			CreateDateTime: notification.CreateDateTime(),
+			Subject:        subject,
+			Body:           body,
+		})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	sender := (&mail.Address{Name: utilities.SystemName, Address: utilities.SystemEmail}).String()
+	to := []string{(&mail.Address{Name: user.Nickname(), Address: user.Email()}).String()}
+
+	attachment := platform.EmailAttachment{}
+	attachment.Data = data
+	attachment.Name = "personal_data.json"
+
+	msg := &platform.EmailMessage{
+		Sender:      sender,
+		To:          to,
+		Subject:     "Personal data export",
+		Body:        "Attached are all the events and notifications that reference you",
+		Attachments: []platform.EmailAttachment{attachment},
+	}
+
+	return msg, nil
+}
@@ -29,7 +29,7 @@ func TestExport(t *testing.T) {
 		t.Fatalf("wrong property id")
 	}
 
-	importedProperty, err := resolver.Property(ctx, &struct{ ID string }{ID: importedPropertyID})
+	importedProperty, err := resolver.Property(ctx, &propertyArgs{ID: importedPropertyID})
 
 	if err != nil {
 		t.Fatal(err)
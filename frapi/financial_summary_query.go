@@ -0,0 +1,335 @@
+package frapi
+
+import (
+	"fmt"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+)
+
+const financialSummaryGQL = `
+# the financial position of the property, totals over the ledgers of the users
+type FinancialSummary {
+	# today in the property timezone
+	date: String!
+	# sum of the balances
+	totalBalance(format: AmountFormat = DECIMAL): String!
+	# sum of the negative balances, as a positive amount
+	outstandingDebt(format: AmountFormat = DECIMAL): String!
+	# sum of the positive balances
+	totalCredit(format: AmountFormat = DECIMAL): String!
+	# users with a negative balance
+	debtors: Int!
+	# the outstanding debt by how many days balances have been negative
+	aging: [AgingBucket!]!
+	# oldest month first, up to the current month
+	months: [FinancialMonth!]!
+}
+
+type AgingBucket {
+	minDays: Int!
+	# null for the last bucket
+	maxDays: Int
+	users: Int!
+	amount(format: AmountFormat = DECIMAL): String!
+}
+
+type FinancialMonth {
+	# ex. 2026-10
+	month: String!
+	# charged for reservations less cancellations
+	reservations(format: AmountFormat = DECIMAL): String!
+	# charged for memberships less opt outs
+	memberships(format: AmountFormat = DECIMAL): String!
+	# expenses charged to users less reversals
+	expenses(format: AmountFormat = DECIMAL): String!
+	# payments received less reversals
+	payments(format: AmountFormat = DECIMAL): String!
+	# sum of the balances at the end of the month
+	balance(format: AmountFormat = DECIMAL): String!
+	# sum of the negative balances at the end of the month, as a positive amount
+	outstandingDebt(format: AmountFormat = DECIMAL): String!
+}
+`
+
+// the default and max number of months in a financial summary
+const (
+	defaultFinancialSummaryMonths = 12
+	maxFinancialSummaryMonths     = 60
+)
+
+// agingBucketDays are the first and last days negative of each aging bucket, 0 for no last day
+var agingBucketDays = [][2]int32{{0, 30}, {31, 60}, {61, 90}, {91, 0}}
+
+type financialSummaryArgs struct {
+	Months *int32
+}
+
+// FinancialSummary is called by gql to get the financial position of the property
+func (r *PropertyResolver) FinancialSummary(args *financialSummaryArgs) (*FinancialSummaryResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, viewLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	months := int32(defaultFinancialSummaryMonths)
+	if args.Months != nil {
+		months = *args.Months
+	}
+	if months < 1 || months > maxFinancialSummaryMonths {
+		return nil, fmt.Errorf("months must be from 1 to %v", maxFinancialSummaryMonths)
+	}
+
+	return r.financialSummary(int(months))
+}
+
+// financialSummary adds up the ledgers of the users for the months up to the current month
+func (r *PropertyResolver) financialSummary(numMonths int) (*FinancialSummaryResolver, error) {
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+	today := dateBuilder.Today()
+
+	summary := &FinancialSummaryResolver{today: today, money: settings.moneyFormat()}
+	for _, days := range agingBucketDays {
+		summary.aging = append(summary.aging, &AgingBucketResolver{minDays: days[0], maxDays: days[1], money: summary.money})
+	}
+
+	// the months, oldest first
+	summary.months = make([]*FinancialMonthResolver, numMonths)
+	start, end := today.MonthInOut()
+	for i := numMonths - 1; i >= 0; i-- {
+		summary.months[i] = &FinancialMonthResolver{start: start, end: end, money: summary.money}
+		end = start
+		start, _ = start.AddDays(-1).MonthInOut()
+	}
+
+	ledgers, err := r.Ledgers(&ledgersArgs{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ledger := range ledgers {
+		if ledger.User().IsSystem() {
+			continue
+		}
+
+		balance := int32(0)
+		var negativeSince *frdate.Date
+		month := 0
+		for _, record := range ledger.Records() {
+			date := dateBuilder.MustNewDateTime(record.EventDateTime()).ToDate()
+
+			// the balance at the end of the months before the record
+			for month < numMonths && !date.Before(summary.months[month].end) {
+				summary.months[month].addBalance(balance)
+				month++
+			}
+			if month < numMonths && !date.Before(summary.months[month].start) {
+				summary.months[month].addRecord(record.Event(), record.amountInternal().Raw())
+			}
+
+			balance = record.balanceInternal().Raw()
+			if balance >= 0 {
+				negativeSince = nil
+			} else if negativeSince == nil {
+				negativeSince = date
+			}
+		}
+		for ; month < numMonths; month++ {
+			summary.months[month].addBalance(balance)
+		}
+
+		summary.addBalance(balance, negativeSince)
+	}
+
+	return summary, nil
+}
+
+// FinancialSummaryResolver resolves the financial position of the property
+type FinancialSummaryResolver struct {
+	today  *frdate.Date
+	money  *moneyFormat
+	credit int32
+	debt   int32
+	aging  []*AgingBucketResolver
+	months []*FinancialMonthResolver
+}
+
+// addBalance adds the current balance of a user, and to the aging bucket if negative
+func (r *FinancialSummaryResolver) addBalance(balance int32, negativeSince *frdate.Date) {
+	if balance >= 0 {
+		r.credit += balance
+		return
+	}
+
+	r.debt -= balance
+	// no days if the balance went negative after today (ex. a test time offset)
+	days, _ := frdate.DaysList(negativeSince, r.today, false)
+	for i := len(r.aging) - 1; i >= 0; i-- {
+		if int32(len(days)) >= r.aging[i].minDays {
+			r.aging[i].users++
+			r.aging[i].amount -= balance
+			return
+		}
+	}
+}
+
+// Date is the day of the summary
+func (r *FinancialSummaryResolver) Date() string {
+	return r.today.ToString()
+}
+
+// TotalBalance is the sum of the balances
+func (r *FinancialSummaryResolver) TotalBalance(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.credit-r.debt, args.Format)
+}
+
+// OutstandingDebt is the sum of the negative balances
+func (r *FinancialSummaryResolver) OutstandingDebt(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.debt, args.Format)
+}
+
+// TotalCredit is the sum of the positive balances
+func (r *FinancialSummaryResolver) TotalCredit(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.credit, args.Format)
+}
+
+// Debtors is the number of users with a negative balance
+func (r *FinancialSummaryResolver) Debtors() int32 {
+	debtors := int32(0)
+	for _, bucket := range r.aging {
+		debtors += bucket.users
+	}
+	return debtors
+}
+
+// Aging is the outstanding debt by days negative
+func (r *FinancialSummaryResolver) Aging() []*AgingBucketResolver {
+	return r.aging
+}
+
+// Months are the monthly totals
+func (r *FinancialSummaryResolver) Months() []*FinancialMonthResolver {
+	return r.months
+}
+
+// AgingBucketResolver resolves the negative balances of a range of days
+type AgingBucketResolver struct {
+	minDays int32
+	maxDays int32
+	users   int32
+	amount  int32
+	money   *moneyFormat
+}
+
+// MinDays is the first day negative of the bucket
+func (r *AgingBucketResolver) MinDays() int32 {
+	return r.minDays
+}
+
+// MaxDays is the last day negative of the bucket, nil for the last bucket
+func (r *AgingBucketResolver) MaxDays() *int32 {
+	if r.maxDays == 0 {
+		return nil
+	}
+	return &r.maxDays
+}
+
+// Users is the number of users with a balance negative for the days of the bucket
+func (r *AgingBucketResolver) Users() int32 {
+	return r.users
+}
+
+// Amount is the sum of the negative balances, as a positive amount
+func (r *AgingBucketResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.amount, args.Format)
+}
+
+// label is the days of the bucket, ex. 31-60 or 91+
+func (r *AgingBucketResolver) label() string {
+	if r.maxDays == 0 {
+		return fmt.Sprintf("%v+", r.minDays)
+	}
+	return fmt.Sprintf("%v-%v", r.minDays, r.maxDays)
+}
+
+// FinancialMonthResolver resolves the totals of a month
+type FinancialMonthResolver struct {
+	start        *frdate.Date
+	end          *frdate.Date
+	reservations int32
+	memberships  int32
+	expenses     int32
+	payments     int32
+	balance      int32
+	debt         int32
+	money        *moneyFormat
+}
+
+// addRecord adds a ledger record to its category, charges to users are counted as positive amounts
+func (r *FinancialMonthResolver) addRecord(event LedgerEvent, amount int32) {
+	switch event {
+	case reservationLedgerEvent, cancelReservationLedgerEvent:
+		r.reservations -= amount
+	case purchaseMembershipLedgerEvent, optoutMembershipLedgerEvent:
+		r.memberships -= amount
+	case expenseLedgerEvent:
+		r.expenses -= amount
+	case paymentLedgerEvent:
+		r.payments += amount
+	case reversalLedgerEvent:
+		// a reversed payment takes money from the balance, a reversed expense gives it back
+		if amount < 0 {
+			r.payments += amount
+		} else {
+			r.expenses -= amount
+		}
+	}
+}
+
+// addBalance adds the balance of a user at the end of the month
+func (r *FinancialMonthResolver) addBalance(balance int32) {
+	r.balance += balance
+	if balance < 0 {
+		r.debt -= balance
+	}
+}
+
+// Month is the year and month, ex. 2026-10
+func (r *FinancialMonthResolver) Month() string {
+	return r.start.Format("2006-01")
+}
+
+// Reservations is the amount charged for reservations
+func (r *FinancialMonthResolver) Reservations(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.reservations, args.Format)
+}
+
+// Memberships is the amount charged for memberships
+func (r *FinancialMonthResolver) Memberships(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.memberships, args.Format)
+}
+
+// Expenses is the amount of expenses charged
+func (r *FinancialMonthResolver) Expenses(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.expenses, args.Format)
+}
+
+// Payments is the amount paid
+func (r *FinancialMonthResolver) Payments(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.payments, args.Format)
+}
+
+// Balance is the sum of the balances at the end of the month
+func (r *FinancialMonthResolver) Balance(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.balance, args.Format)
+}
+
+// OutstandingDebt is the sum of the negative balances at the end of the month
+func (r *FinancialMonthResolver) OutstandingDebt(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.debt, args.Format)
+}
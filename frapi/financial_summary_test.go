@@ -0,0 +1,126 @@
+package frapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func TestFinancialSummary(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	defer func() { frdate.TestTimeOffsetDays = nil }()
+
+	property, jane := createUser(ctx, t, resolver, property, "jane@example.com", "Jane")
+	property, bob := createUser(ctx, t, resolver, property, "bob@example.com", "Bob")
+
+	updateBalance := func(days int, userID string, amount int32, increase bool) {
+		frdate.TestTimeOffsetDays = &days
+		var err error
+		property, err = resolver.UpdateBalance(ctx, &struct {
+			PropertyID string
+			Input      *models.UpdateBalanceInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input: &models.UpdateBalanceInput{
+				ForVersion:      property.EventVersion(),
+				UpdateForUserId: userID,
+				Amount:          amount,
+				Description:     "test balance",
+				Increase:        increase,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reservationAmount := 2 * defaultPropertyInput.MemberRate
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(3).ToString())
+	updateBalance(0, me.UserID(), 3000, false)
+	updateBalance(0, jane.UserID(), 5000, false)
+	updateBalance(0, bob.UserID(), 2000, true)
+	updateBalance(40, me.UserID(), 3000+reservationAmount, true)
+	updateBalance(70, me.UserID(), 1000, false)
+
+	days := 75
+	frdate.TestTimeOffsetDays = &days
+	summary, err := property.FinancialSummary(&financialSummaryArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount := func(resolve func(args *struct{ Format amountFormat }) (string, error)) string {
+		value, err := resolve(&struct{ Format amountFormat }{Format: nodecimal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	t.Log("the current position")
+	if amount(summary.TotalBalance) != "-4000" || amount(summary.OutstandingDebt) != "6000" || amount(summary.TotalCredit) != "2000" ||
+		summary.Debtors() != 2 {
+		t.Fatalf("unexpected position %v %v %v %v", amount(summary.TotalBalance), amount(summary.OutstandingDebt),
+			amount(summary.TotalCredit), summary.Debtors())
+	}
+
+	t.Log("the debt aging")
+	expectedAging := []struct {
+		users  int32
+		amount string
+	}{{1, "1000"}, {0, "0"}, {1, "5000"}, {0, "0"}}
+	for i, bucket := range summary.Aging() {
+		if bucket.Users() != expectedAging[i].users || amount(bucket.Amount) != expectedAging[i].amount {
+			t.Fatalf("unexpected aging bucket %v: %v users %v", bucket.label(), bucket.Users(), amount(bucket.Amount))
+		}
+	}
+	if summary.Aging()[3].MaxDays() != nil || *summary.Aging()[0].MaxDays() != 30 {
+		t.Fatal("unexpected aging bucket days")
+	}
+
+	t.Log("the monthly totals")
+	months := summary.Months()
+	if len(months) != defaultFinancialSummaryMonths {
+		t.Fatalf("expected %v months but got %v", defaultFinancialSummaryMonths, len(months))
+	}
+	reservations, expenses, payments := int32(0), int32(0), int32(0)
+	for _, month := range months {
+		reservations += month.reservations
+		expenses += month.expenses
+		payments += month.payments
+	}
+	if reservations != reservationAmount || expenses != 9000 || payments != 5000+reservationAmount {
+		t.Fatalf("unexpected monthly totals %v %v %v", reservations, expenses, payments)
+	}
+	last := months[len(months)-1]
+	if last.Month() != frdate.MustNewDateBuilder(defaultPropertyInput.Timezone).Today().Format("2006-01") ||
+		amount(last.Balance) != "-4000" || amount(last.OutstandingDebt) != "6000" {
+		t.Fatalf("unexpected current month %v %v %v", last.Month(), amount(last.Balance), amount(last.OutstandingDebt))
+	}
+
+	t.Log("the summary is exported")
+	attachment, err := resolver.exportFinancialSummary(ctx, property, me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csv := string(attachment.Data); !strings.Contains(csv, "61-90 DAYS,50.00") || !strings.Contains(csv, "OUTSTANDING_DEBT,60.00") {
+		t.Fatalf("unexpected financial summary csv:\n%v", csv)
+	}
+
+	t.Log("the months are limited")
+	tooMany := int32(maxFinancialSummaryMonths + 1)
+	if _, err := property.FinancialSummary(&financialSummaryArgs{Months: &tooMany}); err == nil {
+		t.Fatal("expected an error for too many months")
+	}
+
+	t.Log("a member cannot view the summary")
+	testUserEmail = "jane@example.com"
+	defer func() { testUserEmail = defaultEmail }()
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, err := property.FinancialSummary(&financialSummaryArgs{}); err == nil {
+		t.Fatal("expected a member view of the financial summary to fail")
+	}
+}
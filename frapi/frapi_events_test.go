@@ -46,7 +46,7 @@ func TestHappyPath(t *testing.T) {
 	}
 
 	// Query property
-	property, _ = resolver.Property(ctx, &struct{ ID string }{ID: property.PropertyID()})
+	property, _ = resolver.Property(ctx, &propertyArgs{ID: property.PropertyID()})
 
 	me, _ := property.Me()
 
@@ -297,3 +297,32 @@ func createBlackoutRestriction(ctx context.Context, t *testing.T, resolver *Reso
 
 	return property, blackoutRestriction, restrictions
 }
+
+// currentSettingsInput returns an update settings input with the current settings of the property
+func currentSettingsInput(property *PropertyResolver) *models.UpdateSettingsInput {
+	settings, _ := property.Settings(&settingsArgs{})
+	return &models.UpdateSettingsInput{
+		ForVersion:                    property.EventVersion(),
+		PropertyName:                  settings.PropertyName(),
+		Currency:                      settings.settings.Currency,
+		MemberRate:                    settings.memberRateInternal(),
+		AllowNonMembers:               settings.AllowNonMembers(),
+		NonMemberRate:                 settings.nonMemberRateInternal(),
+		Timezone:                      settings.Timezone(),
+		MinBalance:                    settings.minBalanceInternal().Raw(),
+		MaxOutDays:                    settings.MaxOutDays(),
+		MinInDays:                     settings.MinInDays(),
+		ReservationReminderDaysBefore: settings.ReservationReminderDaysBefore(),
+		BalanceReminderIntervalDays:   settings.BalanceReminderIntervalDays(),
+	}
+}
+
+func updateSettings(ctx context.Context, resolver *Resolver, property *PropertyResolver, input *models.UpdateSettingsInput) (*PropertyResolver, error) {
+	return resolver.UpdateSettings(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateSettingsInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      input,
+	})
+}
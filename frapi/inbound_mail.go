@@ -0,0 +1,216 @@
+package frapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+// maxCommentLength is the max number of characters kept from a reply
+const maxCommentLength = 4000
+
+// replyTokenBytes is the number of random bytes in the secret token of a reply address
+const replyTokenBytes = 16
+
+// replyAddressPattern matches the tag of a reply address, i.e. the property id, notification id and reply token
+var replyAddressPattern = regexp.MustCompile(`\+([0-9a-zA-Z-]+)\.([0-9a-fA-F-]{36})\.([0-9a-f]{32})@`)
+
+// quoteStartPattern matches the line mail clients add before the quoted original message
+var quoteStartPattern = regexp.MustCompile(`^(On .*wrote:|-+\s*Original Message\s*-+)$`)
+
+// newReplyToken returns a random secret for the reply address of a notification, so a reply cannot be
+// forged from the property and notification ids alone
+func newReplyToken() string {
+	token := make([]byte, replyTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
+
+// replyAddress returns the Reply-To address for a notification email, empty if replies are not received,
+// example reply+propertyid.notificationid.token@example.com for inbound address reply@example.com
+func replyAddress(propertyID string, notificationID string, replyToken string) string {
+	at := strings.LastIndex(utilities.InboundEmailAddress, "@")
+	if at <= 0 || replyToken == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s+%s.%s.%s%s", utilities.InboundEmailAddress[:at], propertyID, notificationID, replyToken,
+		utilities.InboundEmailAddress[at:])
+}
+
+// ReceiveEmail is called by the service with a raw RFC 822 reply to a notification email, the reply is recorded
+// as a comment on the notification, recipient is the address the message was delivered to if known by the caller
+func ReceiveEmail(ctx context.Context, recipient string, message io.Reader) error {
+	msg, err := mail.ReadMessage(message)
+	if err != nil {
+		return err
+	}
+
+	// find the notification from the reply address
+	propertyID, notificationID, replyToken, err := replyIDs(recipient, msg.Header)
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return err
+	}
+
+	property, err := currentBaseProperty(ctx, utilities.SystemEmail, propertyID)
+	if err != nil {
+		return err
+	}
+
+	notifications, err := property.Notifications(&notificationArgs{notificationID: &notificationID})
+	if err != nil {
+		return err
+	}
+	if len(notifications) != 1 {
+		return errors.New("notification does not exist")
+	}
+	notification := notifications[0]
+
+	expectedToken := notification.rollup.Input.ReplyToken
+	if expectedToken == "" || subtle.ConstantTimeCompare([]byte(replyToken), []byte(expectedToken)) != 1 {
+		return errors.New("reply address does not match the notification")
+	}
+
+	// only the users notified can comment
+	users := property.Users(&usersArgs{Email: &from.Address})
+	if len(users) != 1 || users[0].isErased() {
+		return fmt.Errorf("reply from %v is not from a user of the property", from.Address)
+	}
+	if !notification.rollup.TargetUserIdsMap[users[0].UserID()] {
+		return fmt.Errorf("reply from %v is not from a recipient of the notification", from.Address)
+	}
+
+	// the mail server may deliver the same message twice
+	messageID := msg.Header.Get("Message-Id")
+	if messageID != "" {
+		for _, comment := range notification.rollup.Comments {
+			if comment.MessageId == messageID {
+				Logger.LogInfof("ReceiveEmail: message %v already received", messageID)
+				return nil
+			}
+		}
+	}
+
+	body, err := plainTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return err
+	}
+	comment := stripQuotedReply(body)
+	if comment == "" {
+		return errors.New("reply has no comment")
+	}
+	if runes := []rune(comment); len(runes) > maxCommentLength {
+		comment = string(runes[:maxCommentLength])
+	}
+
+	commentInput := &models.NotificationCommentInput{
+		NotificationId: notificationID,
+		Comment:        comment,
+		MessageId:      messageID,
+		CreateDateTime: frdate.CreateDateTimeUTC(),
+		AuthorUserId:   users[0].UserID(),
+	}
+
+	_, err = commitCronChanges(ctx, propertyID, commentInput)
+	return err
+}
+
+// replyIDs returns the property id, notification id and reply token from the address the reply was sent to
+func replyIDs(recipient string, header mail.Header) (string, string, string, error) {
+	candidates := []string{recipient}
+	for _, key := range []string{"Delivered-To", "X-Original-To", "To", "Cc"} {
+		candidates = append(candidates, header[key]...)
+	}
+	for _, candidate := range candidates {
+		if matches := replyAddressPattern.FindStringSubmatch(candidate); matches != nil {
+			return matches[1], matches[2], matches[3], nil
+		}
+	}
+	return "", "", "", errors.New("no notification reply address found")
+}
+
+// plainTextBody returns the text/plain part of a message body
+func plainTextBody(contentType string, transferEncoding string, body io.Reader) (string, error) {
+	mediaType := "text/plain"
+	params := map[string]string{}
+	if contentType != "" {
+		var err error
+		mediaType, params, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := plainTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err == nil && text != "" {
+				return text, nil
+			}
+		}
+		return "", errors.New("no text part found")
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(transferEncoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	text, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// stripQuotedReply removes the original message quoted below a reply
+func stripQuotedReply(body string) string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if quoteStartPattern.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
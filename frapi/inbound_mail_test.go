@@ -0,0 +1,121 @@
+package frapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+const testReply = `From: Admin <%s>
+To: %s
+Subject: Re: new reservation
+Message-Id: <reply1@test.com>
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Can we extend the stay by a night? The fridge is =
+broken too.
+
+On Mon, Oct 19, 2026 at 9:00 AM Friendly Reservations wrote:
+> A new reservation has been made
+--b1
+Content-Type: text/html; charset=utf-8
+
+<p>Can we extend the stay by a night?</p>
+--b1--
+`
+
+func TestInboundEmailReply(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	inboundAddress := utilities.InboundEmailAddress
+	utilities.InboundEmailAddress = "reply@test.com"
+	defer func() { utilities.InboundEmailAddress = inboundAddress }()
+
+	sender := &testEmailSender{}
+	EmailSender = sender
+
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	notifications, _ := property.Notifications(&notificationArgs{Reverse: &[]bool{true}[0]})
+	notification := notifications[0]
+
+	t.Log("the notification email has a reply address for the notification")
+	replyTo := replyAddress(property.PropertyID(), notification.NotificationID(), notification.rollup.Input.ReplyToken)
+	if replyTo == "" || sender.last == nil || sender.last.ReplyTo != replyTo {
+		t.Fatalf("expected reply to %+v", replyTo)
+	}
+
+	t.Log("a reply is recorded as a comment")
+	reply := fmt.Sprintf(testReply, defaultEmail, replyTo)
+	if err := ReceiveEmail(ctx, "", strings.NewReader(reply)); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	notificationID := notification.NotificationID()
+	notifications, _ = property.Notifications(&notificationArgs{notificationID: &notificationID})
+	comments, err := notifications[0].Comments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("expected 1 comment but got %+v", len(comments))
+	}
+	if comments[0].Comment() != "Can we extend the stay by a night? The fridge is broken too." {
+		t.Fatalf("unexpected comment %+v", comments[0].Comment())
+	}
+	if comments[0].Author().UserID() != me.UserID() {
+		t.Fatal("expected the comment author to be me")
+	}
+
+	t.Log("the same reply is only recorded once")
+	if err := ReceiveEmail(ctx, "", strings.NewReader(reply)); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	notifications, _ = property.Notifications(&notificationArgs{notificationID: &notificationID})
+	if len(notifications[0].rollup.Comments) != 1 {
+		t.Fatal("expected the duplicate reply to be ignored")
+	}
+
+	t.Log("replies from strangers and without a reply address are rejected")
+	stranger := fmt.Sprintf(testReply, "stranger@test.com", replyTo)
+	if err := ReceiveEmail(ctx, "", strings.NewReader(stranger)); err == nil {
+		t.Fatal("expected a reply from a stranger to fail")
+	}
+	untagged := fmt.Sprintf(testReply, defaultEmail, utilities.InboundEmailAddress)
+	if err := ReceiveEmail(ctx, "", strings.NewReader(untagged)); err == nil {
+		t.Fatal("expected a reply without a notification to fail")
+	}
+
+	t.Log("replies with a guessed reply token are rejected")
+	guessed := replyAddress(property.PropertyID(), notification.NotificationID(), strings.Repeat("0", 2*replyTokenBytes))
+	forged := strings.Replace(fmt.Sprintf(testReply, defaultEmail, guessed), "reply1@", "reply2@", 1)
+	if err := ReceiveEmail(ctx, "", strings.NewReader(forged)); err == nil {
+		t.Fatal("expected a reply with the wrong token to fail")
+	}
+
+	t.Log("a member cannot view comments")
+	memberEmail := "member@test.com"
+	createUserWithRoles(ctx, t, resolver, property, memberEmail, "member", []models.UserRole{})
+
+	t.Log("replies from users who were not notified are rejected")
+	notNotified := strings.Replace(fmt.Sprintf(testReply, memberEmail, replyTo), "reply1@", "reply3@", 1)
+	if err := ReceiveEmail(ctx, "", strings.NewReader(notNotified)); err == nil {
+		t.Fatal("expected a reply from a user who was not notified to fail")
+	}
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property = getUpdatedProperty(ctx, t, resolver)
+	notifications, _ = property.Notifications(&notificationArgs{notificationID: &notificationID})
+	if _, err := notifications[0].Comments(); err == nil {
+		t.Fatal("expected member view comments to fail")
+	}
+}
@@ -25,6 +25,15 @@ var PersistedPropertyList platform.PersistedPropertyList
 // EmailSender is used to send emails
 var EmailSender platform.SendMail
 
+// Notifiers send urgent notifications on channels other than email, at most one per channel
+var Notifiers []platform.Notifier
+
+// PaymentProvider collects online payments towards balances, nil if online payments are not configured
+var PaymentProvider platform.PaymentProvider
+
+// PushPublicKey is the VAPID key browsers subscribe to web push with, empty if push is not configured
+var PushPublicKey string
+
 // FrapiCookies contains helper functions for setting and getting cookies
 var FrapiCookies *cookies.AuthCookies
 
@@ -48,4 +57,5 @@ func init() {
 	gob.Register(&RestrictionRollup{})
 	gob.Register(&ContentRollup{})
 	gob.Register(&MembershipRollupRecord{})
+	gob.Register(&PaymentPlanRollup{})
 }
@@ -3,6 +3,7 @@ package frapi
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/bjorge/friendlyreservations/frdate"
@@ -32,8 +33,8 @@ func (r *Resolver) UpdateBalance(ctx context.Context, args *struct {
 		return nil, err
 
 	}
-	if !me.IsAdmin() {
-		return nil, errors.New("only admins can make payments")
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
 	}
 
 	constraints, err := propertyResolver.UpdateBalanceConstraints()
@@ -76,9 +77,97 @@ func (r *Resolver) UpdateBalance(ctx context.Context, args *struct {
 
 	if err == nil {
 		// send the email notification
-		notifications, _ := propertyResolver.Notifications(&notificationArgs{notificationID: &newNotificationInput.NotificationId})
-		sendEmail(ctx, propertyResolver, notifications[0])
+		propertyResolver = deliverNotification(ctx, propertyResolver, newNotificationInput.NotificationId)
+	}
+
+	return propertyResolver, err
+}
+
+// ReverseLedgerEntry undoes a mistaken payment or expense with a reversal linked to it, a record can
+// only be reversed once
+func (r *Resolver) ReverseLedgerEntry(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.ReverseLedgerEntryInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Reverse Ledger Entry")
+
+	// get the current property
+	propertyResolver, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, propertyResolver); duplicate || err != nil {
+		if err == nil {
+			return propertyResolver, nil
+		}
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	original := propertyResolver.balanceUpdate(args.Input.PaymentId)
+	if original == nil {
+		return nil, fmt.Errorf("payment %v does not exist", args.Input.PaymentId)
+	}
+	if propertyResolver.reversedPaymentIDs()[args.Input.PaymentId] {
+		return nil, fmt.Errorf("payment %v is already reversed", args.Input.PaymentId)
+	}
+
+	constraints, err := propertyResolver.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	args.Input.Description = strings.TrimSpace(args.Input.Description)
+	if len(args.Input.Description) < int(constraints.DescriptionMin()) {
+		return nil, errors.New("description too small")
+	}
+
+	if len(args.Input.Description) > int(constraints.DescriptionMax()) {
+		return nil, errors.New("description too big")
+	}
+
+	// input looks good, now add extra internal values
+	args.Input.UpdateForUserId = original.UpdateForUserId
+	args.Input.Amount = original.Amount
+	args.Input.Increase = !original.Increase
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserId = me.UserID()
+
+	paramGroup := templates.Ledger
+	newNotificationInput := createNotificationRecord(notificationTargetMember, propertyResolver, templates.BalanceChangeNotification,
+		&args.Input.UpdateForUserId, &paramGroup, &args.Input.UpdateForUserId)
+
+	propertyResolver, err = commitChanges(ctx, args.PropertyID, propertyResolver.EventVersion(), args.Input, newNotificationInput)
+
+	if err == nil {
+		// send the email notification
+		propertyResolver = deliverNotification(ctx, propertyResolver, newNotificationInput.NotificationId)
 	}
 
 	return propertyResolver, err
 }
+
+// balanceUpdate returns the payment or expense with the payment id, nil if there is none
+func (r *PropertyResolver) balanceUpdate(paymentID string) *models.UpdateBalanceInput {
+	for _, event := range r.property.Events {
+		if updateBalanceInput, ok := event.(*models.UpdateBalanceInput); ok && updateBalanceInput.PaymentId == paymentID {
+			return updateBalanceInput
+		}
+	}
+	return nil
+}
+
+// reversedPaymentIDs returns the payment ids of the reversed payments and expenses
+func (r *PropertyResolver) reversedPaymentIDs() map[string]bool {
+	ids := make(map[string]bool)
+	for _, event := range r.property.Events {
+		if reverseLedgerEntryInput, ok := event.(*models.ReverseLedgerEntryInput); ok {
+			ids[reverseLedgerEntryInput.PaymentId] = true
+		}
+	}
+	return ids
+}
@@ -18,6 +18,8 @@ enum LedgerEvent {
 	MEMBERSHIP_PAYMENT
 	MEMBERSHIP_OPTOUT
 	START
+	# undoes a mistaken PAYMENT or EXPENSE
+	REVERSAL
 }
 
 type LedgerRecord {
@@ -25,6 +27,16 @@ type LedgerRecord {
 	event: LedgerEvent!
 	balance(format: AmountFormat = DECIMAL): String!
 	amount(format: AmountFormat = DECIMAL): String!
+	# the same for the records of an expense split between users
+	expenseId: String
+	# the id of a PAYMENT or EXPENSE, used to reverse it with reverseLedgerEntry
+	paymentId: String
+	# the payment or expense description, or why a REVERSAL was made
+	description: String
+	# for a REVERSAL, the payment id of the reversed record
+	reversedPaymentId: String
+	# true for a PAYMENT or EXPENSE that has been reversed
+	reversed: Boolean!
 }
 
 type Ledger {
@@ -45,6 +57,7 @@ const (
 	purchaseMembershipLedgerEvent LedgerEvent = "MEMBERSHIP_PAYMENT"
 	optoutMembershipLedgerEvent   LedgerEvent = "MEMBERSHIP_OPTOUT"
 	startLedgerEvent              LedgerEvent = "START"
+	reversalLedgerEvent           LedgerEvent = "REVERSAL"
 )
 
 type ledgersArgs struct {
@@ -85,9 +98,15 @@ func (r *LedgerResolver) User() *UserResolver {
 // Records returns ledger records for a user
 func (r *LedgerResolver) Records() []*LedgerRecordResolver {
 	l := []*LedgerRecordResolver{}
+	money := r.property.moneyFormat()
+	reversed := r.property.reversedPaymentIDs()
 	for _, rollup := range r.rollups {
 		recordResolver := &LedgerRecordResolver{}
 		recordResolver.rollup = rollup
+		recordResolver.money = money
+		if updateBalanceInput := recordResolver.updateBalanceInput(); updateBalanceInput != nil {
+			recordResolver.reversed = reversed[updateBalanceInput.PaymentId]
+		}
 		l = append(l, recordResolver)
 	}
 	return l
@@ -95,16 +114,18 @@ func (r *LedgerResolver) Records() []*LedgerRecordResolver {
 
 // LedgerRecordResolver resolves a ledger record
 type LedgerRecordResolver struct {
-	rollup *LedgerRollup
+	rollup   *LedgerRollup
+	money    *moneyFormat
+	reversed bool
 }
 
 // Amount is the ledger record amount
 func (r *LedgerRecordResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.rollup.Amount, args.Format)
+	return r.money.formatAmount(r.rollup.Amount, args.Format)
 }
 
 func (r *LedgerRecordResolver) amountInternal() *amountResolver {
-	return &amountResolver{r.rollup.Amount}
+	return &amountResolver{r.rollup.Amount, r.money}
 }
 
 // Event is the ledger record event
@@ -112,6 +133,62 @@ func (r *LedgerRecordResolver) Event() LedgerEvent {
 	return r.rollup.Event
 }
 
+// ExpenseID links the records of a split expense, nil for other records
+func (r *LedgerRecordResolver) ExpenseID() *string {
+	if updateBalanceInput := r.updateBalanceInput(); updateBalanceInput != nil && updateBalanceInput.ExpenseId != "" {
+		return &updateBalanceInput.ExpenseId
+	}
+	return nil
+}
+
+// PaymentID is the id of a payment or expense, nil for other records
+func (r *LedgerRecordResolver) PaymentID() *string {
+	if updateBalanceInput := r.updateBalanceInput(); updateBalanceInput != nil {
+		return &updateBalanceInput.PaymentId
+	}
+	return nil
+}
+
+// Description is the description of a payment or expense, or the reason of a reversal
+func (r *LedgerRecordResolver) Description() *string {
+	if updateBalanceInput := r.updateBalanceInput(); updateBalanceInput != nil {
+		return &updateBalanceInput.Description
+	}
+	if reverseLedgerEntryInput := r.reverseLedgerEntryInput(); reverseLedgerEntryInput != nil {
+		return &reverseLedgerEntryInput.Description
+	}
+	return nil
+}
+
+// ReversedPaymentID links a reversal to the reversed record, nil for other records
+func (r *LedgerRecordResolver) ReversedPaymentID() *string {
+	if reverseLedgerEntryInput := r.reverseLedgerEntryInput(); reverseLedgerEntryInput != nil {
+		return &reverseLedgerEntryInput.PaymentId
+	}
+	return nil
+}
+
+// Reversed is true for a payment or expense that has been reversed
+func (r *LedgerRecordResolver) Reversed() bool {
+	return r.reversed
+}
+
+func (r *LedgerRecordResolver) updateBalanceInput() *models.UpdateBalanceInput {
+	if r.rollup.VersionedEvent == nil {
+		return nil
+	}
+	updateBalanceInput, _ := (*r.rollup.VersionedEvent).(*models.UpdateBalanceInput)
+	return updateBalanceInput
+}
+
+func (r *LedgerRecordResolver) reverseLedgerEntryInput() *models.ReverseLedgerEntryInput {
+	if r.rollup.VersionedEvent == nil {
+		return nil
+	}
+	reverseLedgerEntryInput, _ := (*r.rollup.VersionedEvent).(*models.ReverseLedgerEntryInput)
+	return reverseLedgerEntryInput
+}
+
 // UserID is the ledger record user ID
 func (r *LedgerRecordResolver) UserID() string {
 	return r.rollup.UserID
@@ -119,6 +196,7 @@ func (r *LedgerRecordResolver) UserID() string {
 
 type amountResolver struct {
 	amount int32
+	money  *moneyFormat
 }
 
 func (r *amountResolver) Raw() int32 {
@@ -130,18 +208,16 @@ func (r *amountResolver) NoDecimal() string {
 }
 
 func (r *amountResolver) Decimal() string {
-	decimalPart := r.amount % 100
-	nonDecimalPart := r.amount / 100
-	return fmt.Sprintf("%d.%02d", int(nonDecimalPart), int(decimalPart))
+	return r.money.digits(r.amount, "", ".")
 }
 
 // Balance is the ledger record balance
 func (r *LedgerRecordResolver) Balance(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.rollup.Balance, args.Format)
+	return r.money.formatAmount(r.rollup.Balance, args.Format)
 }
 
 func (r *LedgerRecordResolver) balanceInternal() *amountResolver {
-	return &amountResolver{r.rollup.Balance}
+	return &amountResolver{r.rollup.Balance, r.money}
 }
 
 // EventDateTime is the ledger record timestamp
@@ -159,8 +235,8 @@ func (r *PropertyResolver) validateLedgersInput(args *ledgersArgs) error {
 	me, _ := r.Me()
 
 	if args.UserID == nil {
-		if !me.IsAdmin() && !me.IsSystem() {
-			return fmt.Errorf("must be admin or system (ex. cron job) to view all records")
+		if !me.hasPermission(viewLedgersPermission) && !me.IsSystem() {
+			return fmt.Errorf("must be admin, treasurer or system (ex. cron job) to view all records")
 		}
 	} else {
 		users := r.Users(&usersArgs{UserID: args.UserID})
@@ -169,8 +245,8 @@ func (r *PropertyResolver) validateLedgersInput(args *ledgersArgs) error {
 			return fmt.Errorf("user with id %v does not exist", args.UserID)
 		}
 
-		if !me.IsAdmin() && users[0].UserID() != *args.UserID {
-			return fmt.Errorf("user must be admin or same user to view ledger")
+		if !me.hasPermission(viewLedgersPermission) && !me.IsSystem() && me.UserID() != *args.UserID {
+			return fmt.Errorf("user must be admin, treasurer or same user to view ledger")
 		}
 	}
 
@@ -181,6 +257,17 @@ func (r *PropertyResolver) validateLedgersInput(args *ledgersArgs) error {
 	return nil
 }
 
+// hasLedgerEntries is true if a ledger of the property has a record after the start record
+func (r *PropertyResolver) hasLedgerEntries() bool {
+	r.rollupLedgers()
+	for _, iface := range r.getRollups(&rollupArgs{}, ledgerRollupType) {
+		if iface.(*LedgerRollup).Event != startLedgerEvent {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *PropertyResolver) resolveLedgers(args *ledgersArgs) ([]*LedgerResolver, error) {
 
 	allVersions := true
@@ -121,6 +121,28 @@ func (r *PropertyResolver) rollupLedgers() {
 
 				r.addRollup(record.UserID, &record, ledgerRollupType)
 
+			case *models.ReverseLedgerEntryInput:
+
+				rollups := r.getRollups(&rollupArgs{id: &ledgerEvent.UpdateForUserId}, ledgerRollupType)
+
+				// make a copy
+				record := *rollups[0].(*LedgerRollup)
+
+				record.Event = reversalLedgerEvent
+				if ledgerEvent.Increase {
+					record.Amount = ledgerEvent.Amount
+				} else {
+					record.Amount = -1 * ledgerEvent.Amount
+				}
+				record.Balance += record.Amount
+
+				record.VersionedEvent = &currentEvent
+
+				record.EventDateTime = ledgerEvent.CreateDateTime
+				record.EventVersion = ledgerEvent.EventVersion
+
+				r.addRollup(record.UserID, &record, ledgerRollupType)
+
 			case *models.UpdateMembershipStatusInput:
 				rollups := r.getRollups(&rollupArgs{id: &ledgerEvent.UpdateForUserId}, ledgerRollupType)
 
@@ -145,11 +167,23 @@ func (r *PropertyResolver) rollupLedgers() {
 				membershipAmount := restriction.internalAmount()
 
 				if ledgerEvent.Purchase {
+					if ledgerEvent.ChargedAmount != nil {
+						membershipAmount = *ledgerEvent.ChargedAmount
+					} else if ledgerEvent.Tier != nil {
+						membershipAmount = restriction.tier(*ledgerEvent.Tier).Amount
+					}
 					record.Event = purchaseMembershipLedgerEvent
 					record.Amount = -1 * membershipAmount
 					record.Balance -= membershipAmount
 				} else {
-					// ok, opt out, see if a purchase was made previously
+					// ok, opt out, see if a purchase was made previously and refund what was charged
+					if charged, ok := r.membershipCharge(ledgerEvent.RestrictionId, ledgerEvent.UpdateForUserId,
+						ledgerEvent.EventVersion-1); ok {
+						membershipAmount = charged
+					} else if lastTier := r.membershipTier(ledgerEvent.RestrictionId, ledgerEvent.UpdateForUserId,
+						ledgerEvent.EventVersion-1); lastTier != "" {
+						membershipAmount = restriction.tier(lastTier).Amount
+					}
 					record.Event = optoutMembershipLedgerEvent
 					if lastStatus == PURCHASED {
 						record.Amount = membershipAmount
@@ -3,7 +3,10 @@ package frapi
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
 )
 
 func TestLedgerResolvers(t *testing.T) {
@@ -133,3 +136,70 @@ func TestLedgerMembershipUpdate(t *testing.T) {
 	checkLedger(ctx, t, property, me.UserID(), 3, optoutMembershipLedgerEvent, balance, amount)
 
 }
+
+func TestReverseLedgerEntry(t *testing.T) {
+	property, ctx, resolver, me, _ := initAndCreateTestProperty(context.Background(), t)
+	userID := me.UserID()
+
+	reverse := func(paymentID string, description string) (*PropertyResolver, error) {
+		return resolver.ReverseLedgerEntry(ctx, &struct {
+			PropertyID string
+			Input      *models.ReverseLedgerEntryInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input: &models.ReverseLedgerEntryInput{
+				ForVersion:  property.EventVersion(),
+				PaymentId:   paymentID,
+				Description: description,
+			},
+		})
+	}
+
+	t.Log("a mistyped payment is reversed")
+	property = createPayment(ctx, t, resolver, property, 5000, true, property.EventVersion())
+	payment, _ := checkLedger(ctx, t, property, userID, 2, paymentLedgerEvent, 5000, 5000)
+	paymentID := *payment.PaymentID()
+
+	property, err := reverse(paymentID, "should be 50.00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversal, records := checkLedger(ctx, t, property, userID, 3, reversalLedgerEvent, 0, -5000)
+	if reversal.ReversedPaymentID() == nil || *reversal.ReversedPaymentID() != paymentID || *reversal.Description() != "should be 50.00" {
+		t.Fatal("expected the reversal to link to the payment")
+	}
+	if !records[1].Reversed() || reversal.Reversed() {
+		t.Fatal("expected the payment to be marked reversed")
+	}
+
+	t.Log("a payment cannot be reversed twice")
+	if _, err := reverse(paymentID, "again"); err == nil {
+		t.Fatal("expected an error reversing twice")
+	}
+
+	t.Log("an expense is reversed")
+	property = createPayment(ctx, t, resolver, property, 1200, false, property.EventVersion())
+	expense, _ := checkLedger(ctx, t, property, userID, 4, expenseLedgerEvent, -1200, -1200)
+	if _, err := reverse(*expense.PaymentID(), ""); err == nil {
+		t.Fatal("expected an error without a description")
+	}
+	if property, err = reverse(*expense.PaymentID(), "charged twice"); err != nil {
+		t.Fatal(err)
+	}
+	checkLedger(ctx, t, property, userID, 5, reversalLedgerEvent, 0, 1200)
+
+	t.Log("unknown payments cannot be reversed")
+	if _, err := reverse("unknown", "typo"); err == nil {
+		t.Fatal("expected an error for an unknown payment")
+	}
+
+	t.Log("the payments export shows the reversals")
+	attachment, err := resolver.exportPayments(ctx, property, me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := string(attachment.Data)
+	if !strings.Contains(csv, "test payment (reversed)") || !strings.Contains(csv, "REVERSAL,reversal of test payment: should be 50.00") {
+		t.Fatalf("expected the reversals in the export:\n%v", csv)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
 )
 
 const membershipStatusConstraintsGQL = `
@@ -21,11 +22,15 @@ enum MembershipState {
 type MembershipStatusConstraintsRecord {
 	status: MembershipState!
 	info: RestrictionRecord!
+	# the tier purchased, if the membership has tiers
+	tier: MembershipTier
 	reservationCount: Int!
 	# cannot opt out if reservations exist
 	optOutAllowed: Boolean!
 	# cannot purchase if over minimum balance
 	purchaseAllowed: Boolean!
+	# the amount charged for a purchase of the membership, or a tier of it, today
+	purchaseAmount(tier: String, format: AmountFormat = DECIMAL): String!
 }
 
 type MembershipStatusConstraints {
@@ -72,6 +77,8 @@ type UserMembershipRecord struct {
 	inDate           *frdate.Date
 	outDate          *frdate.Date
 	prePayStartDate  *frdate.Date
+	graceOutDate     *frdate.Date
+	tier             *models.MembershipTier
 	purchaseAllowed  *bool
 	optOutAllowed    *bool
 	reservationCount int
@@ -127,6 +134,14 @@ func (r *MembershipRecordResolver) Status() MembershipState {
 	return r.membership.status
 }
 
+// Tier is called by the GQL framework, see membershipStatusGQL
+func (r *MembershipRecordResolver) Tier() *MembershipTierResolver {
+	if r.membership.tier == nil {
+		return nil
+	}
+	return &MembershipTierResolver{r.membership.tier, r.property.moneyFormat()}
+}
+
 // ReservationCount is called by the GQL framework, see membershipStatusGQL
 func (r *MembershipRecordResolver) ReservationCount() int32 {
 	return int32(r.membership.reservationCount)
@@ -201,6 +216,26 @@ func (r *MembershipRecordResolver) PurchaseAllowed() bool {
 	return *r.membership.purchaseAllowed
 }
 
+// PurchaseAmount is called by the GQL framework, see membershipStatusGQL
+func (r *MembershipRecordResolver) PurchaseAmount(args *struct {
+	Tier   *string
+	Format amountFormat
+}) (string, error) {
+	membership, ok := r.Info().Restriction().ToMembershipRestriction()
+	if !ok {
+		return "", fmt.Errorf("restriction is not for a membership: %+v", r.membership.restrictionID)
+	}
+	amount := membership.internalAmount()
+	if args.Tier != nil {
+		tier := membership.tier(*args.Tier)
+		if tier == nil {
+			return "", fmt.Errorf("unknown tier %+v", *args.Tier)
+		}
+		amount = tier.Amount
+	}
+	return membership.money.formatAmount(membership.proratedAmount(amount, membership.dateBuilder.Today()), args.Format)
+}
+
 func (r *PropertyResolver) resolveMembershipStatus(args *membershipStatusConstraintsArgs) ([]*MembershipStatusConstraintsResolver, error) {
 
 	// get all the rollups
@@ -245,6 +280,18 @@ func (r *PropertyResolver) resolveMembershipStatus(args *membershipStatusConstra
 		}
 	}
 
+	// membership restrictions by id for the tiers
+	restrictions, err := r.Restrictions(&restrictionsArgs{MaxVersion: args.MaxVersion})
+	if err != nil {
+		return nil, err
+	}
+	memberships := make(map[string]*MembershipRestrictionResolver)
+	for _, restriction := range restrictions {
+		if membership, ok := restriction.Restriction().ToMembershipRestriction(); ok {
+			memberships[restriction.RestrictionID()] = membership
+		}
+	}
+
 	// get all the users
 	users := r.Users(&usersArgs{})
 
@@ -272,12 +319,18 @@ func (r *PropertyResolver) resolveMembershipStatus(args *membershipStatusConstra
 			record.inDate = dateBuilder.MustNewDate(rollup.InDate)
 			record.outDate = dateBuilder.MustNewDate(rollup.OutDate)
 			record.prePayStartDate = dateBuilder.MustNewDate(rollup.PrePayStartDate)
+			record.graceOutDate = dateBuilder.MustNewDate(rollup.GracePeriodOutDate)
 
 			if status, ok := rollup.Users[user.UserID()]; ok {
 				record.status = status
 			} else {
 				record.status = OPEN
 			}
+			if tierName, ok := rollup.Tiers[user.UserID()]; ok && record.status == PURCHASED {
+				if membership, ok := memberships[record.restrictionID]; ok {
+					record.tier = membership.tier(tierName)
+				}
+			}
 
 			// set the reservation count in the record
 			if count, ok := reservationCountMap[resolver.userID][record.restrictionID]; ok {
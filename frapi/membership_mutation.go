@@ -29,9 +29,11 @@ func (r *Resolver) UpdateMembershipStatus(ctx context.Context, args *struct {
 		return nil, err
 	}
 
-	// only allow admins if requested
-	if args.Input.AdminUpdate && !me.IsAdmin() {
-		return nil, fmt.Errorf("only an admin can make this call")
+	// only allow admins or treasurers if requested
+	if args.Input.AdminUpdate {
+		if err := authorize(me, manageMembershipsPermission); err != nil {
+			return nil, err
+		}
 	}
 
 	// comment required if admin request
@@ -56,10 +58,28 @@ func (r *Resolver) UpdateMembershipStatus(ctx context.Context, args *struct {
 		return nil, fmt.Errorf("restriction not found for id: %+v", args.Input.RestrictionId)
 	}
 
-	if _, ok := restrictions[0].Restriction().ToMembershipRestriction(); !ok {
+	membership, ok := restrictions[0].Restriction().ToMembershipRestriction()
+	if !ok {
 		return nil, fmt.Errorf("restriction is not for a membership: %+v", args.Input.RestrictionId)
 	}
 
+	// a tier is chosen when purchasing a membership with tiers
+	if args.Input.Tier != nil && !args.Input.Purchase {
+		return nil, fmt.Errorf("a tier is only chosen for a purchase")
+	}
+	if args.Input.Purchase {
+		hasTiers := len(membership.Tiers()) > 0
+		if hasTiers && args.Input.Tier == nil {
+			return nil, fmt.Errorf("a tier is required for membership %+v", restrictions[0].Description())
+		}
+		if !hasTiers && args.Input.Tier != nil {
+			return nil, fmt.Errorf("membership %+v has no tiers", restrictions[0].Description())
+		}
+		if args.Input.Tier != nil && membership.tier(*args.Input.Tier) == nil {
+			return nil, fmt.Errorf("unknown tier %+v for membership %+v", *args.Input.Tier, restrictions[0].Description())
+		}
+	}
+
 	// get the user
 	users := property.Users(&usersArgs{UserID: &args.Input.UpdateForUserId})
 
@@ -74,5 +94,18 @@ func (r *Resolver) UpdateMembershipStatus(ctx context.Context, args *struct {
 	// store the event!
 	args.Input.AuthorUserId = me.UserID()
 	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+
+	// persist the amount charged so that the ledger does not depend on when it is rolled up
+	args.Input.ChargedAmount = nil
+	if args.Input.Purchase {
+		amount := membership.internalAmount()
+		if args.Input.Tier != nil {
+			amount = membership.tier(*args.Input.Tier).Amount
+		}
+		purchaseDate := membership.dateBuilder.MustNewLocalDate(args.Input.CreateDateTime)
+		amount = membership.proratedAmount(amount, purchaseDate)
+		args.Input.ChargedAmount = &amount
+	}
+
 	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
 }
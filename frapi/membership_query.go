@@ -143,3 +143,34 @@ func (r *PropertyResolver) membershipInfo(restrictionID string, userID string, m
 
 	return OPEN, nil
 }
+
+// membershipTier returns the name of the tier purchased by the user, empty if none
+func (r *PropertyResolver) membershipTier(restrictionID string, userID string, maxVersion int32) string {
+
+	// rollup
+	r.rollupMembershipStatus()
+
+	versionedRollups := r.getRollups(&rollupArgs{maxVersion: &maxVersion, id: &restrictionID}, membershipStatusRollupType)
+
+	if len(versionedRollups) == 0 {
+		return ""
+	}
+
+	return versionedRollups[0].(*MembershipRollupRecord).Tiers[userID]
+}
+
+// membershipCharge returns the amount charged for the membership purchased by the user, false if not persisted
+func (r *PropertyResolver) membershipCharge(restrictionID string, userID string, maxVersion int32) (int32, bool) {
+
+	// rollup
+	r.rollupMembershipStatus()
+
+	versionedRollups := r.getRollups(&rollupArgs{maxVersion: &maxVersion, id: &restrictionID}, membershipStatusRollupType)
+
+	if len(versionedRollups) == 0 {
+		return 0, false
+	}
+
+	amount, ok := versionedRollups[0].(*MembershipRollupRecord).Charges[userID]
+	return amount, ok
+}
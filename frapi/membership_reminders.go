@@ -0,0 +1,124 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/templates"
+)
+
+// membershipNotice is the Membership template param group, the membership period a reminder is about
+type membershipNotice struct {
+	Description        string
+	PrePayStartDate    string
+	InDate             string
+	OutDate            string
+	GracePeriodOutDate string
+}
+
+// membershipNotice returns the Membership template param group for the membership restriction
+func (r *PropertyResolver) membershipNotice(restrictionID string, maxVersion *int32) (*membershipNotice, error) {
+	restrictions, err := r.Restrictions(&restrictionsArgs{RestrictionID: &restrictionID, MaxVersion: maxVersion})
+	if err != nil {
+		return nil, err
+	}
+	if len(restrictions) != 1 {
+		return nil, errors.New("expected a membership")
+	}
+	membership, ok := restrictions[0].Restriction().ToMembershipRestriction()
+	if !ok {
+		return nil, errors.New("expected a membership")
+	}
+
+	return &membershipNotice{
+		Description:        restrictions[0].Description(),
+		PrePayStartDate:    membership.internalPrePayStartDate(),
+		InDate:             membership.inDateInternal(),
+		OutDate:            membership.internalOutDate(),
+		GracePeriodOutDate: membership.internalGracePeriodOutDate(),
+	}, nil
+}
+
+// membershipReminder returns the reminder due today for an open membership and the date its window opened,
+// or "" if no reminder is due, the grace period warning wins over the renewal reminder which wins over the
+// prepay notice
+func membershipReminder(membership *UserMembershipRecord, today *frdate.Date, reminderDays int) (templates.TemplateName, *frdate.Date) {
+	if membership.status != OPEN || !today.Before(membership.graceOutDate) {
+		return "", nil
+	}
+
+	graceWarning := membership.graceOutDate.AddDays(-reminderDays)
+	if !today.Before(membership.inDate) {
+		if !today.Before(graceWarning) {
+			return templates.MembershipGraceNotification, graceWarning
+		}
+		return "", nil
+	}
+
+	renewalReminder := membership.inDate.AddDays(-reminderDays)
+	if !today.Before(renewalReminder) {
+		return templates.MembershipRenewalNotification, renewalReminder
+	}
+
+	if !today.Before(membership.prePayStartDate) {
+		return templates.MembershipPrePayNotification, membership.prePayStartDate
+	}
+
+	return "", nil
+}
+
+// sendMembershipReminders notifies members who have not purchased or opted out of a membership when its prepay
+// window opens, before it starts and before its grace period ends, called by the daily cron, each reminder is
+// sent once per membership window
+func sendMembershipReminders(ctx context.Context, property *PropertyResolver, today *frdate.DateTime, settings *SettingsResolver,
+	lastNotifications map[templates.TemplateName]map[string]*frdate.DateTime) *PropertyResolver {
+
+	statuses, err := property.MembershipStatusConstraints(&membershipStatusConstraintsArgs{})
+	if err != nil {
+		Logger.LogErrorf("DailyCron error accessing memberships: %+v", err)
+		return property
+	}
+
+	for _, status := range statuses {
+		user := status.User()
+		if !user.IsMember() || user.State() != models.ACCEPTED {
+			continue
+		}
+		userID := user.UserID()
+
+		for _, membership := range status.allMemberShips {
+			templateName, windowStart := membershipReminder(membership, today.ToDate(), int(settings.MembershipReminderDaysBefore()))
+			if templateName == "" {
+				continue
+			}
+
+			// already reminded since the window opened
+			if dateTime, ok := lastNotifications[templateName][userID]; ok && !dateTime.ToDate().Before(windowStart) {
+				continue
+			}
+
+			Logger.LogDebugf("DailyCron commit %v for userId: %+v", templateName, userID)
+			paramGroup := templates.Membership
+			newNotificationInput := createNotificationRecord(notificationTargetUser, property, templateName,
+				&userID, &paramGroup, &membership.restrictionID)
+			newNotificationInput.TemplateParamData[templates.Ledger] = userID
+
+			updated, err := commitCronChanges(ctx, property.PropertyID(), newNotificationInput)
+			if err != nil {
+				Logger.LogErrorf("DailyCron error commit membership reminder: %+v", err)
+				continue
+			}
+			property = deliverNotification(ctx, updated, newNotificationInput.NotificationId)
+
+			// one reminder of a type per day, even with overlapping memberships
+			if _, ok := lastNotifications[templateName]; !ok {
+				lastNotifications[templateName] = make(map[string]*frdate.DateTime)
+			}
+			lastNotifications[templateName][userID] = today
+		}
+	}
+
+	return property
+}
@@ -30,6 +30,10 @@ type MembershipRollupRecord struct {
 	EventVersion       int32
 	Description        string
 	Amount             int32
+	// Tiers are the tier names purchased by user id, only for memberships with tiers
+	Tiers map[string]string
+	// Charges are the amounts charged by user id, only for purchases that persisted the amount
+	Charges map[string]int32
 }
 
 // GetEventVersion returns the version of the rollup record
@@ -55,6 +59,8 @@ func (r *PropertyResolver) rollupMembershipStatus() {
 					rollup := &MembershipRollupRecord{
 						RestrictionID:      rollupEvent.RestrictionId,
 						Users:              make(map[string]MembershipState),
+						Tiers:              make(map[string]string),
+						Charges:            make(map[string]int32),
 						EventVersion:       rollupEvent.EventVersion,
 						InDate:             rollupEvent.Membership.InDate,
 						OutDate:            rollupEvent.Membership.OutDate,
@@ -86,8 +92,24 @@ func (r *PropertyResolver) rollupMembershipStatus() {
 					for user, status := range last.Users {
 						next.Users[user] = status
 					}
+					next.Tiers = make(map[string]string)
+					for user, tier := range last.Tiers {
+						next.Tiers[user] = tier
+					}
+					delete(next.Tiers, rollupEvent.UpdateForUserId)
+					next.Charges = make(map[string]int32)
+					for user, amount := range last.Charges {
+						next.Charges[user] = amount
+					}
+					delete(next.Charges, rollupEvent.UpdateForUserId)
 					if rollupEvent.Purchase {
 						next.Users[rollupEvent.UpdateForUserId] = PURCHASED
+						if rollupEvent.Tier != nil {
+							next.Tiers[rollupEvent.UpdateForUserId] = *rollupEvent.Tier
+						}
+						if rollupEvent.ChargedAmount != nil {
+							next.Charges[rollupEvent.UpdateForUserId] = *rollupEvent.ChargedAmount
+						}
 					} else {
 						next.Users[rollupEvent.UpdateForUserId] = OPTOUT
 					}
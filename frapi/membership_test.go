@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
 )
 
 func TestMembershipStatusBasic(t *testing.T) {
@@ -161,3 +162,232 @@ func expectedStatus(t *testing.T, membership *MembershipRecordResolver, status M
 		t.Fatalf("membership reservation count expected: %v actual: %v", reservationCount, membership.ReservationCount())
 	}
 }
+
+func createTieredMembership(ctx context.Context, t *testing.T, resolver *Resolver, property *PropertyResolver, today *frdate.Date) (*PropertyResolver, string) {
+	first, last := today.YearInOut()
+	weekdayRate := int32(1500)
+	maxNights := int32(3)
+	weekdayNights := []models.Weekday{models.MONDAY, models.TUESDAY, models.WEDNESDAY, models.THURSDAY}
+	tiers := []models.MembershipTier{
+		{Name: "full", Amount: 30000},
+		{Name: " weekday ", Amount: 10000, NightlyRate: &weekdayRate, MaxNights: &maxNights, Nights: &weekdayNights},
+	}
+
+	input := &models.NewRestrictionInput{
+		ForVersion:  property.EventVersion(),
+		Description: "tiered",
+		Membership: &models.MembershipRestriction{
+			Amount:             30000,
+			PrePayStartDate:    first.AddDays(-30).ToString(),
+			InDate:             first.ToString(),
+			OutDate:            last.ToString(),
+			GracePeriodOutDate: last.AddDays(300).ToString(),
+			Tiers:              &tiers,
+		},
+	}
+	property, err := resolver.CreateRestriction(ctx, &struct {
+		PropertyID string
+		Input      *models.NewRestrictionInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      input,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return property, input.RestrictionId
+}
+
+func updateMembershipTier(ctx context.Context, resolver *Resolver, property *PropertyResolver, restrictionID string, userID string, purchase bool, tier *string) (*PropertyResolver, error) {
+	return resolver.UpdateMembershipStatus(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateMembershipStatusInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.UpdateMembershipStatusInput{
+			ForVersion:      property.EventVersion(),
+			UpdateForUserId: userID,
+			RestrictionId:   restrictionID,
+			Purchase:        purchase,
+			Tier:            tier,
+		},
+	})
+}
+
+func TestMembershipTiers(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	userID := me.UserID()
+
+	t.Log("tier names must be unique")
+	duplicate := []models.MembershipTier{{Name: "full", Amount: 1}, {Name: "full ", Amount: 2}}
+	first, last := today.YearInOut()
+	_, err := resolver.CreateRestriction(ctx, &struct {
+		PropertyID string
+		Input      *models.NewRestrictionInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewRestrictionInput{
+			ForVersion:  property.EventVersion(),
+			Description: "duplicate",
+			Membership: &models.MembershipRestriction{
+				PrePayStartDate:    first.ToString(),
+				InDate:             first.ToString(),
+				OutDate:            last.ToString(),
+				GracePeriodOutDate: last.ToString(),
+				Tiers:              &duplicate,
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected duplicate tier names to fail")
+	}
+
+	property, restrictionID := createTieredMembership(ctx, t, resolver, property, today)
+	balance := func() int32 {
+		ledgers, _ := property.Ledgers(&ledgersArgs{UserID: &userID})
+		records := ledgers[0].Records()
+		return records[len(records)-1].balanceInternal().Raw()
+	}
+	startBalance := balance()
+
+	t.Log("a tier must be chosen")
+	if _, err := updateMembershipTier(ctx, resolver, property, restrictionID, userID, true, nil); err == nil {
+		t.Fatal("expected a purchase without a tier to fail")
+	}
+	unknown := "junior"
+	if _, err := updateMembershipTier(ctx, resolver, property, restrictionID, userID, true, &unknown); err == nil {
+		t.Fatal("expected an unknown tier to fail")
+	}
+
+	t.Log("the tier price is charged and refunded")
+	weekday := "weekday"
+	if property, err = updateMembershipTier(ctx, resolver, property, restrictionID, userID, true, &weekday); err != nil {
+		t.Fatal(err)
+	}
+	if balance() != startBalance-10000 {
+		t.Fatalf("expected the weekday price to be charged but the balance is %v", balance())
+	}
+	if property, err = updateMembershipTier(ctx, resolver, property, restrictionID, userID, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if balance() != startBalance {
+		t.Fatalf("expected the weekday price to be refunded but the balance is %v", balance())
+	}
+	if property, err = updateMembershipTier(ctx, resolver, property, restrictionID, userID, true, &weekday); err != nil {
+		t.Fatal(err)
+	}
+
+	usersStatus, _ := property.MembershipStatusConstraints(&membershipStatusConstraintsArgs{UserID: &userID})
+	tier := usersStatus[0].Memberships()[0].Tier()
+	if tier == nil || tier.Name() != weekday {
+		t.Fatalf("expected the weekday tier but got %+v", tier)
+	}
+
+	// the next monday after tomorrow
+	monday := today.AddDays(2)
+	for monday.Format("Monday") != "Monday" {
+		monday = monday.AddDays(1)
+	}
+
+	t.Log("the tier rate applies to weekday nights")
+	property, reservations := createReservation(ctx, t, resolver, property, userID, monday.ToString(), monday.AddDays(2).ToString())
+	for _, rate := range reservations[0].Rate() {
+		if rate.Amount() != 1500 {
+			t.Fatalf("expected the tier rate but got %v", rate.Amount())
+		}
+	}
+
+	createStay := func(in *frdate.Date, out *frdate.Date) error {
+		_, err := resolver.CreateReservation(ctx, &struct {
+			PropertyID string
+			Input      *models.NewReservationInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input: &models.NewReservationInput{
+				ForVersion:        property.EventVersion(),
+				ReservedForUserId: userID,
+				StartDate:         in.ToString(),
+				EndDate:           out.ToString(),
+				Member:            true,
+			},
+		})
+		return err
+	}
+
+	t.Log("weekend nights are not allowed")
+	if err := createStay(monday.AddDays(4), monday.AddDays(5)); err == nil {
+		t.Fatal("expected a friday night to fail")
+	}
+
+	t.Log("max nights are enforced")
+	if err := createStay(monday.AddDays(7), monday.AddDays(11)); err == nil {
+		t.Fatal("expected 4 nights to fail")
+	}
+}
+
+func TestMembershipProration(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	userID := me.UserID()
+
+	t.Log("a daily pro-rated membership that started 30 nights ago")
+	input := &models.NewRestrictionInput{
+		ForVersion:  property.EventVersion(),
+		Description: "prorated",
+		Membership: &models.MembershipRestriction{
+			Amount:             30000,
+			PrePayStartDate:    today.AddDays(-60).ToString(),
+			InDate:             today.AddDays(-30).ToString(),
+			OutDate:            today.AddDays(70).ToString(),
+			GracePeriodOutDate: today.AddDays(70).ToString(),
+			Proration:          &models.MembershipProration{Period: models.DAILY, MinimumAmount: 5000},
+		},
+	}
+	property, err := resolver.CreateRestriction(ctx, &struct {
+		PropertyID string
+		Input      *models.NewRestrictionInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      input,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usersStatus, _ := property.MembershipStatusConstraints(&membershipStatusConstraintsArgs{UserID: &userID})
+	amount, err := usersStatus[0].Memberships()[0].PurchaseAmount(&struct {
+		Tier   *string
+		Format amountFormat
+	}{Format: decimal})
+	if err != nil || amount != "210.00" {
+		t.Fatalf("expected a purchase amount of 210.00 but got %v %+v", amount, err)
+	}
+
+	t.Log("the pro-rated amount is charged and refunded")
+	property = updateMembership(ctx, t, resolver, property, input.RestrictionId, userID, true, "purchase")
+	checkLedger(ctx, t, property, userID, 2, purchaseMembershipLedgerEvent, -21000, -21000)
+	property = updateMembership(ctx, t, resolver, property, input.RestrictionId, userID, false, "opt out")
+	checkLedger(ctx, t, property, userID, 3, optoutMembershipLedgerEvent, 0, 21000)
+
+	t.Log("monthly proration with a minimum amount")
+	dateBuilder := frdate.MustNewDateBuilder("America/Los_Angeles")
+	membership := &MembershipRestrictionResolver{
+		restriction: &models.MembershipRestriction{
+			InDate:    "2026-01-01",
+			OutDate:   "2027-01-01",
+			Proration: &models.MembershipProration{Period: models.MONTHLY, MinimumAmount: 5000},
+		},
+		dateBuilder: dateBuilder,
+	}
+	expected := map[string]int32{
+		"2025-12-15": 24000,
+		"2026-01-01": 24000,
+		"2026-10-19": 6000,
+		"2026-12-31": 5000,
+		"2027-02-01": 5000,
+	}
+	for date, amount := range expected {
+		if prorated := membership.proratedAmount(24000, dateBuilder.MustNewDate(date)); prorated != amount {
+			t.Fatalf("expected %v on %v but got %v", amount, date, prorated)
+		}
+	}
+}
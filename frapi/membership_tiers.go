@@ -0,0 +1,118 @@
+package frapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+const membershipTierPeriodGQL = `
+# The tier rules apply to the nights from inDate up to gracePeriodOutDate
+type MembershipTierPeriod {
+	inDate: String!
+	gracePeriodOutDate: String!
+	tier: MembershipTier!
+}
+`
+
+// tierPeriod is a purchased membership with a tier
+type tierPeriod struct {
+	inDate       *frdate.Date
+	graceOutDate *frdate.Date
+	tier         *models.MembershipTier
+	money        *moneyFormat
+}
+
+// purchasedTierPeriods returns the memberships with a tier purchased by the user
+func (r *PropertyResolver) purchasedTierPeriods(userID string) ([]*tierPeriod, error) {
+	membershipStatusList, err := r.MembershipStatusConstraints(&membershipStatusConstraintsArgs{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	periods := []*tierPeriod{}
+	if len(membershipStatusList) == 0 {
+		return periods, nil
+	}
+	money := r.moneyFormat()
+	for _, membership := range membershipStatusList[0].allMemberShips {
+		if membership.status == PURCHASED && membership.tier != nil {
+			periods = append(periods, &tierPeriod{
+				inDate:       membership.inDate,
+				graceOutDate: membership.graceOutDate,
+				tier:         membership.tier,
+				money:        money,
+			})
+		}
+	}
+	return periods, nil
+}
+
+// tierForNight returns the tier for the night starting on the day, nil if no tier applies
+func tierForNight(periods []*tierPeriod, day *frdate.Date) *models.MembershipTier {
+	for _, period := range periods {
+		if !day.Before(period.inDate) && day.Before(period.graceOutDate) {
+			return period.tier
+		}
+	}
+	return nil
+}
+
+// tierRulesBroken returns why the stay is not allowed by the tiers, nil if it is allowed,
+// the booking window and max nights are those of the tier at check in
+func tierRulesBroken(periods []*tierPeriod, checkIn *frdate.Date, checkOut *frdate.Date, today *frdate.Date) error {
+	nights, err := frdate.DaysList(checkIn, checkOut, false)
+	if err != nil {
+		return err
+	}
+
+	if tier := tierForNight(periods, checkIn); tier != nil {
+		if tier.MaxNights != nil && len(nights) > int(*tier.MaxNights) {
+			return fmt.Errorf("membership %v allows at most %v nights", tier.Name, *tier.MaxNights)
+		}
+		if tier.BookingWindowDays != nil && checkOut.After(today.AddDays(int(*tier.BookingWindowDays))) {
+			return fmt.Errorf("membership %v allows booking at most %v days ahead", tier.Name, *tier.BookingWindowDays)
+		}
+	}
+
+	for _, night := range nights {
+		tier := tierForNight(periods, night)
+		if tier == nil || tier.Nights == nil {
+			continue
+		}
+		weekday := models.Weekday(strings.ToUpper(night.Format("Monday")))
+		allowed := false
+		for _, tierNight := range *tier.Nights {
+			if tierNight == weekday {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("membership %v does not allow %v nights", tier.Name, strings.ToLower(string(weekday)))
+		}
+	}
+	return nil
+}
+
+// MembershipTierPeriodResolver resolves a purchased membership tier
+type MembershipTierPeriodResolver struct {
+	period *tierPeriod
+}
+
+// InDate is called by the GQL framework, see membershipTierPeriodGQL
+func (r *MembershipTierPeriodResolver) InDate() string {
+	return r.period.inDate.ToString()
+}
+
+// GracePeriodOutDate is called by the GQL framework, see membershipTierPeriodGQL
+func (r *MembershipTierPeriodResolver) GracePeriodOutDate() string {
+	return r.period.graceOutDate.ToString()
+}
+
+// Tier is called by the GQL framework, see membershipTierPeriodGQL
+func (r *MembershipTierPeriodResolver) Tier() *MembershipTierResolver {
+	return &MembershipTierResolver{r.period.tier, r.period.money}
+}
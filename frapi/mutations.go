@@ -21,6 +21,20 @@ type DuplicateDetectionEvent interface {
 	GetForVersion() int32
 }
 
+// DuplicateKeyEvent is implemented by client requests that are not persisted as events of their own type,
+// or whose event type is shared with other requests, a duplicate has the same key instead of the same type
+type DuplicateKeyEvent interface {
+	GetDuplicateKey() string
+}
+
+// duplicateKey returns the key of an event or request for duplicate detection
+func duplicateKey(event interface{}) string {
+	if keyEvent, ok := event.(DuplicateKeyEvent); ok {
+		return keyEvent.GetDuplicateKey()
+	}
+	return reflect.TypeOf(event).String()
+}
+
 func emailMapFromGob(gobData []byte) (map[string]string, error) {
 	dec := gob.NewDecoder(bytes.NewBuffer(gobData))
 	emailMap := make(map[string]string)
@@ -178,15 +192,16 @@ func isDuplicate(ctx context.Context, request DuplicateDetectionEvent, property
 	}
 
 	// iterate through all the events for a duplicate (from the last one to first)
+	requestKey := duplicateKey(request)
 	for i := len(property.property.Events) - 1; i > 0; i-- {
 		event := property.property.Events[i]
 
-		// a duplicate will have the same type (ex. update settings event)
-		if reflect.TypeOf(event) == reflect.TypeOf(request) {
+		// a duplicate will have the same type (ex. update settings event) or duplicate key
+		if duplicateKey(event) == requestKey {
 
 			// a client event that supports duplicate suppression will implment GetForVersion
 			// (i.e. internal events like notification event may not)
-			// this should always be true because of the type check above...
+			// this should always be true because of the type or key check above...
 			if duplicateDetectionEvent, ok := event.(DuplicateDetectionEvent); ok {
 
 				// if the forVersion is the same, then this request is a duplicate
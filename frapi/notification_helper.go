@@ -2,6 +2,7 @@ package frapi
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/bjorge/friendlyreservations/frdate"
 	"github.com/bjorge/friendlyreservations/models"
@@ -16,6 +17,7 @@ const (
 	notificationTargetAdmins     notificationTargetType = "NOTIFICATION_TARGET_ADMINS"
 	notificationTargetMember     notificationTargetType = "NOTIFICATION_TARGET_MEMBER"
 	notificationTargetAllMembers notificationTargetType = "NOTIFICATION_TARGET_ALL_MEMBERS"
+	notificationTargetUser       notificationTargetType = "NOTIFICATION_TARGET_USER"
 )
 
 func sendEmail(ctx context.Context, property *PropertyResolver, notification *NotificationResolver) error {
@@ -35,6 +37,11 @@ func sendEmail(ctx context.Context, property *PropertyResolver, notification *No
 		Logger.LogErrorf("sendEmail: Error resolving notification body: %+v", err)
 		return err
 	}
+	htmlBody, err := notification.HTMLBody()
+	if err != nil {
+		Logger.LogErrorf("sendEmail: Error resolving notification html body: %+v", err)
+		return err
+	}
 
 	msg := &platform.EmailMessage{
 		Sender:  *notification.authorEmailFormat(),
@@ -43,10 +50,110 @@ func sendEmail(ctx context.Context, property *PropertyResolver, notification *No
 		Subject: subject,
 		Body:    body,
 	}
+	if htmlBody != nil {
+		msg.HTMLBody = *htmlBody
+	}
+	if replyTo := replyAddress(property.PropertyID(), notification.NotificationID(), notification.rollup.Input.ReplyToken); replyTo != "" {
+		msg.ReplyTo = replyTo
+	}
+
+	// nothing to send, example all the recipients have been erased
+	if len(msg.To) == 0 && len(msg.Cc) == 0 {
+		Logger.LogWarningf("sendEmail: notification has no email recipients")
+		return nil
+	}
 
 	return EmailSender.Send(ctx, msg)
 }
 
+// urgentCancelDays is how many days ahead a cancelled stay starts, at most, for its cancellation to be urgent
+const urgentCancelDays = 1
+
+// sendUrgentNotifications sends a short message for an urgent notification, example a stay starting tomorrow
+// was cancelled, to the notified users on every channel they have a contact for, errors are only logged
+func sendUrgentNotifications(ctx context.Context, property *PropertyResolver, notificationID string) {
+	if len(Notifiers) == 0 {
+		return
+	}
+
+	notifications, err := property.Notifications(&notificationArgs{notificationID: &notificationID})
+	if err != nil || len(notifications) != 1 {
+		Logger.LogErrorf("sendUrgentNotifications: notification %v not found", notificationID)
+		return
+	}
+	notification := notifications[0]
+	if notification.rollup.Input.TemplateName != templates.CancelReservationNotification {
+		return
+	}
+
+	reservationID := notification.rollup.Input.TemplateParamData[templates.Reservation]
+	reservations, err := property.Reservations(&reservationsArgs{ReservationID: &reservationID})
+	if err != nil || len(reservations) != 1 {
+		Logger.LogErrorf("sendUrgentNotifications: reservation %v not found", reservationID)
+		return
+	}
+	reservation := reservations[0]
+
+	settings, err := property.Settings(&settingsArgs{})
+	if err != nil {
+		Logger.LogErrorf("sendUrgentNotifications: error reading settings: %+v", err)
+		return
+	}
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+	if dateBuilder.MustNewDate(reservation.StartDate()).After(dateBuilder.Today().AddDays(urgentCancelDays)) {
+		return
+	}
+
+	subject, err := notification.Subject()
+	if err != nil {
+		Logger.LogErrorf("sendUrgentNotifications: error resolving notification subject: %+v", err)
+		return
+	}
+	message := &platform.Notification{
+		Title: subject,
+		Body: fmt.Sprintf("%v, check in %v, check out %v", reservationNickname(reservation),
+			reservation.StartDate(), reservation.EndDate()),
+		URL: notificationLinks()["Reservation"],
+	}
+
+	notified := make(map[string]bool)
+	for _, userID := range notification.rollup.Input.AllNotifiedUserIds {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		users := property.Users(&usersArgs{UserID: &userID})
+		if len(users) != 1 || users[0].isErased() || users[0].State() != models.ACCEPTED {
+			continue
+		}
+
+		contacts, err := PersistedEmailStore.GetContacts(ctx, property.PropertyID(), users[0].emailID())
+		if err != nil {
+			Logger.LogErrorf("sendUrgentNotifications: error reading contacts: %+v", err)
+			continue
+		}
+		for channel, contact := range contacts {
+			notifier := channelNotifier(channel)
+			if notifier == nil {
+				continue
+			}
+			userMessage := *message
+			userMessage.To = contact
+			err := notifier.Notify(ctx, &userMessage)
+			if err == platform.ErrSubscriptionExpired {
+				// the browser unsubscribed, stop sending to it
+				Logger.LogInfof("sendUrgentNotifications: removing expired %v contact", channel)
+				if err := PersistedEmailStore.SetContact(ctx, property.PropertyID(), users[0].emailID(), channel, ""); err != nil {
+					Logger.LogErrorf("sendUrgentNotifications: error removing contact: %+v", err)
+				}
+			} else if err != nil {
+				Logger.LogWarningf("sendUrgentNotifications: error sending %v notification: %+v", channel, err)
+			}
+		}
+	}
+}
+
 func createNotificationRecord(notificationTarget notificationTargetType, property *PropertyResolver, templateName templates.TemplateName,
 	singleTargetUserID *string, paramGroup *templates.TemplateParamGroup, paramData *string) *models.NewNotificationInput {
 
@@ -58,11 +165,29 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 	} else {
 		newNotification.EmailSent = true
 	}
+	newNotification.DeliveryTracked = newNotification.EmailSent
+	newNotification.ReplyToken = newReplyToken()
 
 	// setup the to and cc lists
 	newNotification.CcUserIds = []string{}
 	newNotification.ToUserIds = []string{}
 	newNotification.AllNotifiedUserIds = []string{}
+	newNotification.DigestUserIds = []string{}
+
+	// add an accepted user to the To or Cc list if the user wants the email now,
+	// otherwise the user is only notified
+	addRecipient := func(user *UserResolver, recipients *[]string) {
+		switch user.notificationPreference(templateName) {
+		case models.EMAIL_IMMEDIATELY:
+			*recipients = append(*recipients, user.UserID())
+		case models.EMAIL_DIGEST:
+			newNotification.DigestUserIds = append(newNotification.DigestUserIds, user.UserID())
+			newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
+		default:
+			newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
+		}
+	}
+
 	for _, user := range users {
 		if user.IsSystem() {
 			newNotification.AuthorUserId = user.UserID()
@@ -73,7 +198,7 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 			if user.IsAdmin() {
 				if user.State() == models.ACCEPTED {
 					// notify and send email
-					newNotification.ToUserIds = append(newNotification.ToUserIds, user.UserID())
+					addRecipient(user, &newNotification.ToUserIds)
 				} else if user.State() == models.WAITING_ACCEPT {
 					// notify only
 					newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
@@ -85,7 +210,7 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 				// only user in the To list
 				if user.State() == models.ACCEPTED {
 					// notify and send email
-					newNotification.ToUserIds = append(newNotification.ToUserIds, user.UserID())
+					addRecipient(user, &newNotification.ToUserIds)
 				} else if user.State() == models.WAITING_ACCEPT {
 					// notify only
 					newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
@@ -94,19 +219,25 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 				// all admins into the Cc list
 				if user.State() == models.ACCEPTED {
 					// notify and send email
-					newNotification.CcUserIds = append(newNotification.CcUserIds, user.UserID())
+					addRecipient(user, &newNotification.CcUserIds)
 				} else if user.State() == models.WAITING_ACCEPT {
 					// notify only
 					newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
 				}
 			}
 
+		case notificationTargetUser:
+			// only the user, admins are not copied (ex. a digest)
+			if user.UserID() == *singleTargetUserID && user.State() == models.ACCEPTED {
+				addRecipient(user, &newNotification.ToUserIds)
+			}
+
 		case notificationTargetAllMembers:
 			if user.IsAdmin() && !user.IsMember() {
 				// admin and not a member, so into the Cc list
 				if user.State() == models.ACCEPTED {
 					// notify and send email
-					newNotification.CcUserIds = append(newNotification.CcUserIds, user.UserID())
+					addRecipient(user, &newNotification.CcUserIds)
 				} else if user.State() == models.WAITING_ACCEPT {
 					// notify only
 					newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
@@ -115,7 +246,7 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 				// all members and admin members into To list
 				if user.State() == models.ACCEPTED {
 					// notify and send email
-					newNotification.ToUserIds = append(newNotification.ToUserIds, user.UserID())
+					addRecipient(user, &newNotification.ToUserIds)
 				} else if user.State() == models.WAITING_ACCEPT {
 					// notify only
 					newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, user.UserID())
@@ -129,6 +260,12 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 		newNotification.CcUserIds = []string{}
 	}
 
+	// nobody wants the email now
+	if len(newNotification.ToUserIds) == 0 {
+		newNotification.EmailSent = false
+		newNotification.DeliveryTracked = false
+	}
+
 	// add other To and Cc users into the all list
 	for _, userID := range newNotification.ToUserIds {
 		newNotification.AllNotifiedUserIds = append(newNotification.AllNotifiedUserIds, userID)
@@ -145,7 +282,7 @@ func createNotificationRecord(notificationTarget notificationTargetType, propert
 	newNotification.CreateDateTime = frdate.CreateDateTimeUTC()
 	newNotification.NotificationId = utilities.NewGUID()
 	newNotification.TemplateVersion = int32(templates.CurrentTemplateVersion)
-	newNotification.DefaultTemplate = true
+	newNotification.DefaultTemplate = property.contentOverride(models.ContentName(templateName), nil) == nil
 
 	return newNotification
 }
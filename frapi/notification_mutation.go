@@ -2,6 +2,8 @@ package frapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/bjorge/friendlyreservations/frdate"
 	"github.com/bjorge/friendlyreservations/models"
@@ -23,7 +25,6 @@ func (r *Resolver) NotificationRead(ctx context.Context, args *struct {
 
 	notificationReadInput := &models.NotificationReadInput{}
 
-	// TODO: validate that the notification exists...
 	notificationReadInput.NotificationId = args.NotificationID
 	notificationReadInput.ForVersion = args.ForVersion
 
@@ -35,6 +36,27 @@ func (r *Resolver) NotificationRead(ctx context.Context, args *struct {
 		return nil, err
 	}
 
+	// validate that the notification exists
+	notifications, err := property.Notifications(&notificationArgs{notificationID: &args.NotificationID})
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) != 1 {
+		return nil, errors.New("notification does not exist")
+	}
+	notification := notifications[0]
+
+	// only the users the notification was sent to can read it, unless allowed to view all notifications
+	if _, ok := notification.rollup.TargetUserIdsMap[me.UserID()]; !ok {
+		if err := authorize(me, viewAllNotificationsPermission); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := notification.rollup.ReaderUserIdsMap[me.UserID()]; ok {
+		return nil, errors.New("notification has already been read")
+	}
+
 	// input looks good, now add extra internal values
 	notificationReadInput.CreateDateTime = frdate.CreateDateTimeUTC()
 	notificationReadInput.AuthorUserId = me.UserID()
@@ -42,3 +64,91 @@ func (r *Resolver) NotificationRead(ctx context.Context, args *struct {
 	// persist the event
 	return commitChanges(ctx, args.PropertyID, property.EventVersion(), notificationReadInput)
 }
+
+// MarkAllNotificationsRead marks all the notifications sent to me as having been read
+func (r *Resolver) MarkAllNotificationsRead(ctx context.Context, args *struct {
+	PropertyID string
+	ForVersion int32
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Mark all notifications as read")
+
+	property, me, err := currentProperty(ctx, args.PropertyID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	allReadInput := &models.AllNotificationsReadInput{}
+	allReadInput.ForVersion = args.ForVersion
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, allReadInput, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+
+	// nothing to persist if everything is already read
+	userID := me.UserID()
+	count, err := property.UnreadNotificationCount(&unreadNotificationCountArgs{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return property, nil
+	}
+
+	// input looks good, now add extra internal values
+	allReadInput.CreateDateTime = frdate.CreateDateTimeUTC()
+	allReadInput.AuthorUserId = me.UserID()
+
+	// persist the event
+	return commitChanges(ctx, args.PropertyID, property.EventVersion(), allReadInput)
+}
+
+// UpdateNotificationPreference sets how I receive a type of notification
+func (r *Resolver) UpdateNotificationPreference(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.UpdateNotificationPreferenceInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Update notification preference")
+
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+
+	if !isPreferenceTemplateName(args.Input.TemplateName) {
+		return nil, fmt.Errorf("no preference can be set for notification %v", args.Input.TemplateName)
+	}
+
+	switch args.Input.Preference {
+	case models.EMAIL_IMMEDIATELY, models.EMAIL_DIGEST, models.IN_APP_ONLY:
+	default:
+		return nil, fmt.Errorf("unknown notification preference %v", args.Input.Preference)
+	}
+
+	if property.mandatoryNotification(args.Input.TemplateName) && args.Input.Preference != models.EMAIL_IMMEDIATELY {
+		return nil, fmt.Errorf("notification %v is always emailed", args.Input.TemplateName)
+	}
+
+	if me.notificationPreference(args.Input.TemplateName) == args.Input.Preference {
+		return nil, errors.New("notification preference is unchanged")
+	}
+
+	// input looks good, now add extra internal values
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserId = me.UserID()
+
+	// persist the event
+	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
+}
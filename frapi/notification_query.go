@@ -3,21 +3,36 @@ package frapi
 import (
 	"bytes"
 	"errors"
-	"fmt"
 	"html/template"
+	"net/mail"
 	"sort"
 
 	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
 	"github.com/bjorge/friendlyreservations/templates"
 )
 
 const notificationGQL = `
+enum DeliveryStatus {
+	PENDING
+	DELIVERED
+	FAILED
+	NOT_SENT
+}
+
 type Notification {
+	# true if the email has been delivered to the mail server
 	emailSent: Boolean!
+	deliveryStatus: DeliveryStatus!
+	deliveryAttempts: Int!
+	lastDeliveryError: String
+	lastAttemptDateTime: String
 	to: [User]!
 	cc: [User]!
 	subject: String!
 	body: String!
+	# html version of the body, null if the notification is text only
+	htmlBody: String
 	createDateTime: String!
 	author: User!
 	notificationId: String!
@@ -25,6 +40,21 @@ type Notification {
 	templateVersion: Int!
 	defaultTemplate: Boolean!
 	read: Boolean!
+	# replies to the notification email, only visible to admins
+	comments: [NotificationComment]!
+}
+
+type NotificationComment {
+	author: User!
+	comment: String!
+	createDateTime: String!
+}
+
+type NotificationPreferenceSetting {
+	templateName: NotificationTemplateName!
+	preference: NotificationPreference!
+	# mandatory notifications are always emailed immediately, see the mandatoryNotifications setting
+	mandatory: Boolean!
 }
 `
 
@@ -44,8 +74,9 @@ func (r *PropertyResolver) lastNotifications(dateBuilder *frdate.DateBuilder) ma
 	// get the rollups
 	ifaces := r.getRollups(&rollupArgs{}, notificationRollupType)
 
+	// sort by when the notifications were created, reads add newer rollup versions
 	sort.Slice(ifaces, func(i, j int) bool {
-		return ifaces[i].GetEventVersion() < ifaces[j].GetEventVersion()
+		return ifaces[i].(*NotificationRollup).Input.EventVersion < ifaces[j].(*NotificationRollup).Input.EventVersion
 	})
 
 	// check when last notifications of each type were sent
@@ -100,14 +131,165 @@ func (r *PropertyResolver) Notifications(args *notificationArgs) ([]*Notificatio
 
 	sort.Slice(l, func(i, j int) bool {
 		if reverseOrder {
-			return l[i].GetEventVersion() > l[j].GetEventVersion()
+			return l[i].rollup.Input.EventVersion > l[j].rollup.Input.EventVersion
 		}
-		return l[i].GetEventVersion() < l[j].GetEventVersion()
+		return l[i].rollup.Input.EventVersion < l[j].rollup.Input.EventVersion
 	})
 
 	return l, nil
 }
 
+type unreadNotificationCountArgs struct {
+	UserID *string
+}
+
+// UnreadNotificationCount is called by the GQL framework, returns the number of notifications
+// sent to the user (default me) that the user has not read
+func (r *PropertyResolver) UnreadNotificationCount(args *unreadNotificationCountArgs) (int32, error) {
+	userID, err := r.notificationUserID(args.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	notifications, err := r.Notifications(&notificationArgs{UserID: &userID})
+	if err != nil {
+		return 0, err
+	}
+
+	count := int32(0)
+	for _, notification := range notifications {
+		if !notification.Read() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// notificationUserID returns the user whose notifications are requested, only users with
+// permission can look at the notifications of other users
+func (r *PropertyResolver) notificationUserID(userID *string) (string, error) {
+	me, err := r.Me()
+	if err != nil {
+		return "", err
+	}
+	if userID == nil || *userID == me.UserID() {
+		return me.UserID(), nil
+	}
+	if err := authorize(me, viewAllNotificationsPermission); err != nil {
+		return "", err
+	}
+	return *userID, nil
+}
+
+// preferenceTemplateNames are the notifications a user can set a preference for
+var preferenceTemplateNames = []templates.TemplateName{
+	templates.NewReservationNotification,
+	templates.CancelReservationNotification,
+	templates.BalanceChangeNotification,
+	templates.LowBalanceNotification,
+}
+
+// isPreferenceTemplateName returns true if a user can set a preference for the notification
+func isPreferenceTemplateName(name templates.TemplateName) bool {
+	for _, preferenceName := range preferenceTemplateNames {
+		if preferenceName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMandatoryNotifications are the notifications of a new property that are always emailed immediately,
+// admins can change them in the settings
+var defaultMandatoryNotifications = []templates.TemplateName{
+	templates.LowBalanceNotification,
+}
+
+// mandatoryNotification returns true if the notification is always emailed immediately, whatever the preference of the user
+func (r *PropertyResolver) mandatoryNotification(name templates.TemplateName) bool {
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		Logger.LogErrorf("mandatoryNotification: error reading settings: %+v", err)
+		return false
+	}
+	for _, mandatory := range settings.settings.MandatoryNotifications {
+		if mandatory == name {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationPreference returns how the user receives a type of notification
+func (r *UserResolver) notificationPreference(name templates.TemplateName) models.NotificationPreference {
+	if r.property.mandatoryNotification(name) {
+		return models.EMAIL_IMMEDIATELY
+	}
+	if preference, ok := r.rollup.NotificationPreferences[name]; ok {
+		return preference
+	}
+	return models.EMAIL_IMMEDIATELY
+}
+
+// wantsDigest returns true if the user gets any notifications in a digest
+func (r *UserResolver) wantsDigest() bool {
+	for _, name := range preferenceTemplateNames {
+		if r.notificationPreference(name) == models.EMAIL_DIGEST {
+			return true
+		}
+	}
+	return false
+}
+
+type notificationPreferencesArgs struct {
+	UserID *string
+}
+
+// NotificationPreferences is called by the GQL framework, returns the notification preferences of the user (default me)
+func (r *PropertyResolver) NotificationPreferences(args *notificationPreferencesArgs) ([]*NotificationPreferenceResolver, error) {
+	userID, err := r.notificationUserID(args.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := r.Users(&usersArgs{UserID: &userID})
+	if len(users) != 1 {
+		return nil, errors.New("user not found")
+	}
+
+	l := []*NotificationPreferenceResolver{}
+	for _, name := range preferenceTemplateNames {
+		l = append(l, &NotificationPreferenceResolver{
+			templateName: name,
+			preference:   users[0].notificationPreference(name),
+			mandatory:    r.mandatoryNotification(name),
+		})
+	}
+	return l, nil
+}
+
+// NotificationPreferenceResolver resolves the preference for a type of notification
+type NotificationPreferenceResolver struct {
+	templateName templates.TemplateName
+	preference   models.NotificationPreference
+	mandatory    bool
+}
+
+// TemplateName is called by the GQL framework, see notificationGQL
+func (r *NotificationPreferenceResolver) TemplateName() templates.TemplateName {
+	return r.templateName
+}
+
+// Preference is called by the GQL framework, see notificationGQL
+func (r *NotificationPreferenceResolver) Preference() models.NotificationPreference {
+	return r.preference
+}
+
+// Mandatory is called by the GQL framework, see notificationGQL
+func (r *NotificationPreferenceResolver) Mandatory() bool {
+	return r.mandatory
+}
+
 // NotificationResolver resolves a single notification
 type NotificationResolver struct {
 	rollup   *NotificationRollup
@@ -148,9 +330,10 @@ func (r *NotificationResolver) toEmailFormat() []string {
 			UserID:     &userID,
 			MaxVersion: &r.rollup.Input.EventVersion,
 		})
-		if len(userResolvers) > 0 {
+		// erased users no longer have an email
+		if len(userResolvers) > 0 && userResolvers[0].Email() != "" {
 			user := userResolvers[0]
-			email := fmt.Sprintf("%s <%s>", user.Nickname(), user.Email())
+			email := (&mail.Address{Name: user.Nickname(), Address: user.Email()}).String()
 			l = append(l, email)
 		}
 	}
@@ -181,9 +364,10 @@ func (r *NotificationResolver) ccEmailFormat() []string {
 			UserID:     &userID,
 			MaxVersion: &r.rollup.Input.EventVersion,
 		})
-		if len(userResolvers) > 0 {
+		// erased users no longer have an email
+		if len(userResolvers) > 0 && userResolvers[0].Email() != "" {
 			user := userResolvers[0]
-			email := fmt.Sprintf("%s <%s>", user.Nickname(), user.Email())
+			email := (&mail.Address{Name: user.Nickname(), Address: user.Email()}).String()
 			l = append(l, email)
 		}
 	}
@@ -193,27 +377,58 @@ func (r *NotificationResolver) ccEmailFormat() []string {
 
 func (r *NotificationResolver) templateHelper(subject bool) (string, error) {
 
-	subjectTemplate, bodyTemplate, templateParamGroups := templates.GetNotificationTemplate(int(r.rollup.Input.TemplateVersion), r.rollup.Input.TemplateName)
+	subjectTemplate, bodyTemplate, templateParamGroups := r.notificationTemplates()
 	templateText := subjectTemplate
 	if !subject {
 		templateText = bodyTemplate
 	}
 
-	template, err := template.New("").Parse(templateText)
+	paramsMap, err := r.templateParams(templateParamGroups)
 	if err != nil {
 		return "", err
 	}
 
-	// the params to pass into the template
+	return executeTemplate(templateText, paramsMap)
+}
+
+// notificationTemplates returns the subject and body templates of the notification, either the
+// defaults or the content set by an admin when the notification was created
+func (r *NotificationResolver) notificationTemplates() (string, string, []templates.TemplateParamGroup) {
+	subjectTemplate, bodyTemplate, templateParamGroups := templates.GetNotificationTemplate(int(r.rollup.Input.TemplateVersion), r.rollup.Input.TemplateName)
+	if r.rollup.Input.DefaultTemplate {
+		return subjectTemplate, bodyTemplate, templateParamGroups
+	}
+
+	content := r.property.contentOverride(models.ContentName(r.rollup.Input.TemplateName), &r.rollup.Input.EventVersion)
+	if content == nil {
+		return subjectTemplate, bodyTemplate, templateParamGroups
+	}
+	if content.Input.Subject != nil {
+		subjectTemplate = *content.Input.Subject
+	}
+	return subjectTemplate, content.Input.Template, templateParamGroups
+}
+
+// notificationLinks returns the Links template param group
+func notificationLinks() map[string]string {
+	return map[string]string{
+		"Home":        destinationURI,
+		"Reservation": destinationURI + "/reservations",
+		"Ledger":      destinationURI + "/ledger",
+		"Membership":  destinationURI + "/membership",
+	}
+}
+
+// templateParams returns the params to pass into a notification template for the param groups
+func (r *NotificationResolver) templateParams(templateParamGroups []templates.TemplateParamGroup) (map[string]interface{}, error) {
 	paramsMap := make(map[string]interface{})
 
-	//for paramName, paramValue := range r.notification.Input.TemplateParamData {
 	for _, paramGroupName := range templateParamGroups {
 		switch paramGroupName {
 		case templates.Settings:
 			settings, err := r.property.Settings(&settingsArgs{MaxVersion: &r.rollup.Input.EventVersion})
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			paramsMap[string(paramGroupName)] = settings
 
@@ -225,7 +440,7 @@ func (r *NotificationResolver) templateHelper(subject bool) (string, error) {
 			})
 
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			paramsMap[string(paramGroupName)] = reservations[0]
 
@@ -238,7 +453,7 @@ func (r *NotificationResolver) templateHelper(subject bool) (string, error) {
 			ledgers := userRecord.Records()
 
 			if len(ledgers) != 1 {
-				return "", errors.New("expected a ledger")
+				return nil, errors.New("expected a ledger")
 			}
 
 			ledger := ledgers[0]
@@ -250,9 +465,47 @@ func (r *NotificationResolver) templateHelper(subject bool) (string, error) {
 		case templates.Decimal:
 			paramsMap[string(paramGroupName)] = &struct{ Format amountFormat }{Format: decimal}
 
+		case templates.Amount:
+			paramsMap[string(paramGroupName)] = &struct{ Format amountFormat }{Format: localizedSymbol}
+
+		case templates.Links:
+			paramsMap[string(paramGroupName)] = notificationLinks()
+
+		case templates.Digest:
+			userID := r.rollup.Input.TemplateParamData[templates.Digest]
+			summary, err := r.property.digestSummary(userID, r.rollup.Input.CreateDateTime, &r.rollup.Input.EventVersion)
+			if err != nil {
+				return nil, err
+			}
+			paramsMap[string(paramGroupName)] = summary
+
+		case templates.Membership:
+			restrictionID := r.rollup.Input.TemplateParamData[templates.Membership]
+			notice, err := r.property.membershipNotice(restrictionID, &r.rollup.Input.EventVersion)
+			if err != nil {
+				return nil, err
+			}
+			paramsMap[string(paramGroupName)] = notice
+
+		case templates.PaymentPlan:
+			paymentPlanID := r.rollup.Input.TemplateParamData[templates.PaymentPlan]
+			notice, err := r.property.paymentPlanNotice(paymentPlanID, r.rollup.Input.CreateDateTime, &r.rollup.Input.EventVersion)
+			if err != nil {
+				return nil, err
+			}
+			paramsMap[string(paramGroupName)] = notice
 		}
 	}
 
+	return paramsMap, nil
+}
+
+func executeTemplate(templateText string, paramsMap map[string]interface{}) (string, error) {
+	template, err := template.New("").Parse(templateText)
+	if err != nil {
+		return "", err
+	}
+
 	var buffer bytes.Buffer
 	if err := template.Execute(&buffer, paramsMap); err != nil {
 		return "", err
@@ -261,6 +514,53 @@ func (r *NotificationResolver) templateHelper(subject bool) (string, error) {
 	return buffer.String(), nil
 }
 
+// HTMLBody is called by the GQL framework, see notificationGQL, nil if the notification has no html version
+func (r *NotificationResolver) HTMLBody() (*string, error) {
+	// the html layout always shows the property branding and links back to the site
+	layoutParamGroups := []templates.TemplateParamGroup{templates.Settings, templates.Links}
+
+	// admin provided text is wrapped in the html layout
+	if !r.rollup.Input.DefaultTemplate {
+		body, err := r.templateHelper(false)
+		if err != nil {
+			return nil, err
+		}
+		paramsMap, err := r.templateParams(layoutParamGroups)
+		if err != nil {
+			return nil, err
+		}
+		htmlBody, err := customNotificationHTMLBody(body, paramsMap)
+		if err != nil {
+			return nil, err
+		}
+		return &htmlBody, nil
+	}
+
+	htmlTemplate := templates.GetNotificationHTMLTemplate(int(r.rollup.Input.TemplateVersion), r.rollup.Input.TemplateName)
+	if htmlTemplate == "" {
+		return nil, nil
+	}
+
+	_, _, templateParamGroups := templates.GetNotificationTemplate(int(r.rollup.Input.TemplateVersion), r.rollup.Input.TemplateName)
+	paramsMap, err := r.templateParams(append(templateParamGroups, layoutParamGroups...))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := executeTemplate(htmlTemplate, paramsMap)
+	if err != nil {
+		return nil, err
+	}
+	return &body, nil
+}
+
+// customNotificationHTMLBody wraps a rendered text body in the html layout, the text body was rendered
+// by html/template so its params are already escaped and it must not be escaped again
+func customNotificationHTMLBody(body string, paramsMap map[string]interface{}) (string, error) {
+	paramsMap["Body"] = template.HTML(body)
+	return executeTemplate(templates.GetCustomNotificationHTMLTemplate(), paramsMap)
+}
+
 // Subject is called by the GQL framework, see notificationGQL
 func (r *NotificationResolver) Subject() (string, error) {
 	return r.templateHelper(true)
@@ -288,12 +588,18 @@ func (r *NotificationResolver) DefaultTemplate() bool {
 
 // Read is called by the GQL framework, see notificationGQL
 func (r *NotificationResolver) Read() bool {
-	// only return true if a user is targeted, otherwise all the notifications are returned
+	// read state is per user, default to me when a user is not targeted
 	// (example in an admin call)
-	if r.args.UserID == nil {
-		return false
+	userID := r.args.UserID
+	if userID == nil {
+		me, err := r.property.Me()
+		if err != nil {
+			return false
+		}
+		id := me.UserID()
+		userID = &id
 	}
-	if _, ok := r.rollup.ReaderUserIdsMap[*r.args.UserID]; ok {
+	if _, ok := r.rollup.ReaderUserIdsMap[*userID]; ok {
 		return true
 	}
 	return false
@@ -318,7 +624,7 @@ func (r *NotificationResolver) authorEmailFormat() *string {
 	})
 	if len(userResolvers) > 0 {
 		user := userResolvers[0]
-		email := fmt.Sprintf("%s <%s>", user.Nickname(), user.Email())
+		email := (&mail.Address{Name: user.Nickname(), Address: user.Email()}).String()
 		return &email
 	}
 	return nil
@@ -326,10 +632,78 @@ func (r *NotificationResolver) authorEmailFormat() *string {
 
 // EmailSent is called by the GQL framework, see notificationGQL
 func (r *NotificationResolver) EmailSent() bool {
-	return r.rollup.Input.EmailSent
+	return r.rollup.DeliveryStatus == deliveryDelivered
+}
+
+// DeliveryStatus is called by the GQL framework, see notificationGQL
+func (r *NotificationResolver) DeliveryStatus() DeliveryStatus {
+	return r.rollup.DeliveryStatus
+}
+
+// DeliveryAttempts is called by the GQL framework, see notificationGQL
+func (r *NotificationResolver) DeliveryAttempts() int32 {
+	return r.rollup.DeliveryAttempts
+}
+
+// LastDeliveryError is called by the GQL framework, see notificationGQL
+func (r *NotificationResolver) LastDeliveryError() *string {
+	if r.rollup.LastDeliveryError == "" {
+		return nil
+	}
+	return &r.rollup.LastDeliveryError
+}
+
+// LastAttemptDateTime is called by the GQL framework, see notificationGQL
+func (r *NotificationResolver) LastAttemptDateTime() *string {
+	if r.rollup.LastAttemptDateTime == "" {
+		return nil
+	}
+	return &r.rollup.LastAttemptDateTime
 }
 
 // CreateDateTime is called by the GQL framework, see notificationGQL
 func (r *NotificationResolver) CreateDateTime() string {
 	return r.rollup.Input.CreateDateTime
 }
+
+// Comments is called by the GQL framework, see notificationGQL
+func (r *NotificationResolver) Comments() ([]*NotificationCommentResolver, error) {
+	me, err := r.property.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, viewAllNotificationsPermission); err != nil {
+		return nil, err
+	}
+
+	l := []*NotificationCommentResolver{}
+	for _, comment := range r.rollup.Comments {
+		l = append(l, &NotificationCommentResolver{comment: comment, property: r.property})
+	}
+	return l, nil
+}
+
+// NotificationCommentResolver resolves a reply to a notification email
+type NotificationCommentResolver struct {
+	comment  *models.NotificationCommentInput
+	property *PropertyResolver
+}
+
+// Author is called by the GQL framework, see notificationGQL
+func (r *NotificationCommentResolver) Author() *UserResolver {
+	userResolvers := r.property.Users(&usersArgs{UserID: &r.comment.AuthorUserId})
+	if len(userResolvers) > 0 {
+		return userResolvers[0]
+	}
+	return nil
+}
+
+// Comment is called by the GQL framework, see notificationGQL
+func (r *NotificationCommentResolver) Comment() string {
+	return r.comment.Comment
+}
+
+// CreateDateTime is called by the GQL framework, see notificationGQL
+func (r *NotificationCommentResolver) CreateDateTime() string {
+	return r.comment.CreateDateTime
+}
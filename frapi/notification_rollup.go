@@ -10,11 +10,56 @@ type NotificationRollup struct {
 	ReaderUserIdsMap map[string]bool
 	EventVersion     int32
 	TargetUserIdsMap map[string]bool
+
+	// email delivery, see models.NotificationDeliveryInput
+	DeliveryStatus      DeliveryStatus
+	DeliveryAttempts    int32
+	LastDeliveryError   string
+	LastAttemptDateTime string
+
+	// replies to the notification email, see models.NotificationCommentInput
+	Comments []*models.NotificationCommentInput
+}
+
+// DeliveryStatus is the state of the notification email, exported for GQL
+type DeliveryStatus string
+
+const (
+	deliveryPending   DeliveryStatus = "PENDING"
+	deliveryDelivered DeliveryStatus = "DELIVERED"
+	deliveryFailed    DeliveryStatus = "FAILED"
+	deliveryNotSent   DeliveryStatus = "NOT_SENT"
+)
+
+// maxDeliveryAttempts is the number of failed attempts after which a notification email is given up
+const maxDeliveryAttempts = 8
+
+func initialDeliveryStatus(input *models.NewNotificationInput) DeliveryStatus {
+	if !input.EmailSent {
+		return deliveryNotSent
+	}
+	if !input.DeliveryTracked {
+		// older notifications were assumed to be sent
+		return deliveryDelivered
+	}
+	return deliveryPending
 }
 
 // GetEventVersion returns the version of the rollup record
 func (r *NotificationRollup) GetEventVersion() int {
-	return int(r.Input.EventVersion)
+	return int(r.EventVersion)
+}
+
+// copyWithReader returns a new version of the rollup that includes the reader
+func (r *NotificationRollup) copyWithReader(userID string, eventVersion int32) *NotificationRollup {
+	notification := *r
+	notification.ReaderUserIdsMap = make(map[string]bool)
+	for readerID := range r.ReaderUserIdsMap {
+		notification.ReaderUserIdsMap[readerID] = true
+	}
+	notification.ReaderUserIdsMap[userID] = true
+	notification.EventVersion = eventVersion
+	return &notification
 }
 
 func (r *PropertyResolver) rollupNotifications() {
@@ -38,6 +83,11 @@ func (r *PropertyResolver) rollupNotifications() {
 				for _, userID := range newNotificationEvent.CcUserIds {
 					notificationRecord.TargetUserIdsMap[userID] = true
 				}
+				// users who are notified in the app only, example by preference
+				for _, userID := range newNotificationEvent.AllNotifiedUserIds {
+					notificationRecord.TargetUserIdsMap[userID] = true
+				}
+				notificationRecord.DeliveryStatus = initialDeliveryStatus(newNotificationEvent)
 
 				r.addRollup(notificationRecord.Input.NotificationId,
 					notificationRecord, notificationRollupType)
@@ -47,14 +97,60 @@ func (r *PropertyResolver) rollupNotifications() {
 				ifaces := r.getRollups(&rollupArgs{id: &notificationReadInput.NotificationId}, notificationRollupType)
 				rollup, _ := ifaces[0].(*NotificationRollup)
 
-				// make a copy
+				// make a copy so earlier versions keep their read state
+				notification := rollup.copyWithReader(notificationReadInput.AuthorUserId, notificationReadInput.EventVersion)
+
+				r.addRollup(notification.Input.NotificationId,
+					notification, notificationRollupType)
+			}
+
+			if deliveryInput, ok := event.(*models.NotificationDeliveryInput); ok {
+				ifaces := r.getRollups(&rollupArgs{id: &deliveryInput.NotificationId}, notificationRollupType)
+				rollup, _ := ifaces[0].(*NotificationRollup)
+
+				// make a copy, the maps are not changed so can be shared
 				notification := *rollup
-				notification.ReaderUserIdsMap[notificationReadInput.AuthorUserId] = true
-				notification.EventVersion = notificationReadInput.EventVersion
+				notification.EventVersion = deliveryInput.EventVersion
+				notification.LastAttemptDateTime = deliveryInput.CreateDateTime
+				notification.LastDeliveryError = deliveryInput.Error
+				notification.DeliveryAttempts++
+				if deliveryInput.Delivered {
+					notification.DeliveryStatus = deliveryDelivered
+				} else if notification.DeliveryAttempts >= maxDeliveryAttempts {
+					notification.DeliveryStatus = deliveryFailed
+				}
 
 				r.addRollup(notification.Input.NotificationId,
 					&notification, notificationRollupType)
 			}
+
+			if commentInput, ok := event.(*models.NotificationCommentInput); ok {
+				ifaces := r.getRollups(&rollupArgs{id: &commentInput.NotificationId}, notificationRollupType)
+				rollup, _ := ifaces[0].(*NotificationRollup)
+
+				// make a copy with a new comments slice
+				notification := *rollup
+				notification.EventVersion = commentInput.EventVersion
+				notification.Comments = append(append([]*models.NotificationCommentInput{}, rollup.Comments...), commentInput)
+
+				r.addRollup(notification.Input.NotificationId,
+					&notification, notificationRollupType)
+			}
+
+			if allReadInput, ok := event.(*models.AllNotificationsReadInput); ok {
+				ifaces := r.getRollups(&rollupArgs{}, notificationRollupType)
+				for _, iface := range ifaces {
+					rollup, _ := iface.(*NotificationRollup)
+					if !rollup.TargetUserIdsMap[allReadInput.AuthorUserId] || rollup.ReaderUserIdsMap[allReadInput.AuthorUserId] {
+						continue
+					}
+
+					notification := rollup.copyWithReader(allReadInput.AuthorUserId, allReadInput.EventVersion)
+
+					r.addRollup(notification.Input.NotificationId,
+						notification, notificationRollupType)
+				}
+			}
 		}
 		cacheError := r.cacheRollup(notificationRollupType)
 		if cacheError != nil {
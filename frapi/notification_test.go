@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/templates"
 )
 
 func TestReservationNotification(t *testing.T) {
@@ -150,3 +153,313 @@ func TestNotifications(t *testing.T) {
 	}
 
 }
+
+func TestNotificationReadState(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	property = createPayment(ctx, t, resolver, property, 1111, true, property.EventVersion())
+	property = createPayment(ctx, t, resolver, property, 2222, true, property.EventVersion())
+
+	count, err := property.UnreadNotificationCount(&unreadNotificationCountArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 unread notifications but got %+v", count)
+	}
+
+	t.Log("read the first notification")
+	notifications, _ := property.Notifications(&notificationArgs{})
+	firstID := notifications[0].NotificationID()
+	property, err = resolver.NotificationRead(ctx, &struct {
+		PropertyID     string
+		NotificationID string
+		ForVersion     int32
+	}{
+		PropertyID:     property.PropertyID(),
+		NotificationID: firstID,
+		ForVersion:     property.EventVersion(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, _ = property.UnreadNotificationCount(&unreadNotificationCountArgs{})
+	if count != 2 {
+		t.Fatalf("expected 2 unread notifications but got %+v", count)
+	}
+
+	t.Log("the order is not changed by reading")
+	notifications, _ = property.Notifications(&notificationArgs{})
+	if notifications[0].NotificationID() != firstID || !notifications[0].Read() {
+		t.Fatalf("expected the first notification to stay first and be read")
+	}
+
+	t.Log("earlier versions keep their read state")
+	allVersions := true
+	versions := property.getRollups(&rollupArgs{id: &firstID, allVersions: &allVersions}, notificationRollupType)
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions but got %+v", len(versions))
+	}
+	if len(versions[0].(*NotificationRollup).ReaderUserIdsMap) != 0 {
+		t.Fatalf("expected the first version to be unread")
+	}
+
+	t.Log("a notification cannot be read twice")
+	_, err = resolver.NotificationRead(ctx, &struct {
+		PropertyID     string
+		NotificationID string
+		ForVersion     int32
+	}{
+		PropertyID:     property.PropertyID(),
+		NotificationID: firstID,
+		ForVersion:     property.EventVersion(),
+	})
+	if err == nil {
+		t.Fatal("expected second read to fail")
+	}
+
+	t.Log("mark all read")
+	property, err = resolver.MarkAllNotificationsRead(ctx, &struct {
+		PropertyID string
+		ForVersion int32
+	}{
+		PropertyID: property.PropertyID(),
+		ForVersion: property.EventVersion(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, _ = property.UnreadNotificationCount(&unreadNotificationCountArgs{})
+	if count != 0 {
+		t.Fatalf("expected no unread notifications but got %+v", count)
+	}
+
+	t.Log("a member can read their own notifications")
+	memberEmail := "member@test.com"
+	property, member := createUserWithRoles(ctx, t, resolver, property, memberEmail, "member", []models.UserRole{})
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property, err = resolver.AcceptInvitation(ctx, &struct {
+		PropertyID string
+		Input      *models.AcceptInvitationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.AcceptInvitationInput{ForVersion: property.EventVersion(), Accept: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, _ = createReservation(ctx, t, resolver, property, member.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+
+	count, err = property.UnreadNotificationCount(&unreadNotificationCountArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("expected the member to have unread notifications")
+	}
+
+	adminID := me.UserID()
+	if _, err := property.UnreadNotificationCount(&unreadNotificationCountArgs{UserID: &adminID}); err == nil {
+		t.Fatal("expected member count for another user to fail")
+	}
+
+	_, err = resolver.NotificationRead(ctx, &struct {
+		PropertyID     string
+		NotificationID string
+		ForVersion     int32
+	}{
+		PropertyID:     property.PropertyID(),
+		NotificationID: firstID,
+		ForVersion:     property.EventVersion(),
+	})
+	if err == nil {
+		t.Fatal("expected member read of another user's notification to fail")
+	}
+
+	memberID := member.UserID()
+	notifications, _ = property.Notifications(&notificationArgs{UserID: &memberID})
+	property, err = resolver.NotificationRead(ctx, &struct {
+		PropertyID     string
+		NotificationID string
+		ForVersion     int32
+	}{
+		PropertyID:     property.PropertyID(),
+		NotificationID: notifications[0].NotificationID(),
+		ForVersion:     property.EventVersion(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := property.UnreadNotificationCount(&unreadNotificationCountArgs{})
+	if after != count-1 {
+		t.Fatalf("expected %+v unread notifications but got %+v", count-1, after)
+	}
+}
+
+func TestReservationNotificationHTMLBody(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(3).ToString())
+
+	notifications, err := property.Notifications(&notificationArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("expected 2 notifications")
+	}
+
+	htmlBody, err := notifications[1].HTMLBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htmlBody == nil {
+		t.Fatal("expected an html body")
+	}
+	t.Logf("html body: %+v", *htmlBody)
+
+	settings, _ := property.Settings(&settingsArgs{})
+	for _, expected := range []string{
+		settings.PropertyName(),
+		today.AddDays(1).ToString(),
+		today.AddDays(3).ToString(),
+		me.Nickname(),
+		`href="` + destinationURI + `/reservations"`,
+	} {
+		if !strings.Contains(*htmlBody, expected) {
+			t.Fatalf("expected html body to contain %+v", expected)
+		}
+	}
+}
+
+func updateNotificationPreference(ctx context.Context, resolver *Resolver, property *PropertyResolver, name templates.TemplateName, preference models.NotificationPreference) (*PropertyResolver, error) {
+	return resolver.UpdateNotificationPreference(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateNotificationPreferenceInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.UpdateNotificationPreferenceInput{
+			ForVersion:   property.EventVersion(),
+			TemplateName: name,
+			Preference:   preference,
+		},
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	memberEmail := "member@test.com"
+	property, member := createUserWithRoles(ctx, t, resolver, property, memberEmail, "member", []models.UserRole{})
+	memberID := member.UserID()
+
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property, err := resolver.AcceptInvitation(ctx, &struct {
+		PropertyID string
+		Input      *models.AcceptInvitationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.AcceptInvitationInput{ForVersion: property.EventVersion(), Accept: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("the member sets preferences")
+	property, err = updateNotificationPreference(ctx, resolver, property, templates.NewReservationNotification, models.IN_APP_ONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, err = updateNotificationPreference(ctx, resolver, property, templates.CancelReservationNotification, models.EMAIL_DIGEST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateNotificationPreference(ctx, resolver, property, templates.CancelReservationNotification, models.EMAIL_DIGEST); err == nil {
+		t.Fatal("expected an unchanged preference to fail")
+	}
+	if _, err := updateNotificationPreference(ctx, resolver, property, templates.LowBalanceNotification, models.IN_APP_ONLY); err == nil {
+		t.Fatal("expected a mandatory notification preference to fail")
+	}
+	if _, err := updateNotificationPreference(ctx, resolver, property, templates.NewPropertyNotification, models.IN_APP_ONLY); err == nil {
+		t.Fatal("expected an unknown notification preference to fail")
+	}
+
+	preferences, err := property.NotificationPreferences(&notificationPreferencesArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, preference := range preferences {
+		expected := models.EMAIL_IMMEDIATELY
+		switch preference.TemplateName() {
+		case templates.NewReservationNotification:
+			expected = models.IN_APP_ONLY
+		case templates.CancelReservationNotification:
+			expected = models.EMAIL_DIGEST
+		}
+		if preference.Preference() != expected {
+			t.Fatalf("expected %+v to be %+v but got %+v", preference.TemplateName(), expected, preference.Preference())
+		}
+	}
+
+	adminID := me.UserID()
+	if _, err := property.NotificationPreferences(&notificationPreferencesArgs{UserID: &adminID}); err == nil {
+		t.Fatal("expected a member to not view the preferences of another user")
+	}
+
+	t.Log("the admin makes and cancels a reservation")
+	testUserEmail = defaultEmail
+	property = getUpdatedProperty(ctx, t, resolver)
+	property, reservations := createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	property, _ = cancelReservation(ctx, t, resolver, property, reservations[0].ReservationID(), false, property.EventVersion())
+
+	notifications, _ := property.Notifications(&notificationArgs{UserID: &memberID, Reverse: &[]bool{true}[0]})
+	if len(notifications) != 2 {
+		t.Fatalf("expected the member to be notified in the app twice but got %+v", len(notifications))
+	}
+
+	cancel := notifications[0].rollup.Input
+	newReservation := notifications[1].rollup.Input
+	for _, input := range []*models.NewNotificationInput{cancel, newReservation} {
+		for _, userID := range append(input.ToUserIds, input.CcUserIds...) {
+			if userID == memberID {
+				t.Fatalf("expected the member to not be emailed %+v", input.TemplateName)
+			}
+		}
+	}
+	if len(cancel.DigestUserIds) != 1 || cancel.DigestUserIds[0] != memberID {
+		t.Fatalf("expected the member in the cancel digest but got %+v", cancel.DigestUserIds)
+	}
+	if len(newReservation.DigestUserIds) != 0 {
+		t.Fatalf("expected no digest for the new reservation but got %+v", newReservation.DigestUserIds)
+	}
+
+	t.Log("the admin chooses the mandatory notifications")
+	input := currentSettingsInput(property)
+	input.MandatoryNotifications = &[]templates.TemplateName{templates.NewPropertyNotification}
+	if _, err := updateSettings(ctx, resolver, property, input); err == nil {
+		t.Fatal("expected a notification without preferences to not be mandatory")
+	}
+	input.MandatoryNotifications = &[]templates.TemplateName{templates.CancelReservationNotification}
+	if property, err = updateSettings(ctx, resolver, property, input); err != nil {
+		t.Fatal(err)
+	}
+
+	testUserEmail = memberEmail
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, err := updateNotificationPreference(ctx, resolver, property, templates.LowBalanceNotification, models.IN_APP_ONLY); err != nil {
+		t.Fatalf("expected the low balance notification to no longer be mandatory: %+v", err)
+	}
+	preferences, _ = property.NotificationPreferences(&notificationPreferencesArgs{})
+	for _, preference := range preferences {
+		mandatory := preference.TemplateName() == templates.CancelReservationNotification
+		if preference.Mandatory() != mandatory {
+			t.Fatalf("expected %+v mandatory to be %+v", preference.TemplateName(), mandatory)
+		}
+		if mandatory && preference.Preference() != models.EMAIL_IMMEDIATELY {
+			t.Fatalf("expected the mandatory cancel notification to be emailed but got %+v", preference.Preference())
+		}
+	}
+}
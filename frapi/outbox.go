@@ -0,0 +1,150 @@
+package frapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// deliveryBackoff is the wait before retrying a notification email, doubled after each failed attempt
+const deliveryBackoff = 15 * time.Minute
+
+// deliverNotification emails the notification and records the outcome as an event, failed
+// deliveries stay in the outbox to be retried, returns the property with the outcome recorded
+func deliverNotification(ctx context.Context, property *PropertyResolver, notificationID string) *PropertyResolver {
+	notifications, err := property.Notifications(&notificationArgs{notificationID: &notificationID})
+	if err != nil || len(notifications) != 1 {
+		Logger.LogErrorf("deliverNotification: notification %v not found", notificationID)
+		return property
+	}
+	notification := notifications[0]
+
+	sendErr := sendEmail(ctx, property, notification)
+	if sendErr != nil {
+		Logger.LogErrorf("deliverNotification: error sending email: %+v", sendErr)
+	}
+
+	if !notification.rollup.Input.DeliveryTracked {
+		return property
+	}
+
+	deliveryInput := &models.NotificationDeliveryInput{
+		NotificationId: notificationID,
+		Delivered:      sendErr == nil,
+		CreateDateTime: frdate.CreateDateTimeUTC(),
+		AuthorUserId:   notification.rollup.Input.AuthorUserId,
+	}
+	if sendErr != nil {
+		deliveryInput.Error = sendErr.Error()
+	}
+
+	_, err = PersistedVersionedEvents.NewPropertyEvents(ctx, property.PropertyID(), int(property.EventVersion())+1,
+		[]platform.VersionedEvent{deliveryInput}, false)
+	if err != nil {
+		// the notification stays pending, so the email may be sent again by the outbox
+		Logger.LogWarningf("deliverNotification: error recording delivery: %+v", err)
+		return property
+	}
+
+	updated, err := currentBaseProperty(ctx, property.email, property.PropertyID())
+	if err != nil {
+		Logger.LogErrorf("deliverNotification: error reading property: %+v", err)
+		return property
+	}
+	return updated
+}
+
+// nextDeliveryAttempt returns when the pending notification email should next be sent
+func nextDeliveryAttempt(rollup *NotificationRollup) (time.Time, error) {
+	// an email that has never been attempted may still be in flight, so wait from when it was created
+	last := rollup.LastAttemptDateTime
+	if last == "" {
+		last = rollup.Input.CreateDateTime
+	}
+	lastTime, err := time.Parse(time.RFC3339, last)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	wait := deliveryBackoff
+	for attempt := int32(1); attempt < rollup.DeliveryAttempts; attempt++ {
+		wait *= 2
+	}
+	return lastTime.Add(wait), nil
+}
+
+// deliverPendingNotifications retries the notification emails in the outbox that are due
+func deliverPendingNotifications(ctx context.Context, property *PropertyResolver) *PropertyResolver {
+	now, _ := time.Parse(time.RFC3339, frdate.CreateDateTimeUTC())
+
+	notifications, err := property.Notifications(&notificationArgs{})
+	if err != nil {
+		Logger.LogErrorf("deliverPendingNotifications: error reading notifications: %+v", err)
+		return property
+	}
+
+	for _, notification := range notifications {
+		if notification.rollup.DeliveryStatus != deliveryPending {
+			continue
+		}
+		next, err := nextDeliveryAttempt(notification.rollup)
+		if err != nil {
+			Logger.LogErrorf("deliverPendingNotifications: bad date time: %+v", err)
+			continue
+		}
+		if now.Before(next) {
+			continue
+		}
+		Logger.LogDebugf("deliverPendingNotifications: retry notification %v", notification.NotificationID())
+		property = deliverNotification(ctx, property, notification.NotificationID())
+	}
+	return property
+}
+
+// DeliverOutbox is called by the service periodically to retry failed notification emails
+func DeliverOutbox(ctx context.Context) error {
+	Logger.LogInfof("DeliverOutbox start")
+
+	resolver := &Resolver{}
+	properties, err := resolver.cronProperties(ctx)
+	if err != nil {
+		Logger.LogErrorf("DeliverOutbox error accessing properties: %+v", err)
+		return err
+	}
+
+	for _, property := range properties {
+		deliverPendingNotifications(ctx, property)
+	}
+
+	Logger.LogInfof("DeliverOutbox end success")
+	return nil
+}
+
+// UndeliveredNotifications is called by the GQL framework, returns the notifications
+// whose email is still pending or has failed
+func (r *PropertyResolver) UndeliveredNotifications() ([]*NotificationResolver, error) {
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(me, viewAllNotificationsPermission); err != nil {
+		return nil, err
+	}
+
+	notifications, err := r.Notifications(&notificationArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	undelivered := []*NotificationResolver{}
+	for _, notification := range notifications {
+		status := notification.rollup.DeliveryStatus
+		if status == deliveryPending || status == deliveryFailed {
+			undelivered = append(undelivered, notification)
+		}
+	}
+	return undelivered, nil
+}
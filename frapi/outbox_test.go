@@ -0,0 +1,142 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// testEmailSender fails to send while fail is true
+type testEmailSender struct {
+	fail bool
+	sent int
+	last *platform.EmailMessage
+}
+
+func (r *testEmailSender) Send(ctx context.Context, msg *platform.EmailMessage) error {
+	if r.fail {
+		return errors.New("mail server unavailable")
+	}
+	r.sent++
+	r.last = msg
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	defer func() { frdate.TestTimeOffsetDays = nil }()
+
+	sender := &testEmailSender{fail: true}
+	EmailSender = sender
+
+	t.Log("a failed send leaves the notification in the outbox")
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+
+	undelivered, err := property.UndeliveredNotifications()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undelivered) != 1 {
+		t.Fatalf("expected 1 undelivered notification but got %+v", len(undelivered))
+	}
+	notification := undelivered[0]
+	if notification.EmailSent() || notification.DeliveryStatus() != deliveryPending || notification.DeliveryAttempts() != 1 {
+		t.Fatalf("expected a pending notification after 1 attempt")
+	}
+	if notification.LastDeliveryError() == nil {
+		t.Fatal("expected the send error to be recorded")
+	}
+
+	t.Log("the retry waits for the backoff")
+	property = deliverPendingNotifications(ctx, property)
+	undelivered, _ = property.UndeliveredNotifications()
+	if undelivered[0].DeliveryAttempts() != 1 {
+		t.Fatal("expected no retry before the backoff")
+	}
+
+	t.Log("the outbox delivers once the mail server is back")
+	sender.fail = false
+	offset := 1
+	frdate.TestTimeOffsetDays = &offset
+	if err := DeliverOutbox(ctx); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	undelivered, _ = property.UndeliveredNotifications()
+	if len(undelivered) != 0 || sender.sent != 1 {
+		t.Fatalf("expected the notification to be delivered once")
+	}
+	notifications, _ := property.Notifications(&notificationArgs{notificationID: &notification.rollup.Input.NotificationId})
+	if !notifications[0].EmailSent() || notifications[0].DeliveryAttempts() != 2 {
+		t.Fatalf("expected the notification to be delivered on the second attempt")
+	}
+
+	t.Log("the outbox gives up after the max attempts")
+	sender.fail = true
+	frdate.TestTimeOffsetDays = nil
+	property = createPayment(ctx, t, resolver, property, 200, true, property.EventVersion())
+	for attempt := 2; attempt <= maxDeliveryAttempts+1; attempt++ {
+		offset := attempt * 2
+		frdate.TestTimeOffsetDays = &offset
+		property = deliverPendingNotifications(ctx, property)
+	}
+	undelivered, _ = property.UndeliveredNotifications()
+	if len(undelivered) != 1 || undelivered[0].DeliveryStatus() != deliveryFailed || undelivered[0].DeliveryAttempts() != maxDeliveryAttempts {
+		t.Fatalf("expected a failed notification after %+v attempts", maxDeliveryAttempts)
+	}
+
+	t.Log("a member cannot view the outbox")
+	memberEmail := "member@test.com"
+	createUser(ctx, t, resolver, property, memberEmail, "member")
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, err := property.UndeliveredNotifications(); err == nil {
+		t.Fatal("expected member outbox query to fail")
+	}
+}
+
+func TestEmailAddresses(t *testing.T) {
+	property, ctx, resolver, _, today := initAndCreateTestProperty(context.Background(), t)
+
+	sender := &testEmailSender{}
+	EmailSender = sender
+
+	t.Log("nicknames with commas and non ascii letters are quoted in the addresses")
+	zoeEmail := "zoe@example.com"
+	property, zoe := createUserWithRoles(ctx, t, resolver, property, zoeEmail, "Smith, Zoë", []models.UserRole{})
+	testUserEmail = zoeEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property, err := resolver.AcceptInvitation(ctx, &struct {
+		PropertyID string
+		Input      *models.AcceptInvitationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.AcceptInvitationInput{ForVersion: property.EventVersion(), Accept: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createReservation(ctx, t, resolver, property, zoe.UserID(), today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	if sender.last == nil {
+		t.Fatal("expected an email")
+	}
+	found := false
+	for _, address := range append(append([]string{sender.last.Sender}, sender.last.To...), sender.last.Cc...) {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Name == "Smith, Zoë" && parsed.Address == zoeEmail {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected an address for the user in %+v %+v", sender.last.To, sender.last.Cc)
+	}
+}
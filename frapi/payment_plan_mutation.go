@@ -0,0 +1,147 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+// maxInstallments is the most installments a payment plan can have
+const maxInstallments = 24
+
+// CreatePaymentPlan is called by an admin or treasurer to let a user pay over time
+func (r *Resolver) CreatePaymentPlan(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.NewPaymentPlanInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Create Payment Plan")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	users := property.Users(&usersArgs{UserID: &args.Input.UserId})
+	if len(users) != 1 {
+		return nil, errors.New("user does not exist")
+	}
+
+	constraints, err := property.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	args.Input.Description = strings.TrimSpace(args.Input.Description)
+	if len(args.Input.Description) < int(constraints.DescriptionMin()) {
+		return nil, errors.New("description too small")
+	}
+	if len(args.Input.Description) > int(constraints.DescriptionMax()) {
+		return nil, errors.New("description too big")
+	}
+
+	if len(args.Input.Installments) == 0 {
+		return nil, errors.New("a payment plan needs at least one installment")
+	}
+	if len(args.Input.Installments) > maxInstallments {
+		return nil, fmt.Errorf("a payment plan can have at most %v installments", maxInstallments)
+	}
+
+	settings, err := property.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+	lastDueDate := dateBuilder.Today().AddDays(-1)
+	for _, installment := range args.Input.Installments {
+		dueDate, err := dateBuilder.NewDate(installment.DueDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid installment due date %+v", installment.DueDate)
+		}
+		if !dueDate.After(lastDueDate) {
+			return nil, errors.New("installment due dates must be in order and not in the past")
+		}
+		lastDueDate = dueDate
+
+		if installment.Amount < constraints.AmountMin() || installment.Amount > constraints.AmountMax() {
+			return nil, fmt.Errorf("installment amount out of range %+v", installment.Amount)
+		}
+	}
+
+	// one plan at a time per user
+	plans, err := property.PaymentPlans(&paymentPlansArgs{UserID: &args.Input.UserId})
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range plans {
+		if status := plan.Status(); status == currentPaymentPlanStatus || status == missedPaymentPlanStatus {
+			return nil, errors.New("the user already has an open payment plan")
+		}
+	}
+
+	// input looks good, now add extra internal values
+	args.Input.PaymentPlanId = utilities.NewGUID()
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserId = me.UserID()
+
+	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
+}
+
+// CancelPaymentPlan is called by an admin or treasurer to end a payment plan
+func (r *Resolver) CancelPaymentPlan(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.CancelPaymentPlanInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Cancel Payment Plan")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	plans, err := property.PaymentPlans(&paymentPlansArgs{PaymentPlanID: &args.Input.PaymentPlanId})
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) != 1 {
+		return nil, fmt.Errorf("payment plan not found for id: %+v", args.Input.PaymentPlanId)
+	}
+	if plans[0].rollup.Canceled {
+		return nil, errors.New("payment plan already canceled")
+	}
+
+	// input looks good, now add extra internal values
+	args.Input.UserId = plans[0].rollup.Input.UserId
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserId = me.UserID()
+
+	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
+}
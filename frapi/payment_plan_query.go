@@ -0,0 +1,255 @@
+package frapi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+const paymentPlanGQL = `
+enum PaymentPlanStatus {
+	# all installments due so far have been paid
+	CURRENT
+	# an installment is past its due date and not paid
+	MISSED
+	# all installments have been paid
+	COMPLETED
+	CANCELED
+}
+
+type Installment {
+	dueDate: String!
+	amount(format: AmountFormat = DECIMAL): String!
+	# true if the payments since the plan was created cover this and the earlier installments
+	paid: Boolean!
+}
+
+type PaymentPlan {
+	paymentPlanId: String!
+	user: User!
+	author: User!
+	createDateTime: String!
+	updateDateTime: String!
+	description: String!
+	status: PaymentPlanStatus!
+	total(format: AmountFormat = DECIMAL): String!
+	# sum of the payments since the plan was created
+	paid(format: AmountFormat = DECIMAL): String!
+	installments: [Installment!]!
+}
+`
+
+// PaymentPlanStatus is the status of a payment plan, exported for GQL
+type PaymentPlanStatus string
+
+const (
+	currentPaymentPlanStatus   PaymentPlanStatus = "CURRENT"
+	missedPaymentPlanStatus    PaymentPlanStatus = "MISSED"
+	completedPaymentPlanStatus PaymentPlanStatus = "COMPLETED"
+	canceledPaymentPlanStatus  PaymentPlanStatus = "CANCELED"
+)
+
+type paymentPlansArgs struct {
+	UserID        *string
+	PaymentPlanID *string
+	MaxVersion    *int32
+}
+
+// PaymentPlans is called by gql to list payment plans, members only see their own plans
+func (r *PropertyResolver) PaymentPlans(args *paymentPlansArgs) ([]*PaymentPlanResolver, error) {
+
+	// validate
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+	if !me.hasPermission(viewLedgersPermission) && !me.IsSystem() {
+		if args.UserID == nil || *args.UserID != me.UserID() {
+			return nil, fmt.Errorf("user must be admin, treasurer or same user to view payment plans")
+		}
+	}
+
+	// rollup
+	r.rollupPaymentPlans()
+
+	// resolve
+	return r.resolvePaymentPlans(args)
+}
+
+func (r *PropertyResolver) resolvePaymentPlans(args *paymentPlansArgs) ([]*PaymentPlanResolver, error) {
+	settings, err := r.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+
+	resolvers := []*PaymentPlanResolver{}
+	ifaces := r.getRollups(&rollupArgs{id: args.PaymentPlanID, maxVersion: args.MaxVersion}, paymentPlanRollupType)
+	for _, iface := range ifaces {
+		rollup := iface.(*PaymentPlanRollup)
+		if args.UserID != nil && rollup.Input.UserId != *args.UserID {
+			continue
+		}
+		resolvers = append(resolvers, &PaymentPlanResolver{rollup: rollup, property: r, maxVersion: args.MaxVersion, dateBuilder: dateBuilder})
+	}
+
+	// oldest first
+	sort.Slice(resolvers, func(i, j int) bool {
+		return resolvers[i].rollup.Input.EventVersion < resolvers[j].rollup.Input.EventVersion
+	})
+
+	return resolvers, nil
+}
+
+// hasCurrentPaymentPlan returns true if the user is keeping up with a payment plan (internal call)
+func (r *PropertyResolver) hasCurrentPaymentPlan(userID string) bool {
+	r.rollupPaymentPlans()
+	plans, err := r.resolvePaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if err != nil {
+		return false
+	}
+	for _, plan := range plans {
+		if plan.Status() == currentPaymentPlanStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentPlanResolver resolves a payment plan
+type PaymentPlanResolver struct {
+	rollup      *PaymentPlanRollup
+	property    *PropertyResolver
+	maxVersion  *int32
+	dateBuilder *frdate.DateBuilder
+}
+
+// PaymentPlanID is the unique id of the plan
+func (r *PaymentPlanResolver) PaymentPlanID() string {
+	return r.rollup.Input.PaymentPlanId
+}
+
+// User is who the plan is for
+func (r *PaymentPlanResolver) User() *UserResolver {
+	users := r.property.Users(&usersArgs{UserID: &r.rollup.Input.UserId})
+	return users[0]
+}
+
+// Author is the admin who created the plan
+func (r *PaymentPlanResolver) Author() *UserResolver {
+	users := r.property.Users(&usersArgs{UserID: &r.rollup.Input.AuthorUserId})
+	return users[0]
+}
+
+// CreateDateTime is when the plan was created
+func (r *PaymentPlanResolver) CreateDateTime() string {
+	return r.rollup.Input.CreateDateTime
+}
+
+// UpdateDateTime is when the plan was created or canceled
+func (r *PaymentPlanResolver) UpdateDateTime() string {
+	return r.rollup.UpdateDateTime
+}
+
+// Description is the reason for the plan
+func (r *PaymentPlanResolver) Description() string {
+	return r.rollup.Input.Description
+}
+
+// Total is the sum of the installments
+func (r *PaymentPlanResolver) Total(args *struct{ Format amountFormat }) (string, error) {
+	total := int32(0)
+	for _, installment := range r.rollup.Input.Installments {
+		total += installment.Amount
+	}
+	return r.property.moneyFormat().formatAmount(total, args.Format)
+}
+
+// Paid is the sum of the payments since the plan was created
+func (r *PaymentPlanResolver) Paid(args *struct{ Format amountFormat }) (string, error) {
+	return r.property.moneyFormat().formatAmount(r.paid(), args.Format)
+}
+
+// paid returns the sum of the PAYMENT ledger records of the user since the plan was created, less reversed payments
+func (r *PaymentPlanResolver) paid() int32 {
+	r.property.rollupLedgers()
+
+	allVersions := true
+	ifaces := r.property.getRollups(&rollupArgs{id: &r.rollup.Input.UserId, maxVersion: r.maxVersion, allVersions: &allVersions}, ledgerRollupType)
+
+	paid := int32(0)
+	for _, iface := range ifaces {
+		record := iface.(*LedgerRollup)
+		if record.EventVersion <= r.rollup.Input.EventVersion {
+			continue
+		}
+		// a reversed payment was not paid
+		if record.Event == paymentLedgerEvent || (record.Event == reversalLedgerEvent && record.Amount < 0) {
+			paid += record.Amount
+		}
+	}
+	return paid
+}
+
+// Installments are the payments due
+func (r *PaymentPlanResolver) Installments() []*InstallmentResolver {
+	l := []*InstallmentResolver{}
+	money := r.property.moneyFormat()
+	remaining := r.paid()
+	for i := range r.rollup.Input.Installments {
+		installment := &r.rollup.Input.Installments[i]
+		remaining -= installment.Amount
+		l = append(l, &InstallmentResolver{installment: installment, paid: remaining >= 0, money: money})
+	}
+	return l
+}
+
+// Status is whether the user is keeping up with the plan
+func (r *PaymentPlanResolver) Status() PaymentPlanStatus {
+	if r.rollup.Canceled {
+		return canceledPaymentPlanStatus
+	}
+	if r.missedInstallment(r.dateBuilder.Today()) != nil {
+		return missedPaymentPlanStatus
+	}
+	for _, installment := range r.Installments() {
+		if !installment.paid {
+			return currentPaymentPlanStatus
+		}
+	}
+	return completedPaymentPlanStatus
+}
+
+// missedInstallment returns the first unpaid installment past its due date on the day, nil if none
+func (r *PaymentPlanResolver) missedInstallment(today *frdate.Date) *models.Installment {
+	for _, installment := range r.Installments() {
+		if !installment.paid && r.dateBuilder.MustNewDate(installment.installment.DueDate).Before(today) {
+			return installment.installment
+		}
+	}
+	return nil
+}
+
+// InstallmentResolver resolves an installment of a payment plan
+type InstallmentResolver struct {
+	installment *models.Installment
+	paid        bool
+	money       *moneyFormat
+}
+
+// DueDate is the last day to pay the installment
+func (r *InstallmentResolver) DueDate() string {
+	return r.installment.DueDate
+}
+
+// Amount is the amount due
+func (r *InstallmentResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.installment.Amount, args.Format)
+}
+
+// Paid is true if the installment has been paid
+func (r *InstallmentResolver) Paid() bool {
+	return r.paid
+}
@@ -0,0 +1,93 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/templates"
+)
+
+// paymentPlanNotice is the PaymentPlan template param group, the installment missed in a payment plan
+type paymentPlanNotice struct {
+	Description string
+	DueDate     string
+	Amount      string
+	Paid        string
+	Total       string
+}
+
+// paymentPlanNotice returns the PaymentPlan template param group for the first installment missed
+// when the notification was created
+func (r *PropertyResolver) paymentPlanNotice(paymentPlanID string, createDateTime string, maxVersion *int32) (*paymentPlanNotice, error) {
+	r.rollupPaymentPlans()
+	plans, err := r.resolvePaymentPlans(&paymentPlansArgs{PaymentPlanID: &paymentPlanID, MaxVersion: maxVersion})
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) != 1 {
+		return nil, errors.New("expected a payment plan")
+	}
+	plan := plans[0]
+
+	installment := plan.missedInstallment(plan.dateBuilder.MustNewLocalDate(createDateTime))
+	if installment == nil {
+		return nil, errors.New("expected a missed installment")
+	}
+
+	format := &struct{ Format amountFormat }{Format: localizedSymbol}
+	amount, _ := r.moneyFormat().formatAmount(installment.Amount, localizedSymbol)
+	paid, _ := plan.Paid(format)
+	total, _ := plan.Total(format)
+	return &paymentPlanNotice{
+		Description: plan.Description(),
+		DueDate:     installment.DueDate,
+		Amount:      amount,
+		Paid:        paid,
+		Total:       total,
+	}, nil
+}
+
+// sendPaymentPlanReminders notifies users, and copies the admins, when an installment of a payment plan
+// is missed, called by the daily cron, each missed installment is notified once
+func sendPaymentPlanReminders(ctx context.Context, property *PropertyResolver,
+	lastNotifications map[templates.TemplateName]map[string]*frdate.DateTime) *PropertyResolver {
+
+	plans, err := property.PaymentPlans(&paymentPlansArgs{})
+	if err != nil {
+		Logger.LogErrorf("DailyCron error accessing payment plans: %+v", err)
+		return property
+	}
+
+	for _, plan := range plans {
+		if plan.rollup.Canceled {
+			continue
+		}
+		installment := plan.missedInstallment(plan.dateBuilder.Today())
+		if installment == nil {
+			continue
+		}
+		userID := plan.rollup.Input.UserId
+
+		// already notified since the installment was missed
+		if dateTime, ok := lastNotifications[templates.PaymentPlanMissedNotification][userID]; ok &&
+			dateTime.ToDate().After(plan.dateBuilder.MustNewDate(installment.DueDate)) {
+			continue
+		}
+
+		Logger.LogDebugf("DailyCron commit missed installment for userId: %+v", userID)
+		paramGroup := templates.PaymentPlan
+		newNotificationInput := createNotificationRecord(notificationTargetMember, property, templates.PaymentPlanMissedNotification,
+			&userID, &paramGroup, &plan.rollup.Input.PaymentPlanId)
+		newNotificationInput.TemplateParamData[templates.Ledger] = userID
+
+		updated, err := commitCronChanges(ctx, property.PropertyID(), newNotificationInput)
+		if err != nil {
+			Logger.LogErrorf("DailyCron error commit missed installment: %+v", err)
+			continue
+		}
+		property = deliverNotification(ctx, updated, newNotificationInput.NotificationId)
+	}
+
+	return property
+}
@@ -0,0 +1,61 @@
+package frapi
+
+import (
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+// PaymentPlanRollup is the rollup record for payment plans, exported for memcache
+type PaymentPlanRollup struct {
+	Input *models.NewPaymentPlanInput
+
+	// rollup changes
+	Canceled       bool
+	UpdateDateTime string
+	EventVersion   int32
+}
+
+// GetEventVersion returns version of rollup item
+func (r *PaymentPlanRollup) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+func (r *PropertyResolver) rollupPaymentPlans() {
+
+	r.rollupMutexes[paymentPlanRollupType].Lock()
+	defer r.rollupMutexes[paymentPlanRollupType].Unlock()
+
+	if !r.rollupsExists(paymentPlanRollupType) {
+
+		for _, event := range r.property.Events {
+
+			switch planEvent := event.(type) {
+
+			case *models.NewPaymentPlanInput:
+
+				rollup := &PaymentPlanRollup{}
+				rollup.Input = planEvent
+				rollup.UpdateDateTime = planEvent.CreateDateTime
+				rollup.EventVersion = planEvent.EventVersion
+
+				r.addRollup(planEvent.PaymentPlanId, rollup, paymentPlanRollupType)
+
+			case *models.CancelPaymentPlanInput:
+
+				rollups := r.getRollups(&rollupArgs{id: &planEvent.PaymentPlanId}, paymentPlanRollupType)
+
+				// make a copy
+				rollup := *rollups[0].(*PaymentPlanRollup)
+
+				rollup.Canceled = true
+				rollup.UpdateDateTime = planEvent.CreateDateTime
+				rollup.EventVersion = planEvent.EventVersion
+
+				r.addRollup(planEvent.PaymentPlanId, &rollup, paymentPlanRollupType)
+			}
+		}
+		cacheError := r.cacheRollup(paymentPlanRollupType)
+		if cacheError != nil {
+			Logger.LogWarningf("cache write payment plan rollups error: %+v", cacheError)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package frapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/templates"
+)
+
+func createPaymentPlan(ctx context.Context, resolver *Resolver, property *PropertyResolver, userID string, installments []models.Installment) (*PropertyResolver, error) {
+	return resolver.CreatePaymentPlan(ctx, &struct {
+		PropertyID string
+		Input      *models.NewPaymentPlanInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewPaymentPlanInput{
+			ForVersion:   property.EventVersion(),
+			UserId:       userID,
+			Description:  "past due balance",
+			Installments: installments,
+		},
+	})
+}
+
+func TestPaymentPlan(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	defer func() { frdate.TestTimeOffsetDays = nil }()
+
+	userID := me.UserID()
+
+	t.Log("run the balance below the minimum")
+	property = createPayment(ctx, t, resolver, property, 100000, false, property.EventVersion())
+	property = createPayment(ctx, t, resolver, property, 100000, false, property.EventVersion())
+	constraints, err := property.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserID: &userID, UserType: MEMBER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if constraints.NewReservationAllowed() {
+		t.Fatal("expected new reservations to be blocked by the balance")
+	}
+
+	t.Log("invalid plans are rejected")
+	if _, err := createPaymentPlan(ctx, resolver, property, userID, []models.Installment{}); err == nil {
+		t.Fatal("expected an error for a plan without installments")
+	}
+	if _, err := createPaymentPlan(ctx, resolver, property, userID, []models.Installment{
+		{DueDate: today.AddDays(20).ToString(), Amount: 50000},
+		{DueDate: today.AddDays(10).ToString(), Amount: 50000},
+	}); err == nil {
+		t.Fatal("expected an error for installments out of order")
+	}
+	if _, err := createPaymentPlan(ctx, resolver, property, userID, []models.Installment{
+		{DueDate: today.AddDays(-1).ToString(), Amount: 50000},
+	}); err == nil {
+		t.Fatal("expected an error for an installment in the past")
+	}
+
+	t.Log("a current plan allows new reservations")
+	property, err = createPaymentPlan(ctx, resolver, property, userID, []models.Installment{
+		{DueDate: today.AddDays(10).ToString(), Amount: 50000},
+		{DueDate: today.AddDays(20).ToString(), Amount: 50000},
+		{DueDate: today.AddDays(30).ToString(), Amount: 50000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := createPaymentPlan(ctx, resolver, property, userID, []models.Installment{
+		{DueDate: today.AddDays(10).ToString(), Amount: 50000},
+	}); err == nil {
+		t.Fatal("expected an error for a second open plan")
+	}
+	plans, err := property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].Status() != currentPaymentPlanStatus {
+		t.Fatal("expected a current payment plan")
+	}
+	constraints, err = property.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserID: &userID, UserType: MEMBER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !constraints.NewReservationAllowed() {
+		t.Fatal("expected new reservations to be allowed with a current payment plan")
+	}
+
+	t.Log("a payment covers the first installment")
+	property = createPayment(ctx, t, resolver, property, 50000, true, property.EventVersion())
+	plans, _ = property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	installments := plans[0].Installments()
+	if !installments[0].Paid() || installments[1].Paid() {
+		t.Fatal("expected only the first installment to be paid")
+	}
+	if paid, _ := plans[0].Paid(&struct{ Format amountFormat }{Format: decimal}); paid != "500.00" {
+		t.Fatalf("expected 500.00 paid but got %v", paid)
+	}
+
+	missed := func(offset int) []*NotificationResolver {
+		frdate.TestTimeOffsetDays = &offset
+		if err := DailyCron(ctx); err != nil {
+			t.Fatal(err)
+		}
+		property = getUpdatedProperty(ctx, t, resolver)
+		notifications, _ := property.Notifications(&notificationArgs{})
+		sent := []*NotificationResolver{}
+		for _, notification := range notifications {
+			if notification.TemplateName() == string(templates.PaymentPlanMissedNotification) {
+				sent = append(sent, notification)
+			}
+		}
+		return sent
+	}
+
+	t.Log("no notification before an installment is missed")
+	if sent := missed(15); len(sent) != 0 {
+		t.Fatalf("expected no missed installment notification but got %+v", len(sent))
+	}
+
+	t.Log("the second installment is missed")
+	sent := missed(21)
+	if len(sent) != 1 {
+		t.Fatalf("expected a missed installment notification but got %+v", len(sent))
+	}
+	body, err := sent[0].Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "500.00 due on "+today.AddDays(20).ToString()) {
+		t.Fatalf("expected the installment in the body: %v", body)
+	}
+	if htmlBody, err := sent[0].HTMLBody(); err != nil || htmlBody == nil {
+		t.Fatalf("expected an html notification: %+v", err)
+	}
+	plans, _ = property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if plans[0].Status() != missedPaymentPlanStatus {
+		t.Fatalf("expected a missed payment plan but got %v", plans[0].Status())
+	}
+	constraints, err = property.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserID: &userID, UserType: MEMBER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if constraints.NewReservationAllowed() {
+		t.Fatal("expected new reservations to be blocked after a missed installment")
+	}
+
+	t.Log("a missed installment is only notified once")
+	if sent = missed(22); len(sent) != 1 {
+		t.Fatalf("expected no new notification but got %+v", len(sent))
+	}
+
+	t.Log("paying the rest completes the plan")
+	property = createPayment(ctx, t, resolver, property, 50000, true, property.EventVersion())
+	property = createPayment(ctx, t, resolver, property, 50000, true, property.EventVersion())
+	plans, _ = property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if plans[0].Status() != completedPaymentPlanStatus {
+		t.Fatalf("expected a completed payment plan but got %v", plans[0].Status())
+	}
+
+	t.Log("a new plan can be canceled")
+	property, err = createPaymentPlan(ctx, resolver, property, userID, []models.Installment{
+		{DueDate: today.AddDays(40).ToString(), Amount: 50000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plans, _ = property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 payment plans but got %v", len(plans))
+	}
+	property, err = resolver.CancelPaymentPlan(ctx, &struct {
+		PropertyID string
+		Input      *models.CancelPaymentPlanInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.CancelPaymentPlanInput{
+			ForVersion:    property.EventVersion(),
+			PaymentPlanId: plans[1].PaymentPlanID(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plans, _ = property.PaymentPlans(&paymentPlansArgs{UserID: &userID})
+	if plans[1].Status() != canceledPaymentPlanStatus {
+		t.Fatalf("expected a canceled payment plan but got %v", plans[1].Status())
+	}
+}
@@ -0,0 +1,72 @@
+package frapi
+
+import (
+	"fmt"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+// permission is an action that requires more than being a member of the property
+type permission string
+
+const (
+	manageUsersPermission          permission = "manage users"
+	manageSettingsPermission       permission = "update settings"
+	manageContentPermission        permission = "create content"
+	manageRestrictionsPermission   permission = "create restrictions"
+	manageLedgersPermission        permission = "update balances"
+	viewLedgersPermission          permission = "view all ledgers"
+	manageMembershipsPermission    permission = "update memberships for other users"
+	reserveForOthersPermission     permission = "make or cancel reservations for other users"
+	overrideConstraintsPermission  permission = "make or cancel reservations outside the reservation constraints"
+	exportPermission               permission = "export the property"
+	exportCSVPermission            permission = "export csv files"
+	deletePropertyPermission       permission = "delete the property"
+	viewAuditLogPermission         permission = "view the audit log"
+	viewHistoryPermission          permission = "view the property as of an earlier version"
+	viewAllNotificationsPermission permission = "view notifications sent to other users"
+)
+
+// rolePermissions maps each role to the permissions it grants, admins have all permissions
+var rolePermissions = map[models.UserRole][]permission{
+	models.TREASURER: {
+		manageLedgersPermission,
+		viewLedgersPermission,
+		manageMembershipsPermission,
+		exportCSVPermission,
+	},
+	models.SCHEDULER: {
+		reserveForOthersPermission,
+	},
+}
+
+// hasPermission returns true if the user is an admin or has a role that grants the permission
+func (r *UserResolver) hasPermission(p permission) bool {
+	if r.IsAdmin() {
+		return true
+	}
+	for _, role := range r.rollup.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorize is the central check used by the mutations, returns an error if the user lacks the permission
+func authorize(me *UserResolver, p permission) error {
+	if me == nil || !me.hasPermission(p) {
+		return fmt.Errorf("user does not have permission to %v", p)
+	}
+	return nil
+}
+
+// grantableRoles returns the roles the user is allowed to grant to other users
+func (r *UserResolver) grantableRoles() []models.UserRole {
+	if !r.hasPermission(manageUsersPermission) {
+		return []models.UserRole{}
+	}
+	return models.AllUserRoles
+}
@@ -0,0 +1,232 @@
+package frapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func createUserWithRoles(ctx context.Context, t *testing.T, resolver *Resolver, property *PropertyResolver, email string, nickname string, roles []models.UserRole) (*PropertyResolver, *UserResolver) {
+	property, err := resolver.CreateUser(ctx, &struct {
+		PropertyID string
+		Input      *models.NewUserInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewUserInput{
+			ForVersion: property.EventVersion(),
+			Email:      email,
+			Nickname:   nickname,
+			IsMember:   true,
+			Roles:      &roles,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := property.Users(byEmail(email))
+	if len(users) != 1 {
+		t.Fatalf("expected a user")
+	}
+	return property, users[0]
+}
+
+func TestGrantableRoles(t *testing.T) {
+	property, ctx, resolver, _, _ := initAndCreateTestProperty(context.Background(), t)
+
+	constraints, err := property.UpdateUserConstraints(ctx, &UpdateUserConstraintsArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(constraints.GrantableRoles()) != len(models.AllUserRoles) {
+		t.Fatalf("expected admin to be able to grant all roles but got %+v", constraints.GrantableRoles())
+	}
+
+	t.Log("roles cannot be listed twice")
+	roles := []models.UserRole{models.TREASURER, models.TREASURER}
+	_, err = resolver.CreateUser(ctx, &struct {
+		PropertyID string
+		Input      *models.NewUserInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewUserInput{
+			ForVersion: property.EventVersion(),
+			Email:      "twice@test.com",
+			Nickname:   "twice",
+			IsMember:   true,
+			Roles:      &roles,
+		},
+	})
+	if err == nil {
+		t.Fatal("expected duplicate roles to fail")
+	}
+
+	t.Log("a treasurer cannot grant roles")
+	treasurerEmail := "treasurer@test.com"
+	property, treasurer := createUserWithRoles(ctx, t, resolver, property, treasurerEmail, "treasurer", []models.UserRole{models.TREASURER})
+	if len(treasurer.Roles()) != 1 || treasurer.Roles()[0] != models.TREASURER {
+		t.Fatalf("expected treasurer role but got %+v", treasurer.Roles())
+	}
+
+	testUserEmail = treasurerEmail
+	defer func() { testUserEmail = defaultEmail }()
+	property = getUpdatedProperty(ctx, t, resolver)
+
+	constraints, err = property.UpdateUserConstraints(ctx, &UpdateUserConstraintsArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(constraints.GrantableRoles()) != 0 {
+		t.Fatalf("expected treasurer to not grant roles but got %+v", constraints.GrantableRoles())
+	}
+}
+
+func TestTreasurerPermissions(t *testing.T) {
+	property, ctx, resolver, _, _ := initAndCreateTestProperty(context.Background(), t)
+
+	treasurerEmail := "treasurer@test.com"
+	property, _ = createUserWithRoles(ctx, t, resolver, property, treasurerEmail, "treasurer", []models.UserRole{models.TREASURER})
+
+	testUserEmail = treasurerEmail
+	defer func() { testUserEmail = defaultEmail }()
+
+	t.Log("a treasurer can update balances")
+	property = createPayment(ctx, t, resolver, property, 200, true, property.EventVersion())
+
+	t.Log("a treasurer can view all ledgers")
+	if _, err := property.Ledgers(&ledgersArgs{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("a treasurer cannot update settings")
+	settings, _ := property.Settings(&settingsArgs{})
+	input := &models.UpdateSettingsInput{
+		ForVersion:   property.EventVersion(),
+		PropertyName: settings.PropertyName(),
+	}
+	_, err := resolver.UpdateSettings(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateSettingsInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      input,
+	})
+	if err == nil {
+		t.Fatal("expected treasurer update settings to fail")
+	}
+}
+
+func TestSchedulerPermissions(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	schedulerEmail := "scheduler@test.com"
+	property, _ = createUserWithRoles(ctx, t, resolver, property, schedulerEmail, "scheduler", []models.UserRole{models.SCHEDULER})
+
+	testUserEmail = schedulerEmail
+	defer func() { testUserEmail = defaultEmail }()
+
+	t.Log("a scheduler can book on behalf of a member")
+	property, err := resolver.CreateReservation(ctx, &struct {
+		PropertyID string
+		Input      *models.NewReservationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewReservationInput{
+			ForVersion:        property.EventVersion(),
+			ReservedForUserId: me.UserID(),
+			StartDate:         today.AddDays(1).ToString(),
+			EndDate:           today.AddDays(2).ToString(),
+			Member:            true,
+			AdminRequest:      true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservations, _ := property.Reservations(&reservationsArgs{})
+	if len(reservations) != 1 {
+		t.Fatalf("expected 1 reservation but got %+v", len(reservations))
+	}
+
+	t.Log("a scheduler cannot update balances")
+	_, err = resolver.UpdateBalance(ctx, &struct {
+		PropertyID string
+		Input      *models.UpdateBalanceInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.UpdateBalanceInput{
+			ForVersion:      property.EventVersion(),
+			UpdateForUserId: me.UserID(),
+			Amount:          200,
+			Description:     "test payment",
+			Increase:        true,
+		},
+	})
+	if err == nil {
+		t.Fatal("expected scheduler update balance to fail")
+	}
+
+	t.Log("a scheduler cannot view all ledgers")
+	if _, err := property.Ledgers(&ledgersArgs{}); err == nil {
+		t.Fatal("expected scheduler view all ledgers to fail")
+	}
+
+	bookForMe := func(startDays int) error {
+		updated, err := resolver.CreateReservation(ctx, &struct {
+			PropertyID string
+			Input      *models.NewReservationInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input: &models.NewReservationInput{
+				ForVersion:        property.EventVersion(),
+				ReservedForUserId: me.UserID(),
+				StartDate:         today.AddDays(startDays).ToString(),
+				EndDate:           today.AddDays(startDays + 1).ToString(),
+				Member:            true,
+				AdminRequest:      true,
+			},
+		})
+		if err == nil {
+			property = updated
+		}
+		return err
+	}
+
+	t.Log("a scheduler cannot book for a member below the min balance")
+	testUserEmail = defaultEmail
+	settingsInput := currentSettingsInput(property)
+	minBalance := settingsInput.MinBalance
+	settingsInput.MinBalance = 0
+	if property, err = updateSettings(ctx, resolver, property, settingsInput); err != nil {
+		t.Fatal(err)
+	}
+	testUserEmail = schedulerEmail
+	if err := bookForMe(3); err == nil {
+		t.Fatal("expected a scheduler booking below the min balance to fail")
+	}
+
+	t.Log("a scheduler cannot book for a member in a blackout")
+	testUserEmail = defaultEmail
+	settingsInput = currentSettingsInput(property)
+	settingsInput.MinBalance = minBalance
+	if property, err = updateSettings(ctx, resolver, property, settingsInput); err != nil {
+		t.Fatal(err)
+	}
+	property, _, _ = createBlackoutRestriction(ctx, t, resolver, property, today)
+	testUserEmail = schedulerEmail
+	if err := bookForMe(3); err == nil {
+		t.Fatal("expected a scheduler booking in a blackout to fail")
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	if _, err := property.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserType: ADMIN}); err == nil {
+		t.Fatal("expected scheduler admin constraints to fail")
+	}
+
+	t.Log("an admin can still book in a blackout")
+	testUserEmail = defaultEmail
+	if err := bookForMe(3); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package frapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPropertyAsOfVersion(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	userID := me.UserID()
+
+	t.Log("create a reservation and remember the version")
+	property, _ = createReservation(ctx, t, resolver, property, userID, today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	asOfVersion := property.EventVersion()
+	_, oldRecords := checkLedger(ctx, t, property, userID, 2, reservationLedgerEvent, -4000, -4000)
+
+	t.Log("make more changes")
+	property, _ = createReservation(ctx, t, resolver, property, userID, today.AddDays(4).ToString(), today.AddDays(5).ToString())
+	property = createPayment(ctx, t, resolver, property, 10000, true, property.EventVersion())
+
+	t.Log("check the property as of the earlier version")
+	historical, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfVersion: &asOfVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if historical.EventVersion() != asOfVersion {
+		t.Fatalf("expected version %+v but got %+v", asOfVersion, historical.EventVersion())
+	}
+
+	reservations, err := historical.Reservations(&reservationsArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reservations) != 1 {
+		t.Fatalf("expected 1 reservation as of version %+v but got %+v", asOfVersion, len(reservations))
+	}
+
+	checkLedger(ctx, t, historical, userID, len(oldRecords), reservationLedgerEvent, -4000, -4000)
+
+	t.Log("check the current property is not affected")
+	current, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reservations, _ = current.Reservations(&reservationsArgs{})
+	if len(reservations) != 2 {
+		t.Fatalf("expected 2 current reservations but got %+v", len(reservations))
+	}
+
+	t.Log("check invalid versions")
+	tooLate := property.EventVersion() + 1
+	if _, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfVersion: &tooLate}); err == nil {
+		t.Fatal("expected a version after the current version to fail")
+	}
+	tooEarly := time.Now().UTC().AddDate(-1, 0, 0).Format(time.RFC3339)
+	if _, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfDateTime: &tooEarly}); err == nil {
+		t.Fatal("expected a date time before the property existed to fail")
+	}
+
+	t.Log("check as of date time")
+	later := time.Now().UTC().AddDate(1, 0, 0).Format(time.RFC3339)
+	historical, err = resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfDateTime: &later})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if historical.EventVersion() != property.EventVersion() {
+		t.Fatalf("expected the latest version %+v but got %+v", property.EventVersion(), historical.EventVersion())
+	}
+
+	t.Log("check a member cannot view history")
+	memberEmail := "member@test.com"
+	createUser(ctx, t, resolver, property, memberEmail, "member")
+	testUserEmail = memberEmail
+	defer func() { testUserEmail = defaultEmail }()
+	if _, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfVersion: &asOfVersion}); err == nil {
+		t.Fatal("expected member history to fail")
+	}
+}
@@ -30,8 +30,8 @@ func (r *Resolver) DeleteProperty(ctx context.Context, args *deletePropertyArgs)
 	}
 
 	// check the input values
-	if !me.IsAdmin() {
-		return false, errors.New("only admins can delete the property")
+	if err := authorize(me, deletePropertyPermission); err != nil {
+		return false, err
 	}
 
 	return r.internalDeleteProperty(ctx, args.PropertyID)
@@ -85,6 +85,10 @@ func (r *Resolver) CreateProperty(ctx context.Context, args *struct{ Input *mode
 		return nil, err
 	}
 
+	if err := validateMoneyFormat(args.Input.Currency, args.Input.Locale); err != nil {
+		return nil, err
+	}
+
 	// create the first events
 	nextPropertyTransactionKey, err := PersistedPropertyList.GetNextVersion(ctx)
 	if err != nil {
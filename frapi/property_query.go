@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bjorge/friendlyreservations/models"
 	"github.com/bjorge/friendlyreservations/platform"
@@ -26,6 +28,7 @@ const (
 	restrictionRollupType      rollupType = "RESTRICTION_ROLLUP"
 	contentsRollupType         rollupType = "CONTENTS_ROLLUP"
 	membershipStatusRollupType rollupType = "MEMBERSHIP_STATUS_ROLLUP"
+	paymentPlanRollupType      rollupType = "PAYMENT_PLAN_ROLLUP"
 )
 
 var rollupTypes = [...]rollupType{
@@ -36,7 +39,8 @@ var rollupTypes = [...]rollupType{
 	settingsRollupType,
 	restrictionRollupType,
 	contentsRollupType,
-	membershipStatusRollupType}
+	membershipStatusRollupType,
+	paymentPlanRollupType}
 
 // Property is the basic structure holding information for rollups
 // The public fields can be cached (for current latest event version)
@@ -66,6 +70,11 @@ type PropertyResolver struct {
 	rollupsMutex  sync.RWMutex
 	rollupMutexes map[rollupType]*sync.Mutex
 	ctx           context.Context
+	// set when the property is pinned to a historical version, see asOfVersion
+	historical bool
+	me         *UserResolver
+	// the users erased in the full event list, set for a historical property since its events stop before any later erase
+	erasedUserIDs map[string]bool
 }
 
 // Properties is called to retrieve all the properties for which the caller is a member or admin
@@ -100,18 +109,163 @@ func (r *Resolver) Properties(ctx context.Context) ([]*PropertyResolver, error)
 	return l, nil
 }
 
-// Property returns the information for a single property
-func (r *Resolver) Property(ctx context.Context, args *struct {
-	ID string
-}) (*PropertyResolver, error) {
+type propertyArgs struct {
+	ID           string
+	AsOfVersion  *int32
+	AsOfDateTime *string
+}
+
+// Property returns the information for a single property,
+// optionally pinned to the version as of an event version or date time
+func (r *Resolver) Property(ctx context.Context, args *propertyArgs) (*PropertyResolver, error) {
 
-	propertyResolver, _, err := currentProperty(ctx, args.ID)
+	propertyResolver, me, err := currentProperty(ctx, args.ID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return propertyResolver, nil
+	if args.AsOfVersion == nil && args.AsOfDateTime == nil {
+		return propertyResolver, nil
+	}
+
+	if args.AsOfVersion != nil && args.AsOfDateTime != nil {
+		return nil, errors.New("only one of asOfVersion or asOfDateTime is allowed")
+	}
+
+	if err := authorize(me, viewHistoryPermission); err != nil {
+		return nil, err
+	}
+
+	asOfVersion := args.AsOfVersion
+	if args.AsOfDateTime != nil {
+		asOfVersion, err = propertyResolver.versionAsOfDateTime(*args.AsOfDateTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return propertyResolver.historicalProperty(*asOfVersion, me)
+}
+
+// historicalProperty returns a property resolver containing only the events up to and including asOfVersion,
+// so all the nested resolvers are rolled up as of that version
+func (r *PropertyResolver) historicalProperty(asOfVersion int32, me *UserResolver) (*PropertyResolver, error) {
+	firstVersion := int32(r.property.Events[0].GetEventVersion())
+	if asOfVersion < firstVersion || asOfVersion > r.EventVersion() {
+		return nil, fmt.Errorf("asOfVersion (%+v) must be between %+v and %+v", asOfVersion, firstVersion, r.EventVersion())
+	}
+
+	property := &Property{
+		PropertyID:     r.property.PropertyID,
+		CreateDateTime: r.property.CreateDateTime,
+		EmailMap:       r.property.EmailMap,
+		Rollups:        make(map[rollupType]map[string][]versionedRollup),
+	}
+	for _, event := range r.property.Events {
+		if int32(event.GetEventVersion()) > asOfVersion {
+			break
+		}
+		property.Events = append(property.Events, event)
+	}
+
+	historical := &PropertyResolver{
+		property:      property,
+		email:         r.email,
+		rollupMutexes: make(map[rollupType]*sync.Mutex),
+		ctx:           r.ctx,
+		historical:    true,
+		me:            me,
+		erasedUserIDs: erasedUserIDsOf(r.property.Events),
+	}
+	for _, rollup := range rollupTypes {
+		historical.rollupMutexes[rollup] = &sync.Mutex{}
+	}
+
+	return historical, nil
+}
+
+// versionAsOfDateTime returns the version of the last event at or before the date time
+func (r *PropertyResolver) versionAsOfDateTime(asOfDateTime string) (*int32, error) {
+	asOf, err := time.Parse(time.RFC3339, asOfDateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var version *int32
+	for _, event := range r.property.Events {
+		dateTime := eventDateTime(event)
+		if dateTime == "" {
+			continue
+		}
+		eventTime, err := time.Parse(time.RFC3339, dateTime)
+		if err != nil {
+			return nil, err
+		}
+		if eventTime.After(asOf) {
+			break
+		}
+		eventVersion := int32(event.GetEventVersion())
+		version = &eventVersion
+	}
+
+	if version == nil {
+		return nil, fmt.Errorf("property did not exist at %+v", asOfDateTime)
+	}
+	return version, nil
+}
+
+// eventDateTime returns the create or update time of an event, or empty for internal events
+func eventDateTime(event interface{}) string {
+	switch event := event.(type) {
+	case *models.NewPropertyInput:
+		return event.CreateDateTime
+	case *models.UpdateSettingsInput:
+		return event.CreateDateTime
+	case *models.NewUserInput:
+		return event.CreateDateTime
+	case *models.UpdateUserInput:
+		return event.UpdateDateTime
+	case *models.UpdateSystemUserInput:
+		return event.UpdateDateTime
+	case *models.AcceptInvitationInput:
+		return event.UpdateDateTime
+	case *models.EraseUserInput:
+		return event.CreateDateTime
+	case *models.NewReservationInput:
+		return event.CreateDateTime
+	case *models.CancelReservationInput:
+		return event.CreateDateTime
+	case *models.NewRestrictionInput:
+		return event.CreateDateTime
+	case *models.UpdateMembershipStatusInput:
+		return event.CreateDateTime
+	case *models.UpdateBalanceInput:
+		return event.CreateDateTime
+	case *models.NewNotificationInput:
+		return event.CreateDateTime
+	case *models.NotificationDeliveryInput:
+		return event.CreateDateTime
+	case *models.NotificationCommentInput:
+		return event.CreateDateTime
+	case *models.NotificationReadInput:
+		return event.CreateDateTime
+	case *models.AllNotificationsReadInput:
+		return event.CreateDateTime
+	case *models.UpdateNotificationPreferenceInput:
+		return event.CreateDateTime
+	case *models.NewContentInput:
+		return event.CreateDateTime
+	case *models.NewPaymentPlanInput:
+		return event.CreateDateTime
+	case *models.CancelPaymentPlanInput:
+		return event.CreateDateTime
+	case *models.BankImportMappingInput:
+		return event.CreateDateTime
+	case *models.ReverseLedgerEntryInput:
+		return event.CreateDateTime
+	}
+	return ""
 }
 
 // PropertyID is the unique id of this property
@@ -133,6 +287,11 @@ func (r *PropertyResolver) CreateDateTime() string {
 
 // Me is the information for the logged in user
 func (r *PropertyResolver) Me() (*UserResolver, error) {
+	if r.me != nil {
+		// a historical property is accessed by the current user, who may not have existed back then
+		return r.me, nil
+	}
+
 	users := r.Users(&usersArgs{Email: &r.email})
 
 	if len(users) != 1 {
@@ -296,6 +455,11 @@ func gobFromRollups(rollups map[string][]versionedRollup) ([]byte, error) {
 
 func (r *PropertyResolver) cacheRollup(resolverType rollupType) error {
 	// called at the end of a rollup
+	if r.historical {
+		// only the latest version is cached
+		return nil
+	}
+
 	r.rollupsMutex.Lock()
 	defer r.rollupsMutex.Unlock()
 
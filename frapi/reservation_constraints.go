@@ -33,6 +33,8 @@ type NewReservationConstraints {
 	nonMemberNameMax: Int!
 	nonMemberInfoMin: Int!
 	nonMemberInfoMax: Int!
+	# the rules of the purchased membership tiers, checked when the reservation is created
+	tierPeriods: [MembershipTierPeriod!]!
 }
 
 `
@@ -81,6 +83,8 @@ type NewReservationConstraints struct {
 	newReservationAllowed bool
 	checkinDisabled       []*CalendarDisabledRange
 	checkoutDisabled      []*CalendarDisabledRange
+	tierPeriods           []*tierPeriod
+	today                 *frdate.Date
 }
 
 // NewReservationConstraintsArgs are the arguments for retrieving the constraints
@@ -112,8 +116,14 @@ func (r *PropertyResolver) CancelReservationConstraints(ctx context.Context, arg
 		return nil, err
 	}
 
-	if args.UserType == ADMIN && !me.IsAdmin() {
-		return nil, fmt.Errorf("admin request for non-admin")
+	if args.UserType == ADMIN {
+		if err := authorize(me, overrideConstraintsPermission); err != nil {
+			return nil, err
+		}
+	} else if args.UserID != nil && *args.UserID != me.UserID() {
+		if err := authorize(me, reserveForOthersPermission); err != nil {
+			return nil, err
+		}
 	}
 
 	if args.UserType == NONMEMBER {
@@ -185,6 +195,21 @@ func (r *PropertyResolver) NewReservationConstraints(ctx context.Context, args *
 		return nil, fmt.Errorf("non-admin user type requires user id")
 	}
 
+	me, err := r.Me()
+	if err != nil {
+		return nil, err
+	}
+
+	if args.UserType == ADMIN {
+		if err := authorize(me, overrideConstraintsPermission); err != nil {
+			return nil, err
+		}
+	} else if *args.UserID != me.UserID() {
+		if err := authorize(me, reserveForOthersPermission); err != nil {
+			return nil, err
+		}
+	}
+
 	if args.UserID != nil {
 		users := r.Users(&usersArgs{})
 		found := false
@@ -222,14 +247,17 @@ func (r *PropertyResolver) NewReservationConstraints(ctx context.Context, args *
 	if args.UserType != ADMIN {
 		last := int32(1)
 
-		userRecords, _ := r.Ledgers(&ledgersArgs{UserID: args.UserID, Last: &last})
+		// the balance of the user reserved for applies, even if the caller cannot view the ledger
+		r.rollupLedgers()
+		userRecords, _ := r.resolveLedgers(&ledgersArgs{UserID: args.UserID, Last: &last})
 
 		ledgers := userRecords[0].Records()
 
 		balance, _ := strconv.Atoi(ledgers[0].balanceInternal().NoDecimal())
 		minBalance, _ := strconv.Atoi(settings.minBalanceInternal().NoDecimal())
 
-		if balance < minBalance {
+		// a user keeping up with a payment plan can book with a low balance
+		if balance < minBalance && !r.hasCurrentPaymentPlan(*args.UserID) {
 			newReservationConstraints.newReservationAllowed = false
 			return newReservationConstraints, nil
 		}
@@ -346,6 +374,12 @@ func (r *PropertyResolver) NewReservationConstraints(ctx context.Context, args *
 				newReservationConstraints.newReservationAllowed = false
 				return newReservationConstraints, nil
 			}
+
+			newReservationConstraints.tierPeriods, err = r.purchasedTierPeriods(*args.UserID)
+			if err != nil {
+				return nil, err
+			}
+			newReservationConstraints.today = dateBuilder.Today()
 			inConstraint := &CalendarDisabledRange{BeforeDate: in}
 			outConstraint := &CalendarDisabledRange{AfterDate: grace}
 			newReservationConstraints.checkinDisabled = append(newReservationConstraints.checkinDisabled, inConstraint)
@@ -375,6 +409,15 @@ func (r *NewReservationConstraints) CheckoutDisabled() []*CalendarDisabledRange
 	return r.checkoutDisabled
 }
 
+// TierPeriods returns the purchased membership tiers of the user
+func (r *NewReservationConstraints) TierPeriods() []*MembershipTierPeriodResolver {
+	l := []*MembershipTierPeriodResolver{}
+	for _, period := range r.tierPeriods {
+		l = append(l, &MembershipTierPeriodResolver{period})
+	}
+	return l
+}
+
 // NonMemberNameMin is the minimum length of a non member name
 func (r *NewReservationConstraints) NonMemberNameMin() int32 { return 3 }
 
@@ -31,8 +31,10 @@ func (r *Resolver) CancelReservation(ctx context.Context, args *struct {
 		return nil, err
 	}
 
-	if adminRequest && !me.IsAdmin() {
-		return nil, errors.New("cancel request for admin but user is not an admin")
+	if adminRequest {
+		if err := authorize(me, reserveForOthersPermission); err != nil {
+			return nil, err
+		}
 	}
 
 	// get the reservation
@@ -41,12 +43,19 @@ func (r *Resolver) CancelReservation(ctx context.Context, args *struct {
 		return nil, fmt.Errorf("reservation not found for id: %+v", args.ReservationID)
 	}
 
-	// get the cancel constraints
+	// get the cancel constraints, only admins cancel outside the constraints, other users
+	// cancelling on behalf of a member are held to the constraints of the member
 	var cancelConstraints *CancelReservationConstraints
-	if adminRequest {
+	if adminRequest && me.hasPermission(overrideConstraintsPermission) {
 		cancelConstraints, err = property.CancelReservationConstraints(ctx, &CancelReservationConstraintsArgs{UserType: ADMIN})
 	} else {
 		userID := me.UserID()
+		if adminRequest {
+			if reservations[0].ReservedFor() == nil {
+				return nil, errors.New("cancel reservation is not allowed")
+			}
+			userID = reservations[0].ReservedFor().UserID()
+		}
 		cancelConstraints, err = property.CancelReservationConstraints(ctx, &CancelReservationConstraintsArgs{UserType: MEMBER, UserID: &userID})
 	}
 	if err != nil {
@@ -90,8 +99,9 @@ func (r *Resolver) CancelReservation(ctx context.Context, args *struct {
 
 	if err == nil {
 		// send the email notification
-		notifications, _ := property.Notifications(&notificationArgs{notificationID: &newNotificationInput.NotificationId})
-		sendEmail(ctx, property, notifications[0])
+		property = deliverNotification(ctx, property, newNotificationInput.NotificationId)
+		// and a text or push message if the stay starts soon
+		sendUrgentNotifications(ctx, property, newNotificationInput.NotificationId)
 	}
 
 	return property, err
@@ -118,8 +128,10 @@ func (r *Resolver) CreateReservation(ctx context.Context, args *struct {
 		return nil, err
 	}
 
-	if args.Input.AdminRequest && !me.IsAdmin() {
-		return nil, errors.New("admin request for non-admin")
+	if args.Input.AdminRequest {
+		if err := authorize(me, reserveForOthersPermission); err != nil {
+			return nil, err
+		}
 	}
 
 	// check the input values
@@ -154,8 +166,10 @@ func (r *Resolver) CreateReservation(ctx context.Context, args *struct {
 			checkOut.ToString(), checkIn.ToString())
 	}
 
+	// only admins book outside the constraints, other users booking on behalf
+	// of a member are held to the constraints of the member
 	var constraints *NewReservationConstraints
-	if args.Input.AdminRequest {
+	if args.Input.AdminRequest && me.hasPermission(overrideConstraintsPermission) {
 		constraints, err = propertyResolver.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserType: ADMIN})
 	} else if args.Input.Member {
 		constraints, err = propertyResolver.NewReservationConstraints(ctx, &NewReservationConstraintsArgs{UserID: &args.Input.ReservedForUserId, UserType: MEMBER})
@@ -214,11 +228,21 @@ func (r *Resolver) CreateReservation(ctx context.Context, args *struct {
 	args.Input.ReservationId = utilities.NewGUID()
 	args.Input.AuthorUserId = me.UserID()
 
+	// a membership tier may have its own member rate
+	tierPeriods, err := propertyResolver.purchasedTierPeriods(args.Input.ReservedForUserId)
+	if err != nil {
+		return nil, err
+	}
+
 	args.Input.Rate = []models.DailyRate{}
 	days, _ := frdate.DaysList(checkIn, checkOut, false)
 	for _, day := range days {
 		if args.Input.Member {
-			args.Input.Rate = append(args.Input.Rate, models.DailyRate{Amount: settings.memberRateInternal(), Date: day.ToString()})
+			rate := settings.memberRateInternal()
+			if tier := tierForNight(tierPeriods, day); tier != nil && tier.NightlyRate != nil {
+				rate = *tier.NightlyRate
+			}
+			args.Input.Rate = append(args.Input.Rate, models.DailyRate{Amount: rate, Date: day.ToString()})
 		} else {
 			args.Input.Rate = append(args.Input.Rate, models.DailyRate{Amount: settings.nonMemberRateInternal(), Date: day.ToString()})
 		}
@@ -235,11 +259,7 @@ func (r *Resolver) CreateReservation(ctx context.Context, args *struct {
 		Logger.LogErrorf("CreateReservation: error commiting: %+v", err)
 	} else {
 		// send the email notification
-		notifications, _ := propertyResolver.Notifications(&notificationArgs{notificationID: &newNotificationInput.NotificationId})
-		err := sendEmail(ctx, propertyResolver, notifications[0])
-		if err != nil {
-			Logger.LogErrorf("CreateReservation: error sending email: %+v", err)
-		}
+		propertyResolver = deliverNotification(ctx, propertyResolver, newNotificationInput.NotificationId)
 	}
 
 	return propertyResolver, err
@@ -252,6 +272,11 @@ func (r *PropertyResolver) newReservationDisabled(ctx context.Context, checkIn *
 		return true, nil
 	}
 
+	if err := tierRulesBroken(constraints.tierPeriods, checkIn, checkOut, constraints.today); err != nil {
+		Logger.LogDebugf("newReservationDisabled: %+v", err)
+		return true, nil
+	}
+
 	inRanges := constraints.CheckinDisabled()
 	outRanges := constraints.CheckoutDisabled()
 
@@ -3,6 +3,7 @@ package frapi
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/bjorge/friendlyreservations/frdate"
 	"github.com/bjorge/friendlyreservations/models"
@@ -33,8 +34,8 @@ func (r *Resolver) CreateRestriction(ctx context.Context, args *struct {
 		return nil, errors.New("missing restriction input arg")
 	}
 
-	if !me.IsAdmin() {
-		return nil, errors.New("only an admin can create a restriction")
+	if err := authorize(me, manageRestrictionsPermission); err != nil {
+		return nil, err
 	}
 
 	if args.Input.Description == "" {
@@ -115,6 +116,21 @@ func (r *Resolver) CreateRestriction(ctx context.Context, args *struct {
 				}
 			}
 		}
+
+		if err := validateMembershipTiers(args.Input.Membership.Tiers); err != nil {
+			return nil, err
+		}
+
+		if proration := args.Input.Membership.Proration; proration != nil {
+			switch proration.Period {
+			case models.DAILY, models.MONTHLY:
+			default:
+				return nil, fmt.Errorf("unknown proration period %+v", proration.Period)
+			}
+			if proration.MinimumAmount < 0 {
+				return nil, errors.New("proration minimum amount cannot be negative")
+			}
+		}
 	}
 
 	// update the request with more information
@@ -125,3 +141,60 @@ func (r *Resolver) CreateRestriction(ctx context.Context, args *struct {
 	// persist the event
 	return commitChanges(ctx, args.PropertyID, property.EventVersion(), args.Input)
 }
+
+// validateMembershipTiers checks the tiers of a membership and trims the tier names
+func validateMembershipTiers(tiers *[]models.MembershipTier) error {
+	if tiers == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for i := range *tiers {
+		tier := &(*tiers)[i]
+		name, err := trim(tier.Name)
+		if err != nil {
+			return errors.New("membership tier name is empty")
+		}
+		if names[*name] {
+			return fmt.Errorf("membership tier %v is listed twice", *name)
+		}
+		names[*name] = true
+		tier.Name = *name
+
+		if tier.Amount < 0 {
+			return fmt.Errorf("membership tier %v amount cannot be negative", tier.Name)
+		}
+		if tier.NightlyRate != nil && *tier.NightlyRate < 0 {
+			return fmt.Errorf("membership tier %v nightly rate cannot be negative", tier.Name)
+		}
+		if tier.BookingWindowDays != nil && *tier.BookingWindowDays < 1 {
+			return fmt.Errorf("membership tier %v booking window must be at least 1 day", tier.Name)
+		}
+		if tier.MaxNights != nil && *tier.MaxNights < 1 {
+			return fmt.Errorf("membership tier %v max nights must be at least 1", tier.Name)
+		}
+		if tier.Nights != nil {
+			if len(*tier.Nights) == 0 {
+				return fmt.Errorf("membership tier %v has no nights", tier.Name)
+			}
+			nights := make(map[models.Weekday]bool)
+			for _, night := range *tier.Nights {
+				if nights[night] || weekdayIndex(night) < 0 {
+					return fmt.Errorf("membership tier %v night %v is not valid", tier.Name, night)
+				}
+				nights[night] = true
+			}
+		}
+	}
+	return nil
+}
+
+// weekdayIndex returns the time.Weekday of the weekday, -1 if not valid
+func weekdayIndex(weekday models.Weekday) int {
+	for i, day := range models.AllWeekdays {
+		if day == weekday {
+			return i
+		}
+	}
+	return -1
+}
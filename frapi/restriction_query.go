@@ -22,6 +22,41 @@ type MembershipRestriction {
 	outDate(format: String = "Jan 2, 2006"): String!
 	gracePeriodOutDate(format: String = "Jan 2, 2006"): String!
 	amount(format: AmountFormat = DECIMAL): String!
+	tiers: [MembershipTier!]!
+	proration: MembershipProration
+}
+
+enum ProrationPeriod {
+	# the amount is for the nights left in the membership period
+	DAILY
+	# the amount is for the months left in the membership period, including the month of the purchase
+	MONTHLY
+}
+
+# See MembershipProrationInput.
+type MembershipProration {
+	period: ProrationPeriod!
+	minimumAmount(format: AmountFormat = DECIMAL): String!
+}
+
+enum Weekday {
+	SUNDAY
+	MONDAY
+	TUESDAY
+	WEDNESDAY
+	THURSDAY
+	FRIDAY
+	SATURDAY
+}
+
+# See MembershipTierInput.
+type MembershipTier {
+	name: String!
+	amount(format: AmountFormat = DECIMAL): String!
+	nightlyRate(format: AmountFormat = DECIMAL): String
+	bookingWindowDays: Int
+	maxNights: Int
+	nights: [Weekday!]!
 }
 
 # See FirstDayRestrictionInput, LastDayRestrictionInput, BlackoutRestrictionInput.
@@ -72,6 +107,7 @@ func (r *PropertyResolver) Restrictions(args *restrictionsArgs) ([]*RestrictionR
 type RestrictionResolver struct {
 	restriction interface{}
 	dateBuilder *frdate.DateBuilder
+	money       *moneyFormat
 }
 
 // ToBlackoutRestriction check and convert restriction to blackout restriction
@@ -87,7 +123,7 @@ func (r *RestrictionResolver) ToBlackoutRestriction() (*BlackoutRestrictionResol
 func (r *RestrictionResolver) ToMembershipRestriction() (*MembershipRestrictionResolver, bool) {
 	obj, ok := r.restriction.(*models.MembershipRestriction)
 	if ok {
-		return &MembershipRestrictionResolver{obj, r.dateBuilder}, true
+		return &MembershipRestrictionResolver{obj, r.dateBuilder, r.money}, true
 	}
 	return nil, ok
 }
@@ -108,7 +144,7 @@ func (r *RestrictionRecordResolver) Restriction() *RestrictionResolver {
 	} else {
 		iface = r.rollup.Input.Membership
 	}
-	return &RestrictionResolver{iface, r.dateBuilder}
+	return &RestrictionResolver{iface, r.dateBuilder, r.property.moneyFormat()}
 }
 
 // RestrictionID is the unique restriction id
@@ -156,6 +192,7 @@ func (r *BlackoutRestrictionResolver) StartDate() string {
 type MembershipRestrictionResolver struct {
 	restriction *models.MembershipRestriction
 	dateBuilder *frdate.DateBuilder
+	money       *moneyFormat
 }
 
 // PrePayStartDate is the first date in which a membership can be purchased
@@ -196,9 +233,139 @@ func (r *MembershipRestrictionResolver) internalGracePeriodOutDate() string {
 
 // Amount is the price of the membership
 func (r *MembershipRestrictionResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.restriction.Amount, args.Format)
+	return r.money.formatAmount(r.restriction.Amount, args.Format)
 }
 
 func (r *MembershipRestrictionResolver) internalAmount() int32 {
 	return r.restriction.Amount
 }
+
+// Tiers are the kinds of membership sold for the period
+func (r *MembershipRestrictionResolver) Tiers() []*MembershipTierResolver {
+	l := []*MembershipTierResolver{}
+	if r.restriction.Tiers == nil {
+		return l
+	}
+	for i := range *r.restriction.Tiers {
+		l = append(l, &MembershipTierResolver{&(*r.restriction.Tiers)[i], r.money})
+	}
+	return l
+}
+
+// tier returns the tier with the name, nil if not found
+func (r *MembershipRestrictionResolver) tier(name string) *models.MembershipTier {
+	if r.restriction.Tiers == nil {
+		return nil
+	}
+	for i, tier := range *r.restriction.Tiers {
+		if tier.Name == name {
+			return &(*r.restriction.Tiers)[i]
+		}
+	}
+	return nil
+}
+
+// Proration is how a purchase after the start of the membership period is charged, nil if not pro-rated
+func (r *MembershipRestrictionResolver) Proration() *MembershipProrationResolver {
+	if r.restriction.Proration == nil {
+		return nil
+	}
+	return &MembershipProrationResolver{r.restriction.Proration, r.money}
+}
+
+// proratedAmount returns the amount charged for a membership, or a tier of it, purchased on the date
+func (r *MembershipRestrictionResolver) proratedAmount(amount int32, purchaseDate *frdate.Date) int32 {
+	proration := r.restriction.Proration
+	inDate := r.dateBuilder.MustNewDate(r.restriction.InDate)
+	if proration == nil || !purchaseDate.After(inDate) {
+		return amount
+	}
+	outDate := r.dateBuilder.MustNewDate(r.restriction.OutDate)
+	lastNight := outDate.AddDays(-1)
+
+	var remaining, total int
+	switch proration.Period {
+	case models.MONTHLY:
+		monthIndex := func(date *frdate.Date) int { return date.Year()*12 + date.Month() - 1 }
+		remaining = monthIndex(lastNight) - monthIndex(purchaseDate) + 1
+		total = monthIndex(lastNight) - monthIndex(inDate) + 1
+	default:
+		nights, _ := frdate.DaysList(inDate, outDate, false)
+		total = len(nights)
+		if purchaseDate.Before(outDate) {
+			nights, _ = frdate.DaysList(purchaseDate, outDate, false)
+			remaining = len(nights)
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	prorated := int32(int64(amount) * int64(remaining) / int64(total))
+	if prorated < proration.MinimumAmount {
+		prorated = proration.MinimumAmount
+	}
+	if prorated > amount {
+		prorated = amount
+	}
+	return prorated
+}
+
+// MembershipProrationResolver is a membership proration resolver
+type MembershipProrationResolver struct {
+	proration *models.MembershipProration
+	money     *moneyFormat
+}
+
+// Period is the unit the amount is pro-rated by
+func (r *MembershipProrationResolver) Period() models.ProrationPeriod {
+	return r.proration.Period
+}
+
+// MinimumAmount is the least amount charged for a purchase
+func (r *MembershipProrationResolver) MinimumAmount(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.proration.MinimumAmount, args.Format)
+}
+
+// MembershipTierResolver is a membership tier resolver
+type MembershipTierResolver struct {
+	tier  *models.MembershipTier
+	money *moneyFormat
+}
+
+// Name is the unique name of the tier in the membership
+func (r *MembershipTierResolver) Name() string {
+	return r.tier.Name
+}
+
+// Amount is the price of the tier
+func (r *MembershipTierResolver) Amount(args *struct{ Format amountFormat }) (string, error) {
+	return r.money.formatAmount(r.tier.Amount, args.Format)
+}
+
+// NightlyRate is the member nightly rate of the tier, nil if the settings member rate is used
+func (r *MembershipTierResolver) NightlyRate(args *struct{ Format amountFormat }) (*string, error) {
+	if r.tier.NightlyRate == nil {
+		return nil, nil
+	}
+	rate, err := r.money.formatAmount(*r.tier.NightlyRate, args.Format)
+	return &rate, err
+}
+
+// BookingWindowDays is how many days ahead a stay can be booked, nil if the settings are used
+func (r *MembershipTierResolver) BookingWindowDays() *int32 {
+	return r.tier.BookingWindowDays
+}
+
+// MaxNights is the max nights of a stay, nil if not limited
+func (r *MembershipTierResolver) MaxNights() *int32 {
+	return r.tier.MaxNights
+}
+
+// Nights are the nights of the week that can be booked
+func (r *MembershipTierResolver) Nights() []models.Weekday {
+	if r.tier.Nights == nil {
+		return models.AllWeekdays
+	}
+	return *r.tier.Nights
+}
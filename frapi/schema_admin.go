@@ -11,8 +11,8 @@ var AdminSchema = `
 	
 	# The query type, represents all of the entry points into our object graph
 	type Query {
-		# get info about a property
-		property(id: String!): Property
+		# get info about a property, optionally as of an earlier event version or RFC3339 date time
+		property(id: String!, asOfVersion: Int, asOfDateTime: String): Property
 	}
 
 	# The mutation type, represents all updates we can make to our data
@@ -31,8 +31,30 @@ var AdminSchema = `
 		updateSystemUser(propertyId: String!, userId: String!, input: UpdateSystemUserInput!) : Property
 		# update user balance
 		updateBalance(propertyId: String!, input: UpdateBalanceInput!) : Property
+		# undo a mistaken payment or expense
+		reverseLedgerEntry(propertyId: String!, input: ReverseLedgerEntryInput!) : Property
+		# charge an expense to several users, divided by the split method
+		splitExpense(propertyId: String!, input: SplitExpenseInput!) : Property
+		# create a payment plan for a user
+		createPaymentPlan(propertyId: String!, input: NewPaymentPlanInput!) : Property
+		# cancel a payment plan
+		cancelPaymentPlan(propertyId: String!, input: CancelPaymentPlanInput!) : Property
+		# save how the columns of a bank statement CSV map to payments
+		updateBankImportMapping(propertyId: String!, input: BankImportMappingInput!) : Property
+		# read the transfers of an uploaded bank statement CSV and propose the balance updates for review
+		proposeBankImport(propertyId: String!, file: Upload!) : [BankImportProposal!]!
+		# update the balances for the reviewed bank transfers, transfers imported before are skipped
+		commitBankImport(propertyId: String!, input: BankImportInput!) : Property
 		# mark notification read
-		notificationRead(propertyId: String!, notificationId: String!) : Property
+		notificationRead(propertyId: String!, notificationId: String!, forVersion: Int!) : Property
+		# mark all my notifications read
+		markAllNotificationsRead(propertyId: String!, forVersion: Int!) : Property
+		# set how I receive a type of notification
+		updateNotificationPreference(propertyId: String!, input: UpdateNotificationPreferenceInput!) : Property
+		# set (or clear with an empty contact) my phone number or push subscription for urgent notifications
+		updateNotificationContact(propertyId: String!, channel: NotificationChannel!, contact: String!) : Property
+		# start paying an amount towards my balance online, send me to the url of the checkout session to pay
+		startTopUp(propertyId: String!, amount: Int!) : CheckoutSession!
 		# create content
 		createContent(propertyId: String!, input: NewContentInput!) : Property
 		# accept or reject an invitation to join a property
@@ -44,6 +66,10 @@ var AdminSchema = `
 		export(propertyId: String!) : Property
 		deleteProperty(propertyId: String!) : Boolean!
 		exportCSV(propertyId: String!) : Property
+		# erase the personal information of a user
+		eraseUser(propertyId: String!, input: EraseUserInput!) : Boolean!
+		# email a user all of their personal data
+		personalDataExport(propertyId: String!, userId: String!) : Property
 
 
 	}
@@ -61,16 +87,35 @@ var AdminSchema = `
 		me: User!
 		restrictions(restrictionId: String, maxVersion: Int): [RestrictionRecord]!
 		ledgers(userId: String, last: Int, reverse: Boolean): [Ledger]!
+		paymentPlans(userId: String, paymentPlanId: String): [PaymentPlan!]!
+		# balances, debt aging and monthly totals, default is the last 12 months
+		financialSummary(months: Int): FinancialSummary!
+		# null until a mapping is saved with updateBankImportMapping
+		bankImportMapping: BankImportMapping
 		notifications(userId: String, reverse: Boolean): [Notification]!
+		unreadNotificationCount(userId: String): Int!
+		notificationPreferences(userId: String): [NotificationPreferenceSetting]!
+		notificationChannels: [NotificationChannel!]!
+		notificationContacts: [NotificationContact!]!
+		pushPublicKey: String
+		# true if balances can be paid online, see startTopUp
+		onlinePayments: Boolean!
+		# notifications whose email is pending a retry or has failed
+		undeliveredNotifications: [Notification]!
 		contents: [Content]!
+		# notification email contents that can be set by an admin
+		notificationContents: [Content]!
+		# render content with sample data before it is saved
+		previewContent(name: ContentName!, subject: String, template: String!): ContentPreview!
 		updateSettingsConstraints: UpdateSettingsConstraints!
 		membershipStatusConstraints(userId: String): [MembershipStatusConstraints]!
 		newReservationConstraints(userId: String, userType: ConstraintsUserType!): NewReservationConstraints!
 		cancelReservationConstraints(userId: String, userType: ConstraintsUserType!): CancelReservationConstraints!
 		updateUserConstraints(userId: String): UpdateUserConstraints!
 		updateBalanceConstraints(): UpdateBalanceConstraints!
+		auditLog(fromVersion: Int, toVersion: Int, userId: String, eventType: AuditEventType, first: Int): AuditLog!
 
 	}
 
 
-` + models.NewRestrictionInputGQL + models.BlackoutRestrictionInputGQL + models.MembershipRestrictionInputGQL + models.UpdateSettingsInputGQL + models.AcceptInvitationInputGQL + models.NewReservationInputGQL + settingsGQL + reservationGQL + userGQL + ledgerQueryGQL + membershipStatusConstraintsGQL + restrictionGQL + notificationGQL + contentGQL + reservationConstraintsGQL + settingsConstraintsGQL + updateUserConstraintsGQL + cancelReservationConstraintsGQL + models.LedgerMutationGQL + models.NewUserInputGQL + models.UpdateUserInputGQL + updateBalanceConstraintsGQL + models.NewContentInputGQL + models.UpdateMembershipStatusInputGQL + models.UpdateSystemUserInputGQL
+` + models.NewRestrictionInputGQL + models.BlackoutRestrictionInputGQL + models.MembershipRestrictionInputGQL + models.UpdateSettingsInputGQL + models.AcceptInvitationInputGQL + models.NewReservationInputGQL + settingsGQL + reservationGQL + userGQL + ledgerQueryGQL + membershipStatusConstraintsGQL + restrictionGQL + notificationGQL + models.UpdateNotificationPreferenceInputGQL + models.NotificationTemplateNameGQL + contactGQL + contentGQL + contentPreviewGQL + reservationConstraintsGQL + membershipTierPeriodGQL + settingsConstraintsGQL + updateUserConstraintsGQL + cancelReservationConstraintsGQL + models.LedgerMutationGQL + models.SplitExpenseInputGQL + models.ReverseLedgerEntryInputGQL + models.NewUserInputGQL + models.UpdateUserInputGQL + updateBalanceConstraintsGQL + models.NewContentInputGQL + models.UpdateMembershipStatusInputGQL + models.UpdateSystemUserInputGQL + auditLogGQL + models.EraseUserInputGQL + paymentPlanGQL + checkoutSessionGQL + models.PaymentPlanInputGQL + bankImportGQL + models.BankImportInputGQL + financialSummaryGQL
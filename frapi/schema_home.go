@@ -1,5 +1,9 @@
 package frapi
 
+import (
+	"github.com/bjorge/friendlyreservations/models"
+)
+
 // HomeSchema is the gql schema for choosing a property
 var HomeSchema = `
 	schema {
@@ -39,6 +43,8 @@ var HomeSchema = `
 		isMember: Boolean!
 		nickname: String!
 		timezone: String!
+		# how amounts are formatted, en-US if not set
+		locale: String
 	}
 	
 	# QUERY RESULTS
@@ -53,4 +59,4 @@ var HomeSchema = `
 
 	}
 
-` + settingsGQL + userGQL + settingsConstraintsGQL + updateUserConstraintsGQL
+` + settingsGQL + models.NotificationTemplateNameGQL + userGQL + settingsConstraintsGQL + updateUserConstraintsGQL
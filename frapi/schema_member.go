@@ -24,6 +24,20 @@ var MemberSchema = `
 		acceptInvitation(propertyId: String!, input: AcceptInvitationInput!) : Property
 		# update membership status
 		updateMembershipStatus(propertyId: String!, input: UpdateMembershipInput!) : Property
+		# erase my personal information, I will no longer have access to the property
+		eraseUser(propertyId: String!, input: EraseUserInput!) : Boolean!
+		# email me all of my personal data
+		personalDataExport(propertyId: String!, userId: String!) : Property
+		# mark a notification sent to me read
+		notificationRead(propertyId: String!, notificationId: String!, forVersion: Int!) : Property
+		# mark all my notifications read
+		markAllNotificationsRead(propertyId: String!, forVersion: Int!) : Property
+		# set how I receive a type of notification
+		updateNotificationPreference(propertyId: String!, input: UpdateNotificationPreferenceInput!) : Property
+		# set (or clear with an empty contact) my phone number or push subscription for urgent notifications
+		updateNotificationContact(propertyId: String!, channel: NotificationChannel!, contact: String!) : Property
+		# start paying an amount towards my balance online, send me to the url of the checkout session to pay
+		startTopUp(propertyId: String!, amount: Int!) : CheckoutSession!
 	}
 
 	# QUERY RESULTS
@@ -39,8 +53,16 @@ var MemberSchema = `
 		users(userId: String, email: String, maxVersion: Int): [User!]!
 		me: User!
 		ledgers(userId: String, last: Int, reverse: Boolean): [Ledger]!
+		paymentPlans(userId: String, paymentPlanId: String): [PaymentPlan!]!
 		# ranges of dates that are disabled for the calendar view
 		notifications(userId: String, reverse: Boolean): [Notification]!
+		unreadNotificationCount(userId: String): Int!
+		notificationPreferences(userId: String): [NotificationPreferenceSetting]!
+		notificationChannels: [NotificationChannel!]!
+		notificationContacts: [NotificationContact!]!
+		pushPublicKey: String
+		# true if balances can be paid online, see startTopUp
+		onlinePayments: Boolean!
 		contents: [Content]!
 		membershipStatusConstraints(userId: String): [MembershipStatusConstraints]!
 		newReservationConstraints(userId: String, userType: ConstraintsUserType!): NewReservationConstraints!
@@ -49,4 +71,4 @@ var MemberSchema = `
 	}
 
 
-` + models.AcceptInvitationInputGQL + models.NewReservationInputGQL + settingsGQL + reservationGQL + restrictionGQL + userGQL + ledgerQueryGQL + notificationGQL + contentGQL + membershipStatusConstraintsGQL + reservationConstraintsGQL + cancelReservationConstraintsGQL + models.UpdateMembershipStatusInputGQL
+` + models.AcceptInvitationInputGQL + models.NewReservationInputGQL + settingsGQL + reservationGQL + restrictionGQL + userGQL + ledgerQueryGQL + notificationGQL + models.UpdateNotificationPreferenceInputGQL + models.NotificationTemplateNameGQL + contactGQL + contentGQL + membershipStatusConstraintsGQL + reservationConstraintsGQL + membershipTierPeriodGQL + cancelReservationConstraintsGQL + models.UpdateMembershipStatusInputGQL + models.EraseUserInputGQL + paymentPlanGQL + checkoutSessionGQL
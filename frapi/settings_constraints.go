@@ -25,6 +25,10 @@ type UpdateSettingsConstraints {
 	reservationReminderDaysBeforeMax: Int!
 	balanceReminderIntervalDaysMin: Int! 
 	balanceReminderIntervalDaysMax: Int!
+	membershipReminderDaysBeforeMin: Int!
+	membershipReminderDaysBeforeMax: Int!
+	# the locales amounts can be formatted for
+	locales: [String!]!
 	allowNewProperty: Boolean!
 	allowPropertyImport: Boolean!
 	allowPropertyExportCSV: Boolean!
@@ -100,6 +104,15 @@ func (r *UpdateSettingsConstraints) BalanceReminderIntervalDaysMin() int32 { ret
 // BalanceReminderIntervalDaysMax returns min value
 func (r *UpdateSettingsConstraints) BalanceReminderIntervalDaysMax() int32 { return 40 }
 
+// MembershipReminderDaysBeforeMin returns min value
+func (r *UpdateSettingsConstraints) MembershipReminderDaysBeforeMin() int32 { return 1 }
+
+// MembershipReminderDaysBeforeMax returns max value
+func (r *UpdateSettingsConstraints) MembershipReminderDaysBeforeMax() int32 { return 60 }
+
+// Locales returns the supported locales
+func (r *UpdateSettingsConstraints) Locales() []string { return localeNames() }
+
 // AllowNewProperty is true if a new property creation is allowed
 func (r *UpdateSettingsConstraints) AllowNewProperty() bool {
 	if !utilities.AllowNewProperty {
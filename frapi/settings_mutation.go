@@ -2,7 +2,6 @@ package frapi
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/bjorge/friendlyreservations/frdate"
@@ -31,8 +30,8 @@ func (r *Resolver) UpdateSettings(ctx context.Context, args *struct {
 	}
 
 	// check the input values
-	if !me.IsAdmin() {
-		return nil, errors.New("only admins can update settings")
+	if err := authorize(me, manageSettingsPermission); err != nil {
+		return nil, err
 	}
 
 	constraints, err := property.UpdateSettingsConstraints(ctx)
@@ -54,12 +53,18 @@ func (r *Resolver) UpdateSettings(ctx context.Context, args *struct {
 		return nil, fmt.Errorf("property name too long")
 	}
 
-	switch args.Input.Currency {
-	case models.USD:
-	case models.EUR:
-		break
-	default:
-		return nil, fmt.Errorf("unknown currency %+v", args.Input.Currency)
+	if err := validateMoneyFormat(args.Input.Currency, args.Input.Locale); err != nil {
+		return nil, err
+	}
+
+	// amounts are kept in minor units, so a currency with other minor units would rescale the ledgers
+	settings, err := property.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if currencies[args.Input.Currency].minorUnits != settings.moneyFormat().currency.minorUnits && property.hasLedgerEntries() {
+		return nil, fmt.Errorf("currency cannot change from %v to %v after ledger entries are made",
+			settings.settings.Currency, args.Input.Currency)
 	}
 
 	if _, err := frdate.NewDateBuilder(args.Input.Timezone); err != nil {
@@ -96,11 +101,25 @@ func (r *Resolver) UpdateSettings(ctx context.Context, args *struct {
 		return nil, fmt.Errorf("ReservationReminderDaysBefore out of range %+v", args.Input.ReservationReminderDaysBefore)
 	}
 
+	if args.Input.MembershipReminderDaysBefore != nil &&
+		(*args.Input.MembershipReminderDaysBefore < constraints.MembershipReminderDaysBeforeMin() ||
+			*args.Input.MembershipReminderDaysBefore > constraints.MembershipReminderDaysBeforeMax()) {
+		return nil, fmt.Errorf("MembershipReminderDaysBefore out of range %+v", *args.Input.MembershipReminderDaysBefore)
+	}
+
 	if args.Input.MinInDays < constraints.MinInDaysMin() ||
 		args.Input.MinInDays > constraints.MinInDaysMax() {
 		return nil, fmt.Errorf("MinInDays out of range %+v", args.Input.MinInDays)
 	}
 
+	if args.Input.MandatoryNotifications != nil {
+		for _, name := range *args.Input.MandatoryNotifications {
+			if !isPreferenceTemplateName(name) {
+				return nil, fmt.Errorf("notification %v cannot be mandatory", name)
+			}
+		}
+	}
+
 	// update the request with more information
 	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
 	args.Input.AuthorUserId = me.UserID()
@@ -4,15 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 
 	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/templates"
 )
 
 const settingsGQL = `
 type Settings {
 	propertyName: String!
 	currency(format: CurrencyFormat = ACRONYM): String!
+	# the number of decimals of the currency, amounts are in these minor units (ex. cents)
+	minorUnits: Int!
+	# how amounts are formatted for the LOCALE and CURRENCY amount formats, ex. en-US
+	locale: String!
 	memberRate(format: AmountFormat = DECIMAL): String!
 	allowNonMembers: Boolean!
 	nonMemberRate(format: AmountFormat = DECIMAL): String!
@@ -22,16 +26,51 @@ type Settings {
 	minInDays: Int!
 	reservationReminderDaysBefore: Int!
 	balanceReminderIntervalDays: Int!
+	membershipReminderDaysBefore: Int!
+	# notifications always emailed immediately, members cannot choose a digest or in app only for them
+	mandatoryNotifications: [NotificationTemplateName!]!
 }
 
 enum AmountFormat {
+	# the amount with the decimals of the currency, ex. 1234.50
 	DECIMAL
+	# the amount in minor units, ex. 123450
 	NODECIMAL
+	# the amount written for the locale, ex. 1.234,50
+	LOCALE
+	# the amount with the currency symbol written for the locale, ex. 1.234,50 €
+	CURRENCY
 }
 
+# ISO 4217 currency codes
 enum Currency {
 	USD
 	EUR
+	GBP
+	CHF
+	CAD
+	AUD
+	NZD
+	SEK
+	NOK
+	DKK
+	PLN
+	CZK
+	HUF
+	MXN
+	BRL
+	ZAR
+	INR
+	CNY
+	HKD
+	SGD
+	JPY
+	KRW
+	ISK
+	KWD
+	BHD
+	OMR
+	JOD
 }
 
 enum CurrencyFormat {
@@ -94,22 +133,35 @@ func (r *SettingsResolver) Currency(ctx context.Context, args *struct{ Format cu
 		return string(r.settings.Currency), nil
 	}
 	if args.Format == symbol {
-		switch r.settings.Currency {
-		case "USD":
-			return "$", nil
-		case "EUR":
-			return "€", nil
+		if info, ok := currencies[r.settings.Currency]; ok {
+			return info.symbol, nil
 		}
 	}
 
 	return "", fmt.Errorf("unknown currency format")
 }
 
+// MinorUnits is the number of decimals of the currency
+func (r *SettingsResolver) MinorUnits() int32 {
+	return int32(r.moneyFormat().currency.minorUnits)
+}
+
+// Locale is how amounts are written
+func (r *SettingsResolver) Locale() string {
+	return r.settings.Locale
+}
+
+func (r *SettingsResolver) moneyFormat() *moneyFormat {
+	return newMoneyFormat(r.settings.Currency, r.settings.Locale)
+}
+
 type amountFormat string
 
 const (
-	decimal   amountFormat = "DECIMAL"
-	nodecimal amountFormat = "NODECIMAL"
+	decimal         amountFormat = "DECIMAL"
+	nodecimal       amountFormat = "NODECIMAL"
+	localized       amountFormat = "LOCALE"
+	localizedSymbol amountFormat = "CURRENCY"
 )
 
 func formatDate(dateBuilder *frdate.DateBuilder, date string, format string) (string, error) {
@@ -118,26 +170,9 @@ func formatDate(dateBuilder *frdate.DateBuilder, date string, format string) (st
 	return formattedDate, nil
 }
 
-func formatAmount(amount int32, format amountFormat) (string, error) {
-
-	if format == "" {
-		format = decimal
-	}
-
-	switch format {
-	case decimal:
-		decimalPart := amount % 100
-		nonDecimalPart := amount / 100
-		return fmt.Sprintf("%d.%02d", int(nonDecimalPart), int(decimalPart)), nil
-	case nodecimal:
-		return strconv.Itoa(int(amount)), nil
-	}
-	return "", fmt.Errorf("unknown format %+v", format)
-}
-
 // MemberRate is the daily member rate for reservations
 func (r *SettingsResolver) MemberRate(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.settings.MemberRate, args.Format)
+	return r.moneyFormat().formatAmount(r.settings.MemberRate, args.Format)
 }
 
 func (r *SettingsResolver) memberRateInternal() int32 {
@@ -151,7 +186,7 @@ func (r *SettingsResolver) AllowNonMembers() bool {
 
 // NonMemberRate is the daily non-member rate for reservations
 func (r *SettingsResolver) NonMemberRate(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.settings.NonMemberRate, args.Format)
+	return r.moneyFormat().formatAmount(r.settings.NonMemberRate, args.Format)
 }
 
 func (r *SettingsResolver) nonMemberRateInternal() int32 {
@@ -170,11 +205,11 @@ func (r *SettingsResolver) GetEventVersion() int {
 
 // MinBalance is the minimum balance required to make new reservations
 func (r *SettingsResolver) MinBalance(args *struct{ Format amountFormat }) (string, error) {
-	return formatAmount(r.settings.MinBalance, args.Format)
+	return r.moneyFormat().formatAmount(r.settings.MinBalance, args.Format)
 }
 
 func (r *SettingsResolver) minBalanceInternal() *amountResolver {
-	return &amountResolver{r.settings.MinBalance}
+	return &amountResolver{r.settings.MinBalance, r.moneyFormat()}
 }
 
 // MinInDays is the number of days before the current date when new reservations can be made
@@ -196,3 +231,14 @@ func (r *SettingsResolver) ReservationReminderDaysBefore() int32 {
 func (r *SettingsResolver) BalanceReminderIntervalDays() int32 {
 	return r.settings.BalanceReminderIntervalDays
 }
+
+// MembershipReminderDaysBefore is the number of days before a membership starts, or its grace period ends,
+// at which members who have not purchased or opted out are reminded
+func (r *SettingsResolver) MembershipReminderDaysBefore() int32 {
+	return r.settings.MembershipReminderDaysBefore
+}
+
+// MandatoryNotifications are the notifications always emailed immediately, whatever the preference of the user
+func (r *SettingsResolver) MandatoryNotifications() []templates.TemplateName {
+	return append([]templates.TemplateName{}, r.settings.MandatoryNotifications...)
+}
@@ -2,6 +2,7 @@ package frapi
 
 import (
 	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/templates"
 )
 
 // SettingsRollup is an internal struct used duing rollup of settings
@@ -18,6 +19,10 @@ type SettingsRollup struct {
 	EventVersion                  int32
 	ReservationReminderDaysBefore int32
 	BalanceReminderIntervalDays   int32
+	MembershipReminderDaysBefore  int32
+	Locale                        string
+	MandatoryNotifications        []templates.TemplateName
+	BankImportMapping             *models.BankImportMappingInput
 }
 
 // GetEventVersion returns version of rollup item
@@ -50,6 +55,12 @@ func (r *PropertyResolver) rollupSettings() {
 				settings.MinBalance = -100000
 				settings.ReservationReminderDaysBefore = 3
 				settings.BalanceReminderIntervalDays = 2
+				settings.MembershipReminderDaysBefore = 14
+				settings.Locale = defaultLocale
+				if settingsEvent.Locale != nil {
+					settings.Locale = *settingsEvent.Locale
+				}
+				settings.MandatoryNotifications = defaultMandatoryNotifications
 
 				r.addRollup(settingsID,
 					settings, settingsRollupType)
@@ -75,9 +86,32 @@ func (r *PropertyResolver) rollupSettings() {
 				settings.MinBalance = settingsEvent.MinBalance
 				settings.ReservationReminderDaysBefore = settingsEvent.ReservationReminderDaysBefore
 				settings.BalanceReminderIntervalDays = settingsEvent.BalanceReminderIntervalDays
+				if settingsEvent.MembershipReminderDaysBefore != nil {
+					settings.MembershipReminderDaysBefore = *settingsEvent.MembershipReminderDaysBefore
+				}
+				if settingsEvent.Locale != nil {
+					settings.Locale = *settingsEvent.Locale
+				}
+				if settingsEvent.MandatoryNotifications != nil {
+					settings.MandatoryNotifications = *settingsEvent.MandatoryNotifications
+				}
 
 				settings.EventVersion = settingsEvent.EventVersion
 
+				r.addRollup(settingsID,
+					&settings, settingsRollupType)
+
+			case *models.BankImportMappingInput:
+
+				id := settingsID
+				ifaces := r.getRollups(&rollupArgs{id: &id}, settingsRollupType)
+
+				// make a copy
+				settings := *ifaces[0].(*SettingsRollup)
+
+				settings.BankImportMapping = settingsEvent
+				settings.EventVersion = settingsEvent.EventVersion
+
 				r.addRollup(settingsID,
 					&settings, settingsRollupType)
 			}
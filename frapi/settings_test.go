@@ -72,6 +72,8 @@ func TestSettings(t *testing.T) {
 	input.NonMemberRate = settings.nonMemberRateInternal()
 	input.PropertyName = testPropertyName
 	input.ReservationReminderDaysBefore = settings.ReservationReminderDaysBefore()
+	membershipReminderDays := int32(7)
+	input.MembershipReminderDaysBefore = &membershipReminderDays
 
 	input.ForVersion = property.EventVersion()
 
@@ -93,6 +95,9 @@ func TestSettings(t *testing.T) {
 	if settings.PropertyName() != testPropertyName {
 		t.Fatalf("update property name failed, returned %+v expected %+v", settings.PropertyName(), testPropertyName)
 	}
+	if settings.MembershipReminderDaysBefore() != membershipReminderDays {
+		t.Fatalf("update membership reminder days failed, returned %+v", settings.MembershipReminderDaysBefore())
+	}
 
 	if firstEventVersion == secondEventVersion {
 		t.Fatal("expected the event version to change after the mutation")
@@ -0,0 +1,249 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/templates"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+// maxExpenseShares is the most users an expense can be split between
+const maxExpenseShares = 100
+
+// SplitExpense is called by an admin or treasurer to charge an expense to several users, the balance
+// updates are committed together and share an expense id
+func (r *Resolver) SplitExpense(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.SplitExpenseInput
+}) (*PropertyResolver, error) {
+	Logger.LogDebugf("Split Expense")
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the input values and for duplicates, the shares are persisted as balance updates
+	if duplicate, err := isDuplicate(ctx, args.Input, property); duplicate || err != nil {
+		if err == nil {
+			return property, nil
+		}
+		return nil, err
+	}
+	if err := authorize(me, manageLedgersPermission); err != nil {
+		return nil, err
+	}
+
+	constraints, err := property.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Input.Amount < constraints.AmountMin() {
+		return nil, errors.New("amount too small")
+	}
+
+	args.Input.Description = strings.TrimSpace(args.Input.Description)
+	if len(args.Input.Description) < int(constraints.DescriptionMin()) {
+		return nil, errors.New("description too small")
+	}
+	if len(args.Input.Description) > int(constraints.DescriptionMax()) {
+		return nil, errors.New("description too big")
+	}
+
+	if len(args.Input.Shares) == 0 {
+		return nil, errors.New("an expense must be split between at least one user")
+	}
+	if len(args.Input.Shares) > maxExpenseShares {
+		return nil, fmt.Errorf("an expense can be split between at most %v users", maxExpenseShares)
+	}
+	sharedBy := make(map[string]bool)
+	for _, share := range args.Input.Shares {
+		users := property.Users(&usersArgs{UserID: &share.UserId})
+		if len(users) != 1 || users[0].IsSystem() {
+			return nil, fmt.Errorf("user %v does not exist", share.UserId)
+		}
+		if sharedBy[share.UserId] {
+			return nil, fmt.Errorf("user %v has more than one share", share.UserId)
+		}
+		sharedBy[share.UserId] = true
+	}
+
+	weights, err := property.expenseWeights(args.Input)
+	if err != nil {
+		return nil, err
+	}
+	amounts, err := splitAmount(args.Input.Amount, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	// input looks good, now create a balance update for each share
+	expenseID := utilities.NewGUID()
+	createDateTime := frdate.CreateDateTimeUTC()
+	events := []platform.VersionedEvent{}
+	notificationIDs := []string{}
+	for i, share := range args.Input.Shares {
+		if amounts[i] == 0 {
+			continue
+		}
+		if amounts[i] > constraints.AmountMax() {
+			return nil, fmt.Errorf("share of user %v too big", share.UserId)
+		}
+
+		userID := share.UserId
+		updateBalanceInput := &models.UpdateBalanceInput{
+			ForVersion:      args.Input.ForVersion,
+			UpdateForUserId: userID,
+			Amount:          amounts[i],
+			Description:     args.Input.Description,
+			Increase:        false,
+			CreateDateTime:  createDateTime,
+			AuthorUserId:    me.UserID(),
+			PaymentId:       utilities.NewGUID(),
+			ExpenseId:       expenseID,
+		}
+
+		paramGroup := templates.Ledger
+		newNotificationInput := createNotificationRecord(notificationTargetMember, property, templates.BalanceChangeNotification,
+			&userID, &paramGroup, &userID)
+
+		events = append(events, updateBalanceInput, newNotificationInput)
+		notificationIDs = append(notificationIDs, newNotificationInput.NotificationId)
+	}
+
+	property, err = commitChanges(ctx, args.PropertyID, property.EventVersion(), events...)
+	if err != nil {
+		return nil, err
+	}
+
+	// send the email notifications
+	for _, notificationID := range notificationIDs {
+		property = deliverNotification(ctx, property, notificationID)
+	}
+	return property, nil
+}
+
+// expenseWeights returns the weight of each share for the split method
+func (r *PropertyResolver) expenseWeights(input *models.SplitExpenseInput) ([]int64, error) {
+	weights := make([]int64, len(input.Shares))
+
+	switch input.Method {
+	case models.EQUAL:
+		for i := range weights {
+			weights[i] = 1
+		}
+
+	case models.WEIGHTS:
+		for i, share := range input.Shares {
+			if share.Weight == nil || *share.Weight < 0 {
+				return nil, fmt.Errorf("user %v needs a weight of at least 0", share.UserId)
+			}
+			weights[i] = int64(*share.Weight)
+		}
+
+	case models.NIGHTS:
+		if input.StartDate == nil || input.EndDate == nil {
+			return nil, errors.New("the nights split method needs a start and end date")
+		}
+		settings, err := r.Settings(&settingsArgs{})
+		if err != nil {
+			return nil, err
+		}
+		dateBuilder := frdate.MustNewDateBuilder(settings.Timezone())
+		startDate, err := dateBuilder.NewDate(*input.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date %+v", *input.StartDate)
+		}
+		endDate, err := dateBuilder.NewDate(*input.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date %+v", *input.EndDate)
+		}
+		if !endDate.After(startDate) {
+			return nil, errors.New("end date must be after start date")
+		}
+		for i, share := range input.Shares {
+			nights, err := r.nightsReserved(share.UserId, dateBuilder, startDate, endDate)
+			if err != nil {
+				return nil, err
+			}
+			weights[i] = int64(nights)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown split method %+v", input.Method)
+	}
+	return weights, nil
+}
+
+// nightsReserved returns the nights of the reservations of a user from the start date up to the end date
+func (r *PropertyResolver) nightsReserved(userID string, dateBuilder *frdate.DateBuilder, startDate *frdate.Date, endDate *frdate.Date) (int, error) {
+	reservations, err := r.Reservations(&reservationsArgs{UserID: &userID})
+	if err != nil {
+		return 0, err
+	}
+
+	nights := 0
+	for _, reservation := range reservations {
+		if reservation.Canceled() {
+			continue
+		}
+		first := dateBuilder.MustNewDate(reservation.StartDate())
+		if first.Before(startDate) {
+			first = startDate
+		}
+		last := dateBuilder.MustNewDate(reservation.EndDate())
+		if last.After(endDate) {
+			last = endDate
+		}
+		if last.After(first) {
+			days, err := frdate.DaysList(first, last, false)
+			if err != nil {
+				return 0, err
+			}
+			nights += len(days)
+		}
+	}
+	return nights, nil
+}
+
+// splitAmount divides the amount by the weights, each share is rounded down and the units left over go
+// one each to the shares with the largest rounded off fractions, the earlier share first on a tie
+func splitAmount(amount int32, weights []int64) ([]int32, error) {
+	total := int64(0)
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		return nil, errors.New("no user has a share of the expense")
+	}
+
+	amounts := make([]int32, len(weights))
+	remainders := make([]int64, len(weights))
+	left := int64(amount)
+	for i, weight := range weights {
+		amounts[i] = int32(int64(amount) * weight / total)
+		remainders[i] = int64(amount) * weight % total
+		left -= int64(amounts[i])
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for _, i := range order[:left] {
+		amounts[i]++
+	}
+	return amounts, nil
+}
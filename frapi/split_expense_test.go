@@ -0,0 +1,201 @@
+package frapi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/models"
+)
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		amount   int32
+		weights  []int64
+		expected []int32
+	}{
+		{100, []int64{1, 1, 1}, []int32{34, 33, 33}},
+		{120000, []int64{1, 1, 1, 1, 1, 1, 1, 1}, []int32{15000, 15000, 15000, 15000, 15000, 15000, 15000, 15000}},
+		{1000, []int64{1, 2}, []int32{333, 667}},
+		{1000, []int64{0, 1, 3}, []int32{0, 250, 750}},
+		{5, []int64{3, 3, 3, 3, 3, 3}, []int32{1, 1, 1, 1, 1, 0}},
+	}
+
+	for _, test := range tests {
+		amounts, err := splitAmount(test.amount, test.weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(amounts, test.expected) {
+			t.Fatalf("%v split by %v: expected %v but got %v", test.amount, test.weights, test.expected, amounts)
+		}
+	}
+
+	if _, err := splitAmount(100, []int64{0, 0}); err == nil {
+		t.Fatal("expected an error without weights")
+	}
+}
+
+func TestSplitExpense(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+	property, jane := createUser(ctx, t, resolver, property, "jane@example.com", "Jane")
+	property, bob := createUser(ctx, t, resolver, property, "bob@example.com", "Bob")
+
+	splitExpense := func(input *models.SplitExpenseInput) (*PropertyResolver, error) {
+		return resolver.SplitExpense(ctx, &struct {
+			PropertyID string
+			Input      *models.SplitExpenseInput
+		}{
+			PropertyID: property.PropertyID(),
+			Input:      input,
+		})
+	}
+	shares := func(userIDs ...string) []models.ExpenseShareInput {
+		l := []models.ExpenseShareInput{}
+		for _, userID := range userIDs {
+			l = append(l, models.ExpenseShareInput{UserId: userID})
+		}
+		return l
+	}
+	lastRecord := func(userID string) *LedgerRecordResolver {
+		ledgers, err := property.Ledgers(&ledgersArgs{UserID: &userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := ledgers[0].Records()
+		return records[len(records)-1]
+	}
+
+	t.Log("split equally")
+	input := &models.SplitExpenseInput{
+		ForVersion:  property.EventVersion(),
+		Amount:      100,
+		Description: "plumbing",
+		Method:      models.EQUAL,
+		Shares:      shares(me.UserID(), jane.UserID(), bob.UserID()),
+	}
+	property, err := splitExpense(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLedger(ctx, t, property, me.UserID(), 2, expenseLedgerEvent, -34, -34)
+	checkLedger(ctx, t, property, jane.UserID(), 2, expenseLedgerEvent, -33, -33)
+	checkLedger(ctx, t, property, bob.UserID(), 2, expenseLedgerEvent, -33, -33)
+	expenseID := lastRecord(me.UserID()).ExpenseID()
+	if expenseID == nil || *lastRecord(jane.UserID()).ExpenseID() != *expenseID || *lastRecord(bob.UserID()).ExpenseID() != *expenseID {
+		t.Fatal("expected the records to share the expense id")
+	}
+
+	t.Log("a duplicate request is ignored")
+	version := property.EventVersion()
+	if property, err = splitExpense(input); err != nil {
+		t.Fatal(err)
+	}
+	if property.EventVersion() != version {
+		t.Fatal("expected the duplicate split to be ignored")
+	}
+
+	t.Log("split by weights, a share of 0 is not charged")
+	janeWeight, bobWeight := int32(1), int32(3)
+	zero := int32(0)
+	property, err = splitExpense(&models.SplitExpenseInput{
+		ForVersion:  property.EventVersion(),
+		Amount:      1000,
+		Description: "new roof",
+		Method:      models.WEIGHTS,
+		Shares: []models.ExpenseShareInput{
+			{UserId: me.UserID(), Weight: &zero},
+			{UserId: jane.UserID(), Weight: &janeWeight},
+			{UserId: bob.UserID(), Weight: &bobWeight},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLedger(ctx, t, property, me.UserID(), 2, expenseLedgerEvent, -34, -34)
+	checkLedger(ctx, t, property, jane.UserID(), 3, expenseLedgerEvent, -283, -250)
+	checkLedger(ctx, t, property, bob.UserID(), 3, expenseLedgerEvent, -783, -750)
+
+	t.Log("split by the nights reserved in the period")
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(1).ToString(), today.AddDays(4).ToString())
+	property, _ = createReservation(ctx, t, resolver, property, me.UserID(), today.AddDays(7).ToString(), today.AddDays(10).ToString())
+	property, err = resolver.CreateReservation(ctx, &struct {
+		PropertyID string
+		Input      *models.NewReservationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input: &models.NewReservationInput{
+			ForVersion:        property.EventVersion(),
+			ReservedForUserId: jane.UserID(),
+			StartDate:         today.AddDays(4).ToString(),
+			EndDate:           today.AddDays(6).ToString(),
+			Member:            true,
+			AdminRequest:      true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	startDate, endDate := today.AddDays(1).ToString(), today.AddDays(8).ToString()
+	property, err = splitExpense(&models.SplitExpenseInput{
+		ForVersion:  property.EventVersion(),
+		Amount:      600,
+		Description: "cleaning",
+		Method:      models.NIGHTS,
+		Shares:      shares(me.UserID(), jane.UserID(), bob.UserID()),
+		StartDate:   &startDate,
+		EndDate:     &endDate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for userID, expected := range map[string]int32{me.UserID(): -400, jane.UserID(): -200, bob.UserID(): -750} {
+		if amount := lastRecord(userID).amountInternal().Raw(); amount != expected {
+			t.Fatalf("expected a last amount of %v but got %v", expected, amount)
+		}
+	}
+
+	t.Log("bad splits are rejected")
+	for _, bad := range []*models.SplitExpenseInput{
+		{Amount: 100, Description: "x", Method: models.EQUAL, Shares: shares(me.UserID())},
+		{Amount: 100, Description: "repairs", Method: models.EQUAL, Shares: shares()},
+		{Amount: 100, Description: "repairs", Method: models.EQUAL, Shares: shares(me.UserID(), me.UserID())},
+		{Amount: 100, Description: "repairs", Method: models.EQUAL, Shares: shares("nobody")},
+		{Amount: 100, Description: "repairs", Method: models.WEIGHTS, Shares: shares(me.UserID())},
+		{Amount: 100, Description: "repairs", Method: models.NIGHTS, Shares: shares(me.UserID())},
+		{Amount: 300000, Description: "repairs", Method: models.EQUAL, Shares: shares(me.UserID(), jane.UserID())},
+	} {
+		bad.ForVersion = property.EventVersion()
+		if _, err := splitExpense(bad); err == nil {
+			t.Fatalf("expected an error for %+v", bad)
+		}
+	}
+
+	t.Log("a split and a balance update sent for the same stale version are not duplicates")
+	staleVersion := property.EventVersion()
+	property = createPayment(ctx, t, resolver, property, 10, true, staleVersion)
+	firewood := &models.SplitExpenseInput{
+		ForVersion:  staleVersion,
+		Amount:      30,
+		Description: "firewood",
+		Method:      models.EQUAL,
+		Shares:      shares(jane.UserID(), bob.UserID()),
+	}
+	version = property.EventVersion()
+	if property, err = splitExpense(firewood); err != nil {
+		t.Fatal(err)
+	}
+	if property.EventVersion() == version || lastRecord(jane.UserID()).amountInternal().Raw() != -15 {
+		t.Fatal("expected the split for a stale version to be committed")
+	}
+	staleVersion = property.EventVersion()
+	firewood.ForVersion = staleVersion
+	if property, err = splitExpense(firewood); err != nil {
+		t.Fatal(err)
+	}
+	version = property.EventVersion()
+	property = createPayment(ctx, t, resolver, property, 20, true, staleVersion)
+	if property.EventVersion() == version || lastRecord(me.UserID()).amountInternal().Raw() != 20 {
+		t.Fatal("expected the balance update for a stale version to be committed")
+	}
+}
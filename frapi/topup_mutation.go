@@ -0,0 +1,187 @@
+package frapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/frdate"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/templates"
+	"github.com/bjorge/friendlyreservations/utilities"
+)
+
+const checkoutSessionGQL = `
+# a payment page at the payment provider
+type CheckoutSession {
+	sessionId: String!
+	# send the user here to pay
+	url: String!
+}
+`
+
+// topUpDescription is the ledger description of an online payment
+const topUpDescription = "Online payment"
+
+// metadata keys of a top-up checkout session
+const (
+	topUpPropertyIDKey = "propertyId"
+	topUpUserIDKey     = "userId"
+)
+
+// OnlinePayments is called by the GQL framework, true if balances can be topped up online
+func (r *PropertyResolver) OnlinePayments() bool {
+	return PaymentProvider != nil
+}
+
+// StartTopUp is called by a user to pay an amount towards their balance online, the balance
+// is updated when the payment provider calls the webhook, see ReceivePayment
+func (r *Resolver) StartTopUp(ctx context.Context, args *struct {
+	PropertyID string
+	Amount     int32
+}) (*CheckoutSessionResolver, error) {
+	Logger.LogDebugf("Start Top Up")
+
+	if PaymentProvider == nil {
+		return nil, errors.New("online payments are not configured")
+	}
+
+	// get the current property
+	property, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return nil, err
+	}
+	if me.IsSystem() {
+		return nil, errors.New("the system user cannot pay")
+	}
+
+	constraints, err := property.UpdateBalanceConstraints()
+	if err != nil {
+		return nil, err
+	}
+	if args.Amount < constraints.AmountMin() {
+		return nil, errors.New("amount too small")
+	}
+	if args.Amount > constraints.AmountMax() {
+		return nil, errors.New("amount too big")
+	}
+
+	settings, err := property.Settings(&settingsArgs{})
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := PaymentProvider.CreateCheckoutSession(ctx, &platform.CheckoutRequest{
+		Amount:        args.Amount,
+		Currency:      string(settings.settings.Currency),
+		Description:   fmt.Sprintf("%v balance for %v", settings.PropertyName(), me.Nickname()),
+		CustomerEmail: me.Email(),
+		SuccessURL:    notificationLinks()["Ledger"],
+		CancelURL:     notificationLinks()["Ledger"],
+		Metadata: map[string]string{
+			topUpPropertyIDKey: args.PropertyID,
+			topUpUserIDKey:     me.UserID(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckoutSessionResolver{session}, nil
+}
+
+// CheckoutSessionResolver resolves a checkout session
+type CheckoutSessionResolver struct {
+	session *platform.CheckoutSession
+}
+
+// SessionID is the id of the session at the payment provider
+func (r *CheckoutSessionResolver) SessionID() string {
+	return r.session.ID
+}
+
+// URL is the payment page
+func (r *CheckoutSessionResolver) URL() string {
+	return r.session.URL
+}
+
+// ReceivePayment is called by the service with a payment provider webhook call, a completed top-up
+// is recorded as a payment to the balance of the user, a payment received twice is only recorded once
+func ReceivePayment(ctx context.Context, payload []byte, header http.Header) error {
+	if PaymentProvider == nil {
+		return errors.New("online payments are not configured")
+	}
+
+	payment, err := PaymentProvider.VerifyWebhook(payload, header)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		// not a completed payment
+		return nil
+	}
+
+	propertyID := payment.Metadata[topUpPropertyIDKey]
+	userID := payment.Metadata[topUpUserIDKey]
+	if propertyID == "" || userID == "" {
+		return fmt.Errorf("payment %v is not a top-up", payment.ID)
+	}
+
+	property, err := currentBaseProperty(ctx, utilities.SystemEmail, propertyID)
+	if err != nil {
+		return err
+	}
+
+	// the provider may call the webhook more than once
+	if property.balanceUpdate(payment.ID) != nil {
+		Logger.LogInfof("ReceivePayment: payment %v already recorded", payment.ID)
+		return nil
+	}
+
+	settings, err := property.Settings(&settingsArgs{})
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(payment.Currency, string(settings.settings.Currency)) {
+		return fmt.Errorf("payment %v is in %v, not the property currency", payment.ID, payment.Currency)
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("payment %v has no amount", payment.ID)
+	}
+
+	users := property.Users(&usersArgs{UserID: &userID})
+	if len(users) != 1 {
+		return fmt.Errorf("payment %v is for an unknown user", payment.ID)
+	}
+
+	me, err := property.Me()
+	if err != nil {
+		return err
+	}
+
+	updateBalanceInput := &models.UpdateBalanceInput{
+		UpdateForUserId: userID,
+		Amount:          payment.Amount,
+		Description:     topUpDescription,
+		Increase:        true,
+		CreateDateTime:  frdate.CreateDateTimeUTC(),
+		AuthorUserId:    me.UserID(),
+		PaymentId:       payment.ID,
+	}
+
+	paramGroup := templates.Ledger
+	newNotificationInput := createNotificationRecord(notificationTargetMember, property, templates.BalanceChangeNotification,
+		&userID, &paramGroup, &userID)
+
+	// a webhook call recording the payment at the same time makes the commit fail, the provider
+	// then calls again and finds the payment recorded
+	updated, err := commitSystemChanges(ctx, propertyID, property.EventVersion(), updateBalanceInput, newNotificationInput)
+	if err != nil {
+		return err
+	}
+	deliverNotification(ctx, updated, newNotificationInput.NotificationId)
+	return nil
+}
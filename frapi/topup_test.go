@@ -0,0 +1,138 @@
+package frapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/local_platform"
+	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/templates"
+)
+
+// overlappingEvents runs overlap once just before the first commit starts, like a second webhook call
+// recording the payment between the check and the commit of the first call
+type overlappingEvents struct {
+	platform.PersistedVersionedEvents
+	overlap func()
+}
+
+func (r *overlappingEvents) runOverlap() {
+	if overlap := r.overlap; overlap != nil {
+		r.overlap = nil
+		overlap()
+	}
+}
+
+func (r *overlappingEvents) GetNextEventID(ctx context.Context, propertyID string, inTransaction bool) (int, error) {
+	r.runOverlap()
+	return r.PersistedVersionedEvents.GetNextEventID(ctx, propertyID, inTransaction)
+}
+
+func (r *overlappingEvents) NewPropertyEvents(ctx context.Context, propertyID string, transactionKey int,
+	events []platform.VersionedEvent, inTransaction bool) (int, error) {
+	r.runOverlap()
+	return r.PersistedVersionedEvents.NewPropertyEvents(ctx, propertyID, transactionKey, events, inTransaction)
+}
+
+func TestTopUp(t *testing.T) {
+	property, ctx, resolver, me, _ := initAndCreateTestProperty(context.Background(), t)
+
+	startTopUp := func(amount int32) (*CheckoutSessionResolver, error) {
+		return resolver.StartTopUp(ctx, &struct {
+			PropertyID string
+			Amount     int32
+		}{
+			PropertyID: property.PropertyID(),
+			Amount:     amount,
+		})
+	}
+
+	t.Log("online payments are not configured")
+	if property.OnlinePayments() {
+		t.Fatal("expected online payments to be off")
+	}
+	if _, err := startTopUp(2500); err == nil {
+		t.Fatal("expected an error without a payment provider")
+	}
+
+	provider := localplatform.NewPaymentProvider()
+	PaymentProvider = provider
+	defer func() { PaymentProvider = nil }()
+
+	t.Log("amounts are checked")
+	if _, err := startTopUp(0); err == nil {
+		t.Fatal("expected an error for no amount")
+	}
+
+	session, err := startTopUp(2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.SessionID() == "" || session.URL() == "" {
+		t.Fatal("expected a checkout session")
+	}
+
+	t.Log("the webhook records the payment")
+	payload, header, err := provider.CompletePayment(session.SessionID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReceivePayment(ctx, payload, header); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	record, _ := checkLedger(ctx, t, property, me.UserID(), 2, paymentLedgerEvent, 2500, 2500)
+	event := (*record.rollup.VersionedEvent).(*models.UpdateBalanceInput)
+	if event.PaymentId != "pi_"+session.SessionID() || event.Description != topUpDescription {
+		t.Fatalf("unexpected payment %+v", event)
+	}
+
+	notifications, _ := property.Notifications(&notificationArgs{})
+	if len(notifications) == 0 || notifications[len(notifications)-1].TemplateName() != string(templates.BalanceChangeNotification) {
+		t.Fatal("expected a balance change notification")
+	}
+
+	t.Log("a payment received twice is recorded once")
+	version := property.EventVersion()
+	if err := ReceivePayment(ctx, payload, header); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	if property.EventVersion() != version {
+		t.Fatal("expected the duplicate payment to be ignored")
+	}
+	checkLedger(ctx, t, property, me.UserID(), 2, paymentLedgerEvent, 2500, 2500)
+
+	t.Log("overlapping webhook calls record the payment once")
+	session, err = startTopUp(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, header, err = provider.CompletePayment(session.SessionID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	persisted := PersistedVersionedEvents
+	defer func() { PersistedVersionedEvents = persisted }()
+	PersistedVersionedEvents = &overlappingEvents{PersistedVersionedEvents: persisted, overlap: func() {
+		if err := ReceivePayment(ctx, payload, header); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	if err := ReceivePayment(ctx, payload, header); err == nil {
+		t.Fatal("expected the overlapping call to fail")
+	}
+	PersistedVersionedEvents = persisted
+	if err := ReceivePayment(ctx, payload, header); err != nil {
+		t.Fatal(err)
+	}
+	property = getUpdatedProperty(ctx, t, resolver)
+	checkLedger(ctx, t, property, me.UserID(), 3, paymentLedgerEvent, 3500, 1000)
+
+	t.Log("bad signatures are rejected")
+	header.Set("Local-Signature", "bad")
+	if err := ReceivePayment(ctx, payload, header); err == nil {
+		t.Fatal("expected an error for a bad signature")
+	}
+}
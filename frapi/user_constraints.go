@@ -3,6 +3,7 @@ package frapi
 import (
 	"context"
 
+	"github.com/bjorge/friendlyreservations/models"
 	"github.com/bjorge/friendlyreservations/utilities"
 )
 
@@ -15,6 +16,8 @@ type UpdateUserConstraints {
 	emailMax: Int!
 	invalidNicknames: [String]!
 	invalidEmails: [String]!
+	# the roles the current user is allowed to grant
+	grantableRoles: [UserRole!]!
 }
 `
 
@@ -22,6 +25,7 @@ type UpdateUserConstraints {
 type UpdateUserConstraints struct {
 	args  *UpdateUserConstraintsArgs
 	users []*UserResolver
+	me    *UserResolver
 }
 
 // UpdateUserConstraintsArgs holds the arguments for the UpdateUserConstraints GQL call
@@ -33,7 +37,9 @@ type UpdateUserConstraintsArgs struct {
 func (r *PropertyResolver) UpdateUserConstraints(ctx context.Context, args *UpdateUserConstraintsArgs) (*UpdateUserConstraints, error) {
 
 	users := r.Users(&usersArgs{})
-	return &UpdateUserConstraints{args, users}, nil
+	// me is not required, ex. during property creation
+	me, _ := r.Me()
+	return &UpdateUserConstraints{args, users, me}, nil
 }
 
 // UpdateUserConstraints returns input constraints for updating user
@@ -95,3 +101,11 @@ func (r *UpdateUserConstraints) InvalidEmails() []*string {
 	}
 	return emails
 }
+
+// GrantableRoles is the list of roles the current user can grant to a user
+func (r *UpdateUserConstraints) GrantableRoles() []models.UserRole {
+	if r.me == nil {
+		return []models.UserRole{}
+	}
+	return r.me.grantableRoles()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bjorge/friendlyreservations/frdate"
 	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
 	"github.com/bjorge/friendlyreservations/utilities"
 )
 
@@ -74,8 +75,14 @@ func (r *Resolver) CreateUser(ctx context.Context, args *struct {
 	}
 
 	// only an admin can add a user
-	if !me.IsAdmin() {
-		return nil, errors.New("only an admin can create a user")
+	if err := authorize(me, manageUsersPermission); err != nil {
+		return nil, err
+	}
+
+	if args.Input.Roles != nil {
+		if err := validateRoles(*args.Input.Roles, constraints); err != nil {
+			return nil, err
+		}
 	}
 
 	// update the request with more information
@@ -124,8 +131,8 @@ func (r *Resolver) UpdateUser(ctx context.Context, args *struct {
 	}
 
 	// only an admin can update a user
-	if !me.IsAdmin() {
-		return nil, errors.New("only an admin can update a user")
+	if err := authorize(me, manageUsersPermission); err != nil {
+		return nil, err
 	}
 
 	users := propertyResolver.Users(&usersArgs{UserID: &args.UserID})
@@ -142,6 +149,12 @@ func (r *Resolver) UpdateUser(ctx context.Context, args *struct {
 		return nil, err
 	}
 
+	if args.Input.Roles != nil {
+		if err := validateRoles(*args.Input.Roles, constraints); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(args.Input.Nickname) < int(constraints.NicknameMin()) {
 		return nil, errors.New("nickname too short: " + args.Input.Nickname)
 	}
@@ -276,8 +289,8 @@ func (r *Resolver) UpdateSystemUser(ctx context.Context, args *struct {
 	}
 
 	// only an admin can update a user
-	if !me.IsAdmin() {
-		return nil, errors.New("only an admin can update a user")
+	if err := authorize(me, manageUsersPermission); err != nil {
+		return nil, err
 	}
 
 	users := propertyResolver.Users(&usersArgs{UserID: &args.UserID})
@@ -347,3 +360,97 @@ func (r *Resolver) UpdateSystemUser(ctx context.Context, args *struct {
 	// persist the event
 	return commitChanges(ctx, args.PropertyID, propertyResolver.EventVersion(), args.Input)
 }
+
+// EraseUser is called by an admin or the user to erase the personal information of a user
+func (r *Resolver) EraseUser(ctx context.Context, args *struct {
+	PropertyID string
+	Input      *models.EraseUserInput
+}) (bool, error) {
+	Logger.LogDebugf("Erase User")
+
+	propertyResolver, me, err := currentProperty(ctx, args.PropertyID)
+	if err != nil {
+		return false, err
+	}
+
+	// check the input values and for duplicates
+	if duplicate, err := isDuplicate(ctx, args.Input, propertyResolver); duplicate || err != nil {
+		if err == nil {
+			return true, nil
+		}
+		return false, err
+	}
+
+	// a user can erase themselves, otherwise only an admin can erase a user
+	if me.UserID() != args.Input.UserID {
+		if err := authorize(me, manageUsersPermission); err != nil {
+			return false, err
+		}
+	}
+
+	users := propertyResolver.Users(&usersArgs{UserID: &args.Input.UserID})
+	if len(users) == 0 {
+		return false, errors.New("target user does not exist")
+	}
+	user := users[0]
+
+	if user.IsSystem() {
+		return false, errors.New("cannot erase the system user")
+	}
+
+	if user.isErased() {
+		return false, errors.New("user has already been erased")
+	}
+
+	// do not leave the property without an admin
+	if user.IsAdmin() {
+		otherAdmins := 0
+		for _, other := range propertyResolver.Users(&usersArgs{}) {
+			if other.UserID() != user.UserID() && other.IsAdmin() && other.State() == models.ACCEPTED {
+				otherAdmins++
+			}
+		}
+		if otherAdmins == 0 {
+			return false, errors.New("cannot erase the last admin")
+		}
+	}
+
+	// delete the pii first, a retry after a failed commit will delete nothing
+	if err := PersistedEmailStore.DeleteEmail(ctx, args.PropertyID, user.emailID()); err != nil {
+		return false, err
+	}
+
+	// update the request with more information
+	args.Input.CreateDateTime = frdate.CreateDateTimeUTC()
+	args.Input.AuthorUserID = me.UserID()
+
+	// persist the event, the property is not returned since users can erase themselves
+	key := int(propertyResolver.EventVersion()) + 1
+	if _, err := PersistedVersionedEvents.NewPropertyEvents(ctx, args.PropertyID, key, []platform.VersionedEvent{args.Input}, false); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateRoles checks that each role can be granted and is only listed once
+func validateRoles(roles []models.UserRole, constraints *UpdateUserConstraints) error {
+	seen := make(map[models.UserRole]bool)
+	for _, role := range roles {
+		if seen[role] {
+			return fmt.Errorf("role listed more than once: %+v", role)
+		}
+		seen[role] = true
+
+		grantable := false
+		for _, grantableRole := range constraints.GrantableRoles() {
+			if role == grantableRole {
+				grantable = true
+			}
+		}
+		if !grantable {
+			return fmt.Errorf("role cannot be granted: %+v", role)
+		}
+	}
+	return nil
+}
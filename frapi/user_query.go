@@ -15,6 +15,11 @@ enum UserState {
 	DECLINED
 }
 
+enum UserRole {
+	TREASURER
+	SCHEDULER
+}
+
 type User {
 	# the user id
 	userId: String!
@@ -26,6 +31,8 @@ type User {
 	isMember: Boolean!
 	# whether this user is a system user
 	isSystem: Boolean!
+	# the roles granted to this user in addition to admin or member
+	roles: [UserRole!]!
 	# the current state of the user
 	state: UserState!
 	# the name of the user
@@ -106,6 +113,14 @@ func (r *UserResolver) IsSystem() bool {
 	return r.rollup.IsSystem
 }
 
+// Roles are the additional roles granted to the user
+func (r *UserResolver) Roles() []models.UserRole {
+	if r.rollup.Roles == nil {
+		return []models.UserRole{}
+	}
+	return r.rollup.Roles
+}
+
 // State is the user state
 func (r *UserResolver) State() models.UserState {
 	return r.rollup.State
@@ -116,6 +131,10 @@ func (r *UserResolver) Nickname() string {
 	return r.rollup.Nickname
 }
 
+func (r *UserResolver) isErased() bool {
+	return r.rollup.IsErased
+}
+
 func (r *UserResolver) emailID() string {
 	return r.rollup.EmailID
 }
@@ -2,6 +2,8 @@ package frapi
 
 import (
 	"github.com/bjorge/friendlyreservations/models"
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/templates"
 )
 
 // UserRollup is the user event rollup structure
@@ -13,7 +15,32 @@ type UserRollup struct {
 	State        models.UserState
 	Nickname     string
 	EmailID      string
+	Roles        []models.UserRole
+	IsErased     bool
 	EventVersion int32
+
+	// NotificationPreferences are the preferences set by the user, missing means EMAIL_IMMEDIATELY
+	NotificationPreferences map[templates.TemplateName]models.NotificationPreference
+}
+
+// formerMemberNickname is shown instead of the nickname of an erased user
+const formerMemberNickname = "Former member"
+
+// eraseUserRollup removes personal information from a user rollup
+func eraseUserRollup(userRollup *UserRollup) {
+	userRollup.Nickname = formerMemberNickname
+	userRollup.EmailID = ""
+}
+
+// erasedUserIDsOf returns the ids of the users erased by the events
+func erasedUserIDsOf(events []platform.VersionedEvent) map[string]bool {
+	erasedUserIDs := make(map[string]bool)
+	for _, event := range events {
+		if eraseEvent, ok := event.(*models.EraseUserInput); ok {
+			erasedUserIDs[eraseEvent.UserID] = true
+		}
+	}
+	return erasedUserIDs
 }
 
 // GetEventVersion returns the version of the rollup record
@@ -28,6 +55,12 @@ func (r *PropertyResolver) rollupUsers() {
 
 	if !r.rollupsExists(userRollupType) {
 
+		// erased users are shown as former members in all versions
+		erasedUserIDs := r.erasedUserIDs
+		if erasedUserIDs == nil {
+			erasedUserIDs = erasedUserIDsOf(r.property.Events)
+		}
+
 		for _, event := range r.property.Events {
 
 			switch userEvent := event.(type) {
@@ -40,11 +73,18 @@ func (r *PropertyResolver) rollupUsers() {
 				userRollup.IsMember = userEvent.IsMember
 				userRollup.State = userEvent.State
 				userRollup.Nickname = userEvent.Nickname
+				if userEvent.Roles != nil {
+					userRollup.Roles = *userEvent.Roles
+				}
 
 				userRollup.EmailID = userEvent.EmailId
 
 				userRollup.EventVersion = userEvent.EventVersion
 
+				if erasedUserIDs[userRollup.UserID] {
+					eraseUserRollup(userRollup)
+				}
+
 				r.addRollup(userEvent.UserId,
 					userRollup, userRollupType)
 
@@ -63,11 +103,18 @@ func (r *PropertyResolver) rollupUsers() {
 						userRollup.IsMember = userEvent.IsMember
 						userRollup.State = userEvent.State
 						userRollup.Nickname = userEvent.Nickname
+						if userEvent.Roles != nil {
+							userRollup.Roles = *userEvent.Roles
+						}
 
 						userRollup.EmailID = userEvent.EmailId
 
 						userRollup.EventVersion = userEvent.EventVersion
 
+						if erasedUserIDs[userRollup.UserID] {
+							eraseUserRollup(&userRollup)
+						}
+
 						r.addRollup(userEvent.UserId,
 							&userRollup, userRollupType)
 					}
@@ -85,6 +132,7 @@ func (r *PropertyResolver) rollupUsers() {
 						userRollup.IsSystem = true
 						userRollup.IsAdmin = false
 						userRollup.IsMember = false
+						userRollup.Roles = nil
 						userRollup.State = models.ACCEPTED
 						userRollup.Nickname = userEvent.Nickname
 
@@ -92,6 +140,10 @@ func (r *PropertyResolver) rollupUsers() {
 
 						userRollup.EventVersion = userEvent.EventVersion
 
+						if erasedUserIDs[userRollup.UserID] {
+							eraseUserRollup(&userRollup)
+						}
+
 						r.addRollup(userEvent.UserID,
 							&userRollup, userRollupType)
 					}
@@ -117,6 +169,46 @@ func (r *PropertyResolver) rollupUsers() {
 							&userRollup, userRollupType)
 					}
 				}
+			case *models.UpdateNotificationPreferenceInput:
+				rollups := r.getRollups(&rollupArgs{id: &userEvent.AuthorUserId}, userRollupType)
+				if len(rollups) > 0 {
+					rollup := rollups[0]
+					if user, ok := rollup.(*UserRollup); ok {
+						// make a copy, including the preferences
+						userRollup := *user
+						userRollup.NotificationPreferences = make(map[templates.TemplateName]models.NotificationPreference)
+						for name, preference := range user.NotificationPreferences {
+							userRollup.NotificationPreferences[name] = preference
+						}
+						userRollup.NotificationPreferences[userEvent.TemplateName] = userEvent.Preference
+
+						userRollup.EventVersion = userEvent.EventVersion
+
+						r.addRollup(userEvent.AuthorUserId,
+							&userRollup, userRollupType)
+					}
+				}
+			case *models.EraseUserInput:
+				rollups := r.getRollups(&rollupArgs{id: &userEvent.UserID}, userRollupType)
+				if len(rollups) > 0 {
+					rollup := rollups[0]
+					if user, ok := rollup.(*UserRollup); ok {
+						// make a copy
+						userRollup := *user
+
+						eraseUserRollup(&userRollup)
+						userRollup.IsAdmin = false
+						userRollup.IsMember = false
+						userRollup.Roles = nil
+						userRollup.State = models.DISABLED
+						userRollup.IsErased = true
+
+						userRollup.EventVersion = userEvent.EventVersion
+
+						r.addRollup(userEvent.UserID,
+							&userRollup, userRollupType)
+					}
+				}
 
 			}
 
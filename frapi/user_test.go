@@ -2,7 +2,9 @@ package frapi
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/bjorge/friendlyreservations/models"
@@ -182,3 +184,118 @@ func singleUserMaxVersion(userID string, maxVersion int32) *usersArgs {
 func byEmail(email string) *usersArgs {
 	return &usersArgs{Email: &email}
 }
+
+func TestEraseUser(t *testing.T) {
+	property, ctx, resolver, me, today := initAndCreateTestProperty(context.Background(), t)
+
+	email := "erase@test.com"
+	property, user := createUserWithRoles(ctx, t, resolver, property, email, "erased", []models.UserRole{})
+	userID := user.UserID()
+
+	t.Log("create a reservation for the user so events reference the user")
+	testUserEmail = email
+	property, err := resolver.AcceptInvitation(ctx, &struct {
+		PropertyID string
+		Input      *models.AcceptInvitationInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.AcceptInvitationInput{ForVersion: property.EventVersion(), Accept: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	property, _ = createReservation(ctx, t, resolver, property, userID, today.AddDays(1).ToString(), today.AddDays(2).ToString())
+	testUserEmail = defaultEmail
+	property = getUpdatedProperty(ctx, t, resolver)
+
+	t.Log("export the personal data")
+	msg, err := resolver.personalDataExportInternal(ctx, property, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Attachments) != 1 {
+		t.Fatalf("expected 1 attachment")
+	}
+	export := &struct {
+		Events        []map[string]interface{}
+		Notifications []personalDataNotification
+	}{}
+	if err := json.Unmarshal(msg.Attachments[0].Data, export); err != nil {
+		t.Fatal(err)
+	}
+	// create user, accept, reservation and notification events
+	if len(export.Events) < 3 || len(export.Notifications) == 0 {
+		t.Fatalf("expected events and notifications but got %+v events and %+v notifications", len(export.Events), len(export.Notifications))
+	}
+
+	t.Log("the admin export only has the admin's fields of the events about other users")
+	msg, err = resolver.personalDataExportInternal(ctx, property, me)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(msg.Attachments[0].Data, export); err != nil {
+		t.Fatal(err)
+	}
+	adminEvents, err := json.Marshal(export.Events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(adminEvents), userID) || strings.Contains(string(adminEvents), `"erased"`) {
+		t.Fatalf("expected the admin export to not contain the data of the other user but got %+v", string(adminEvents))
+	}
+
+	t.Log("the last admin cannot be erased")
+	adminID := me.UserID()
+	_, err = resolver.EraseUser(ctx, &struct {
+		PropertyID string
+		Input      *models.EraseUserInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.EraseUserInput{ForVersion: property.EventVersion(), UserID: adminID},
+	})
+	if err == nil {
+		t.Fatal("expected erasing the last admin to fail")
+	}
+
+	t.Log("erase the user")
+	beforeErase := property.EventVersion()
+	erased, err := resolver.EraseUser(ctx, &struct {
+		PropertyID string
+		Input      *models.EraseUserInput
+	}{
+		PropertyID: property.PropertyID(),
+		Input:      &models.EraseUserInput{ForVersion: property.EventVersion(), UserID: userID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !erased {
+		t.Fatal("expected the user to be erased")
+	}
+
+	if exists, _ := PersistedEmailStore.EmailExists(ctx, property.PropertyID(), email); *exists {
+		t.Fatal("expected the email to be deleted")
+	}
+
+	property = getUpdatedProperty(ctx, t, resolver)
+	users := property.Users(singleUser(userID))
+	if users[0].Nickname() != formerMemberNickname || users[0].Email() != "" || users[0].State() != models.DISABLED {
+		t.Fatalf("expected a former member but got %+v", users[0].Nickname())
+	}
+
+	t.Log("earlier versions also show a former member")
+	reservations, _ := property.Reservations(&reservationsArgs{})
+	if reservations[0].ReservedFor().Nickname() != formerMemberNickname {
+		t.Fatalf("expected reservation for a former member but got %+v", reservations[0].ReservedFor().Nickname())
+	}
+
+	t.Log("a version before the erase also shows a former member")
+	historical, err := resolver.Property(ctx, &propertyArgs{ID: property.PropertyID(), AsOfVersion: &beforeErase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users = historical.Users(singleUser(userID))
+	if users[0].Nickname() != formerMemberNickname || users[0].Email() != "" {
+		t.Fatalf("expected a former member as of version %+v but got %+v", beforeErase, users[0].Nickname())
+	}
+}
@@ -71,6 +71,12 @@ func (r *DateBuilder) NewDate(iso8601short string) (*Date, error) {
 	return &Date{t}, nil
 }
 
+// MustNewLocalDate produces the Date in the location of the builder of the date-time string, or panics
+func (r *DateBuilder) MustNewLocalDate(rfc3339datetime string) *Date {
+	dateTime := r.MustNewDateTime(rfc3339datetime)
+	return r.MustNewDate(dateTime.t.In(&r.loc).Format(iso8601format))
+}
+
 // MustNewDate produces a Date given the date string, or panics
 func (r *DateBuilder) MustNewDate(iso8601short string) *Date {
 	t, err := time.ParseInLocation(iso8601format, iso8601short, &r.loc)
@@ -116,6 +122,11 @@ func (r *Date) Year() int {
 	return r.t.Year()
 }
 
+// Month returns the month portion of a Date, 1 for January
+func (r *Date) Month() int {
+	return int(r.t.Month())
+}
+
 // ToString returns the iso string representation of a Date
 func (r *Date) ToString() string {
 	return r.t.Format(iso8601format)
@@ -8,6 +8,8 @@ import (
 	"github.com/bjorge/friendlyreservations/frapi"
 	"github.com/bjorge/friendlyreservations/gae_platform"
 	"github.com/bjorge/friendlyreservations/logger"
+	"github.com/bjorge/friendlyreservations/notifier_platform"
+	"github.com/bjorge/friendlyreservations/payment_platform"
 	graphql "github.com/graph-gophers/graphql-go"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -49,6 +51,12 @@ func init() {
 	frapi.PersistedPropertyList = gaeplatform.NewPersistedPropertyList()
 	frapi.EmailSender = gaeplatform.NewEmailSender()
 
+	var err error
+	if frapi.Notifiers, frapi.PushPublicKey, err = notifierplatform.NotifiersFromSettings(); err != nil {
+		panic(err)
+	}
+	frapi.PaymentProvider = paymentplatform.ProviderFromSettings()
+
 	adminSchema = graphql.MustParseSchema(frapi.AdminSchema, &frapi.Resolver{})
 	memberSchema = graphql.MustParseSchema(frapi.MemberSchema, &frapi.Resolver{})
 	homeSchema = graphql.MustParseSchema(frapi.HomeSchema, &frapi.Resolver{})
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bjorge/friendlyreservations/cookies"
 	"github.com/bjorge/friendlyreservations/frapi"
@@ -80,6 +82,62 @@ func main() {
 		}
 	}))
 
+	// handle the outbox retries
+	http.Handle("/outbox", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cronHeaders := r.Header["X-Appengine-Cron"]
+		if len(cronHeaders) == 0 {
+			log.LogDebugf("/outbox called but not by appengine, so just return")
+			return
+		}
+
+		ctx := appengine.NewContext(r)
+		ctx, err := appengine.Namespace(ctx, namespace)
+		if err != nil {
+			panic(err)
+		}
+		log.LogInfof("Run outbox in namespace %v", namespace)
+		err = frapi.DeliverOutbox(ctx)
+		if err != nil {
+			log.LogErrorf("Outbox error: %+v", err)
+		}
+	}))
+
+	// handle replies to notification emails, app engine posts inbound mail to /_ah/mail/<address>
+	http.Handle("/_ah/mail/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := appengine.NewContext(r)
+		ctx, err := appengine.Namespace(ctx, namespace)
+		if err != nil {
+			panic(err)
+		}
+		recipient := strings.TrimPrefix(r.URL.Path, "/_ah/mail/")
+		if err := frapi.ReceiveEmail(ctx, recipient, io.LimitReader(r.Body, 1<<20)); err != nil {
+			log.LogWarningf("Inbound mail error: %+v", err)
+		}
+	}))
+
+	// handle the payment provider webhook, ex. https://myapp.appspot.com/paymentwebhook for stripe
+	http.Handle("/paymentwebhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<16))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		ctx := appengine.NewContext(r)
+		ctx, err = appengine.Namespace(ctx, namespace)
+		if err != nil {
+			panic(err)
+		}
+		if err := frapi.ReceivePayment(ctx, payload, r.Header); err != nil {
+			// the provider retries the call
+			log.LogWarningf("Payment webhook error: %+v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+	}))
+
 	// handle the graphql requests
 	for uri, schema := range map[string]*graphql.Schema{
 		"/homequery":   homeSchema,
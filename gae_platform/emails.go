@@ -17,6 +17,13 @@ type PersistedEmail struct {
 	PropertyID string `datastore:"PropertyId"` // legacy name
 }
 
+// PersistedContact is the structure used to store a notification channel contact of an email id
+type PersistedContact struct {
+	EmailID string `datastore:"EmailId"`
+	Channel string `datastore:"Channel"`
+	Contact string `datastore:"Contact,noindex"`
+}
+
 // BUG(bjorge): change array items []PersistedEmail to array of pointers
 
 type dataStoreEmailImpl struct{}
@@ -28,6 +35,8 @@ func NewPersistedEmailStore() platform.PersistedEmailStore {
 
 var persistedEmailsKind = "PERSISTED_EMAILS_KIND"
 
+var persistedContactsKind = "PERSISTED_CONTACTS_KIND"
+
 var emailKeyDelimiter = ":"
 
 var emailKeyPrefix = "EMAIL_KEY"
@@ -169,16 +178,18 @@ func (r *dataStoreEmailImpl) DeleteEmails(ctx context.Context, propertyID string
 	}
 
 	keys := []*datastore.Key{}
-	query := datastore.NewQuery(persistedEmailsKind).Ancestor(parentKey).KeysOnly()
-	for iterator := query.Run(ctx); ; {
-		key, err := iterator.Next(nil)
-		if err == datastore.Done {
-			break
-		}
-		if err != nil {
-			return err
+	for _, kind := range []string{persistedEmailsKind, persistedContactsKind} {
+		query := datastore.NewQuery(kind).Ancestor(parentKey).KeysOnly()
+		for iterator := query.Run(ctx); ; {
+			key, err := iterator.Next(nil)
+			if err == datastore.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
 		}
-		keys = append(keys, key)
 	}
 
 	for _, key := range keys {
@@ -189,6 +200,31 @@ func (r *dataStoreEmailImpl) DeleteEmails(ctx context.Context, propertyID string
 
 }
 
+func (r *dataStoreEmailImpl) DeleteEmail(ctx context.Context, propertyID string, emailID string) error {
+
+	parentKey, err := propertyParentKey(ctx, propertyID)
+	if err != nil {
+		return err
+	}
+
+	keys := []*datastore.Key{}
+	for _, kind := range []string{persistedEmailsKind, persistedContactsKind} {
+		query := datastore.NewQuery(kind).Ancestor(parentKey).Filter("EmailId =", emailID).KeysOnly()
+		for iterator := query.Run(ctx); ; {
+			key, err := iterator.Next(nil)
+			if err == datastore.Done {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	return datastore.DeleteMulti(ctx, keys)
+}
+
 func (r *dataStoreEmailImpl) CreateEmail(ctx context.Context, propertyID string, email string) (string, error) {
 
 	existingEmail, err := r.GetEmail(ctx, propertyID, email)
@@ -214,3 +250,49 @@ func (r *dataStoreEmailImpl) CreateEmail(ctx context.Context, propertyID string,
 		return "", err
 	}
 }
+
+func (r *dataStoreEmailImpl) SetContact(ctx context.Context, propertyID string, emailID string, channel platform.NotificationChannel, contact string) error {
+	parentKey, err := propertyParentKey(ctx, propertyID)
+	if err != nil {
+		return err
+	}
+
+	emailKeys, err := datastore.NewQuery(persistedEmailsKind).Ancestor(parentKey).Filter("EmailId =", emailID).KeysOnly().GetAll(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if len(emailKeys) == 0 {
+		return errors.New("email does not exist")
+	}
+
+	key := datastore.NewKey(ctx, persistedContactsKind, emailID+emailKeyDelimiter+string(channel), 0, parentKey)
+	if contact == "" {
+		err = datastore.Delete(ctx, key)
+		if err == datastore.ErrNoSuchEntity {
+			return nil
+		}
+		return err
+	}
+
+	record := &PersistedContact{EmailID: emailID, Channel: string(channel), Contact: contact}
+	_, err = datastore.Put(ctx, key, record)
+	return err
+}
+
+func (r *dataStoreEmailImpl) GetContacts(ctx context.Context, propertyID string, emailID string) (map[platform.NotificationChannel]string, error) {
+	parentKey, err := propertyParentKey(ctx, propertyID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*PersistedContact{}
+	if _, err := datastore.NewQuery(persistedContactsKind).Ancestor(parentKey).Filter("EmailId =", emailID).GetAll(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	contacts := make(map[platform.NotificationChannel]string)
+	for _, record := range records {
+		contacts[platform.NotificationChannel(record.Channel)] = record.Contact
+	}
+	return contacts, nil
+}
@@ -15,7 +15,9 @@ func TestEmail(t *testing.T) {
 	defer done()
 
 	platformtesting.TestCreateEmail(ctx, t, NewPersistedEmailStore())
+	platformtesting.TestDeleteSingleEmail(ctx, t, NewPersistedEmailStore())
 	platformtesting.TestDeleteEmail(ctx, t, NewPersistedEmailStore())
 	platformtesting.TestRestoreEmail(ctx, t, NewPersistedEmailStore())
+	platformtesting.TestContacts(ctx, t, NewPersistedEmailStore())
 
 }
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+
 	"github.com/bjorge/friendlyreservations/config"
+	"github.com/bjorge/friendlyreservations/file_platform"
 	"github.com/bjorge/friendlyreservations/frapi"
 	"github.com/bjorge/friendlyreservations/local_platform"
 	"github.com/bjorge/friendlyreservations/logger"
+	"github.com/bjorge/friendlyreservations/notifier_platform"
+	"github.com/bjorge/friendlyreservations/payment_platform"
+	"github.com/bjorge/friendlyreservations/postgres_platform"
+	"github.com/bjorge/friendlyreservations/smtp_platform"
 	graphql "github.com/graph-gophers/graphql-go"
 )
 
@@ -19,17 +26,70 @@ var homeSchema *graphql.Schema
 var redirectURL string
 var redirectLabel string
 
+// maxWebhookSize is the max size of a payment provider webhook call
+const maxWebhookSize = 1 << 16
+
+// maxInboundMailSize is the max size of a reply to a notification email posted to /inboundmail
+const maxInboundMailSize = 1 << 20
+
+// inboundEmailSecret must be passed by the local MTA pipe to post replies to /inboundmail
+var inboundEmailSecret string
+
 func init() {
 
 	corsOriginURI = config.GetConfig("PLATFORM_CORS_ORIGIN_URI")
 
 	redirectURL = config.GetConfig("REDIRECT_URL")
 	redirectLabel = config.GetConfig("REDIRECT_LABEL")
+	inboundEmailSecret = config.GetConfig("INBOUND_EMAIL_SECRET")
+
+	if dataSourceName := config.GetConfig("PLATFORM_POSTGRES_DSN"); dataSourceName != "" {
+		// postgres stores, the schema is migrated on startup
+		db, err := postgresplatform.Open(context.Background(), dataSourceName)
+		if err != nil {
+			panic(err)
+		}
+		frapi.PersistedEmailStore = postgresplatform.NewPersistedEmailStore(db)
+		frapi.PersistedVersionedEvents = postgresplatform.NewPersistedVersionedEvents(db)
+		frapi.PersistedPropertyList = postgresplatform.NewPersistedPropertyList(db)
+	} else if dataDir := config.GetConfig("PLATFORM_DATA_DIR"); dataDir != "" {
+		// durable stores, the data survives a restart of the server
+		var err error
+		if frapi.PersistedEmailStore, err = fileplatform.NewPersistedEmailStore(dataDir); err != nil {
+			panic(err)
+		}
+		if frapi.PersistedVersionedEvents, err = fileplatform.NewPersistedVersionedEvents(dataDir); err != nil {
+			panic(err)
+		}
+		if frapi.PersistedPropertyList, err = fileplatform.NewPersistedPropertyList(dataDir); err != nil {
+			panic(err)
+		}
+	} else {
+		// in memory stores, all data is lost when the server stops
+		log.LogWarningf("PLATFORM_DATA_DIR is not set, data will not be saved")
+		frapi.PersistedEmailStore = localplatform.NewPersistedEmailStore()
+		frapi.PersistedVersionedEvents = localplatform.NewPersistedVersionedEvents()
+		frapi.PersistedPropertyList = localplatform.NewPersistedPropertyList()
+	}
+
+	smtpConfig, err := smtpplatform.ConfigFromSettings()
+	if err != nil {
+		panic(err)
+	}
+	if smtpConfig != nil {
+		frapi.EmailSender = smtpplatform.NewEmailSender(smtpConfig)
+	} else {
+		// emails are only logged
+		frapi.EmailSender = localplatform.NewEmailSender()
+	}
+
+	// optional sms and web push channels for urgent notifications
+	if frapi.Notifiers, frapi.PushPublicKey, err = notifierplatform.NotifiersFromSettings(); err != nil {
+		panic(err)
+	}
 
-	frapi.PersistedEmailStore = localplatform.NewPersistedEmailStore()
-	frapi.PersistedVersionedEvents = localplatform.NewPersistedVersionedEvents()
-	frapi.PersistedPropertyList = localplatform.NewPersistedPropertyList()
-	frapi.EmailSender = localplatform.NewEmailSender()
+	// optional online payments towards balances
+	frapi.PaymentProvider = paymentplatform.ProviderFromSettings()
 
 	adminSchema = graphql.MustParseSchema(frapi.AdminSchema, &frapi.Resolver{})
 	memberSchema = graphql.MustParseSchema(frapi.MemberSchema, &frapi.Resolver{})
@@ -2,6 +2,7 @@ package localplatform
 
 import (
 	"context"
+	"errors"
 
 	"github.com/bjorge/friendlyreservations/platform"
 	uuid "github.com/satori/go.uuid"
@@ -17,6 +18,8 @@ type PersistedEmail struct {
 type unitTestEmailImpl struct {
 	propertyToEmailIds map[string]map[string]PersistedEmail
 	emailToProperties  map[string][]PersistedEmail
+	// contacts by property id, email id and then channel
+	contacts map[string]map[string]map[platform.NotificationChannel]string
 }
 
 // NewPersistedEmailStore is the factory method to create an email store
@@ -24,6 +27,7 @@ func NewPersistedEmailStore() platform.PersistedEmailStore {
 	return &unitTestEmailImpl{
 		propertyToEmailIds: make(map[string]map[string]PersistedEmail),
 		emailToProperties:  make(map[string][]PersistedEmail),
+		contacts:           make(map[string]map[string]map[platform.NotificationChannel]string),
 	}
 }
 
@@ -129,5 +133,49 @@ func (r *unitTestEmailImpl) DeleteEmails(ctx context.Context, propertyID string)
 		}
 		delete(r.propertyToEmailIds, propertyID)
 	}
+	delete(r.contacts, propertyID)
 	return nil
 }
+
+func (r *unitTestEmailImpl) DeleteEmail(ctx context.Context, propertyID string, emailID string) error {
+	record, ok := r.propertyToEmailIds[propertyID][emailID]
+	if !ok {
+		return nil
+	}
+	newList := []PersistedEmail{}
+	for _, record2 := range r.emailToProperties[record.Email] {
+		if record2.PropertyID != propertyID {
+			newList = append(newList, record2)
+		}
+	}
+	r.emailToProperties[record.Email] = newList
+	delete(r.propertyToEmailIds[propertyID], emailID)
+	delete(r.contacts[propertyID], emailID)
+	return nil
+}
+
+func (r *unitTestEmailImpl) SetContact(ctx context.Context, propertyID string, emailID string, channel platform.NotificationChannel, contact string) error {
+	if _, ok := r.propertyToEmailIds[propertyID][emailID]; !ok {
+		return errors.New("email does not exist")
+	}
+	if contact == "" {
+		delete(r.contacts[propertyID][emailID], channel)
+		return nil
+	}
+	if _, ok := r.contacts[propertyID]; !ok {
+		r.contacts[propertyID] = make(map[string]map[platform.NotificationChannel]string)
+	}
+	if _, ok := r.contacts[propertyID][emailID]; !ok {
+		r.contacts[propertyID][emailID] = make(map[platform.NotificationChannel]string)
+	}
+	r.contacts[propertyID][emailID][channel] = contact
+	return nil
+}
+
+func (r *unitTestEmailImpl) GetContacts(ctx context.Context, propertyID string, emailID string) (map[platform.NotificationChannel]string, error) {
+	contacts := make(map[platform.NotificationChannel]string)
+	for channel, contact := range r.contacts[propertyID][emailID] {
+		contacts[channel] = contact
+	}
+	return contacts, nil
+}
@@ -8,7 +8,9 @@ import (
 
 func TestEmail(t *testing.T) {
 	platformtesting.TestCreateEmail(nil, t, NewPersistedEmailStore())
+	platformtesting.TestDeleteSingleEmail(nil, t, NewPersistedEmailStore())
 	platformtesting.TestDeleteEmail(nil, t, NewPersistedEmailStore())
 	platformtesting.TestRestoreEmail(nil, t, NewPersistedEmailStore())
+	platformtesting.TestContacts(nil, t, NewPersistedEmailStore())
 
 }
@@ -0,0 +1,89 @@
+package localplatform
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// localSignatureHeader holds the signature of a local webhook payload
+const localSignatureHeader = "Local-Signature"
+
+var localWebhookSecret = []byte("local webhook secret")
+
+// PaymentProvider is a fake payment provider for testing, call CompletePayment to get the webhook
+// call the provider would make when a checkout session is paid
+type PaymentProvider struct {
+	mutex    sync.Mutex
+	count    int
+	sessions map[string]*platform.CheckoutRequest
+}
+
+// NewPaymentProvider is the factory method to create a fake payment provider
+func NewPaymentProvider() *PaymentProvider {
+	return &PaymentProvider{sessions: make(map[string]*platform.CheckoutRequest)}
+}
+
+// CreateCheckoutSession remembers the request so the session can be paid with CompletePayment
+func (r *PaymentProvider) CreateCheckoutSession(ctx context.Context, request *platform.CheckoutRequest) (*platform.CheckoutSession, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.count++
+	id := fmt.Sprintf("cs_local_%d", r.count)
+	r.sessions[id] = request
+	logging.LogDebugf("local checkout session %v for %v %v", id, request.Amount, request.Currency)
+
+	return &platform.CheckoutSession{ID: id, URL: "http://localhost/checkout/" + id}, nil
+}
+
+// CompletePayment returns the signed webhook payload and headers for the payment of a checkout session
+func (r *PaymentProvider) CompletePayment(sessionID string) ([]byte, http.Header, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	request, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown checkout session %v", sessionID)
+	}
+
+	payload, err := json.Marshal(&platform.Payment{
+		ID:       "pi_" + sessionID,
+		Amount:   request.Amount,
+		Currency: request.Currency,
+		Metadata: request.Metadata,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(localSignatureHeader, localSignature(payload))
+	return payload, header, nil
+}
+
+// VerifyWebhook checks a payload returned by CompletePayment
+func (r *PaymentProvider) VerifyWebhook(payload []byte, header http.Header) (*platform.Payment, error) {
+	if !hmac.Equal([]byte(header.Get(localSignatureHeader)), []byte(localSignature(payload))) {
+		return nil, errors.New("invalid webhook signature")
+	}
+	payment := &platform.Payment{}
+	if err := json.Unmarshal(payload, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func localSignature(payload []byte) string {
+	mac := hmac.New(sha256.New, localWebhookSecret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"html/template"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -54,6 +57,53 @@ func main() {
 		}
 	}))
 
+	// handle the outbox retries, call every few minutes
+	http.Handle("/outbox", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+		log.LogInfof("Run outbox")
+		err := frapi.DeliverOutbox(ctx)
+		if err != nil {
+			log.LogErrorf("Outbox error: %+v", err)
+		}
+	}))
+
+	// handle replies to notification emails piped from a local MTA, example:
+	// curl --data-binary @- 'http://localhost:8080/inboundmail?secret=INBOUND_EMAIL_SECRET'
+	http.Handle("/inboundmail", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		secret := r.URL.Query().Get("secret")
+		if inboundEmailSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(inboundEmailSecret)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		ctx := context.Background()
+		if err := frapi.ReceiveEmail(ctx, "", io.LimitReader(r.Body, maxInboundMailSize)); err != nil {
+			log.LogWarningf("Inbound mail error: %+v", err)
+		}
+	}))
+
+	// handle the payment provider webhook, ex. https://mydomain.com/paymentwebhook for stripe
+	http.Handle("/paymentwebhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookSize))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		ctx := context.Background()
+		if err := frapi.ReceivePayment(ctx, payload, r.Header); err != nil {
+			// the provider retries the call
+			log.LogWarningf("Payment webhook error: %+v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}
+	}))
+
 	// handle the graphql requests
 	for uri, schema := range map[string]*graphql.Schema{
 		"/homequery":   homeSchema,
@@ -0,0 +1,80 @@
+package models
+
+// BankImportInputGQL is the GQL string for importing payments from a bank statement
+const BankImportInputGQL = `
+# How the columns of a bank statement CSV map to payments, a column is named by its header
+input BankImportMappingInput {
+	forVersion: Int!
+	# the column separator, default is a comma
+	delimiter: String
+	# the unique id of a transfer at the bank, used to skip transfers already imported
+	transactionIdColumn: String!
+	dateColumn: String
+	# the amount, negative for money paid out
+	amountColumn: String!
+	# the name of the account holder who paid
+	payerColumn: String!
+	# the text the payer entered with the transfer
+	referenceColumn: String
+}
+
+# The bank transfers approved to update balances
+input BankImportInput {
+	entries: [BankImportEntryInput!]!
+}
+
+input BankImportEntryInput {
+	transactionId: String!
+	updateForUserId: String!
+	amount: Int!
+	increase: Boolean!
+	description: String!
+}
+`
+
+// BankImportMappingInput is the go struct corresponding to the input GQL
+type BankImportMappingInput struct {
+	// Fields received from the client
+	ForVersion          int32
+	Delimiter           *string
+	TransactionIdColumn string
+	DateColumn          *string
+	AmountColumn        string
+	PayerColumn         string
+	ReferenceColumn     *string
+
+	// Extra fields persisted with the above
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *BankImportMappingInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *BankImportMappingInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *BankImportMappingInput) GetForVersion() int32 {
+	return r.ForVersion
+}
+
+// BankImportInput is the go struct corresponding to the input GQL, each entry is
+// persisted as an UpdateBalanceInput event
+type BankImportInput struct {
+	Entries []BankImportEntryInput
+}
+
+// BankImportEntryInput is the go struct corresponding to the input GQL
+type BankImportEntryInput struct {
+	TransactionId   string
+	UpdateForUserId string
+	Amount          int32
+	Increase        bool
+	Description     string
+}
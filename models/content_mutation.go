@@ -6,6 +6,8 @@ const NewContentInputGQL = `
 input NewContentInput {
 	forVersion: String!
 	name: ContentName!
+	# the email subject template, only for notification contents
+	subject: String
 	template: String!
 	comment: String!
 }
@@ -16,6 +18,7 @@ type NewContentInput struct {
 	// Fields received from the client
 	ForVersion int32
 	Name       ContentName
+	Subject    *string
 	Template   string
 	Comment    string
 
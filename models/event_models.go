@@ -14,11 +14,37 @@ func (r *NewVersionEvent) SetEventVersion(Version int) {
 	r.EventVersion = int32(Version)
 }
 
+// Currency is an ISO 4217 currency code, amounts are kept in the minor units of the currency
 type Currency string
 
 const (
 	USD Currency = "USD"
 	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	CHF Currency = "CHF"
+	CAD Currency = "CAD"
+	AUD Currency = "AUD"
+	NZD Currency = "NZD"
+	SEK Currency = "SEK"
+	NOK Currency = "NOK"
+	DKK Currency = "DKK"
+	PLN Currency = "PLN"
+	CZK Currency = "CZK"
+	HUF Currency = "HUF"
+	MXN Currency = "MXN"
+	BRL Currency = "BRL"
+	ZAR Currency = "ZAR"
+	INR Currency = "INR"
+	CNY Currency = "CNY"
+	HKD Currency = "HKD"
+	SGD Currency = "SGD"
+	JPY Currency = "JPY"
+	KRW Currency = "KRW"
+	ISK Currency = "ISK"
+	KWD Currency = "KWD"
+	BHD Currency = "BHD"
+	OMR Currency = "OMR"
+	JOD Currency = "JOD"
 )
 
 type NewPropertyInput struct {
@@ -31,6 +57,7 @@ type NewPropertyInput struct {
 	IsMember        bool
 	NickName        string
 	Timezone        string
+	Locale          *string
 
 	// Extra fields persisted with the above
 	// BUG(bjorge): remove propertyId from here - misleading
@@ -59,4 +86,20 @@ type ContentName string
 const (
 	ADMIN_HOME  ContentName = "ADMIN_HOME"
 	MEMBER_HOME ContentName = "MEMBER_HOME"
+
+	// notification email contents, the values match the notification template names
+	NOTIFICATION_NEW_PROPERTY      ContentName = "NOTIFICATION_NEW_PROPERTY"
+	NEW_RESERVATION                ContentName = "NEW_RESERVATION"
+	CANCEL_RESERVATION             ContentName = "CANCEL_RESERVATION"
+	BALANCE_INCREASE               ContentName = "BALANCE_INCREASE"
+	BALANCE_NOTIFICATION           ContentName = "BALANCE_NOTIFICATION"
+	DIGEST                         ContentName = "DIGEST"
+	MEMBERSHIP_PREPAY_OPEN         ContentName = "MEMBERSHIP_PREPAY_OPEN"
+	MEMBERSHIP_RENEWAL_REMINDER    ContentName = "MEMBERSHIP_RENEWAL_REMINDER"
+	MEMBERSHIP_GRACE_PERIOD_ENDING ContentName = "MEMBERSHIP_GRACE_PERIOD_ENDING"
+	PAYMENT_PLAN_MISSED            ContentName = "PAYMENT_PLAN_MISSED"
 )
+
+// NotificationContentNames are the contents that override a notification email template
+var NotificationContentNames = []ContentName{NOTIFICATION_NEW_PROPERTY, NEW_RESERVATION, CANCEL_RESERVATION, BALANCE_INCREASE, BALANCE_NOTIFICATION, DIGEST,
+	MEMBERSHIP_PREPAY_OPEN, MEMBERSHIP_RENEWAL_REMINDER, MEMBERSHIP_GRACE_PERIOD_ENDING, PAYMENT_PLAN_MISSED}
@@ -18,10 +18,19 @@ func init() {
 	gob.Register(&UpdateUserInput{})
 	gob.Register(&UpdateSystemUserInput{})
 	gob.Register(&AcceptInvitationInput{})
+	gob.Register(&EraseUserInput{})
 	gob.Register(&NewRestrictionInput{})
 	gob.Register(&NewNotificationInput{})
 	gob.Register(&NotificationReadInput{})
+	gob.Register(&AllNotificationsReadInput{})
+	gob.Register(&NotificationDeliveryInput{})
+	gob.Register(&NotificationCommentInput{})
+	gob.Register(&UpdateNotificationPreferenceInput{})
 	gob.Register(&NewContentInput{})
+	gob.Register(&NewPaymentPlanInput{})
+	gob.Register(&CancelPaymentPlanInput{})
+	gob.Register(&BankImportMappingInput{})
+	gob.Register(&ReverseLedgerEntryInput{})
 
 	gob.Register(&BlackoutRestriction{})
 	gob.Register(&MembershipRestriction{})
@@ -27,6 +27,10 @@ type UpdateBalanceInput struct {
 	AuthorUserId   string
 	EventVersion   int32
 	PaymentId      string
+	// the transfer at the bank, for balance updates imported from a bank statement
+	BankTransactionId string
+	// the expense shared with other users, for balance updates of a split expense
+	ExpenseId string
 }
 
 // GetEventVersion called to get input version
@@ -43,3 +47,130 @@ func (r *UpdateBalanceInput) SetEventVersion(Version int) {
 func (r *UpdateBalanceInput) GetForVersion() int32 {
 	return r.ForVersion
 }
+
+// GetDuplicateKey is called to check for duplicate requests, the balance updates of a split
+// expense are only duplicates of another split expense
+func (r *UpdateBalanceInput) GetDuplicateKey() string {
+	if r.ExpenseId != "" {
+		return splitExpenseDuplicateKey
+	}
+	return "UpdateBalanceInput"
+}
+
+// SplitExpenseInputGQL is the GQL string for sharing an expense between users
+const SplitExpenseInputGQL = `
+
+# How an expense is divided between the users sharing it
+enum SplitMethod {
+	# the same amount for each user
+	EQUAL
+	# by the nights each user has reserved from startDate up to endDate
+	NIGHTS
+	# by the weight of each user
+	WEIGHTS
+}
+
+# Information to charge an expense to several users.
+input SplitExpenseInput {
+	forVersion: Int!
+	# the total to divide
+	amount: Int!
+	description: String!
+	method: SplitMethod!
+	shares: [ExpenseShareInput!]!
+	# the period of the NIGHTS method
+	startDate: String
+	endDate: String
+}
+
+input ExpenseShareInput {
+	userId: String!
+	# required for the WEIGHTS method
+	weight: Int
+}
+`
+
+// SplitMethod is how an expense is divided between users
+type SplitMethod string
+
+// SplitMethod values
+const (
+	EQUAL   SplitMethod = "EQUAL"
+	NIGHTS  SplitMethod = "NIGHTS"
+	WEIGHTS SplitMethod = "WEIGHTS"
+)
+
+// SplitExpenseInput is the go struct corresponding to the input GQL, each share is
+// persisted as an UpdateBalanceInput event with the same ExpenseId
+type SplitExpenseInput struct {
+	ForVersion  int32
+	Amount      int32
+	Description string
+	Method      SplitMethod
+	Shares      []ExpenseShareInput
+	StartDate   *string
+	EndDate     *string
+}
+
+// splitExpenseDuplicateKey is the duplicate key of a split expense and of its balance updates
+const splitExpenseDuplicateKey = "SplitExpenseInput"
+
+// GetForVersion is called for duplicate suppression
+func (r *SplitExpenseInput) GetForVersion() int32 {
+	return r.ForVersion
+}
+
+// GetDuplicateKey is called for duplicate suppression, a split expense is persisted as balance updates
+func (r *SplitExpenseInput) GetDuplicateKey() string {
+	return splitExpenseDuplicateKey
+}
+
+// ExpenseShareInput is a user sharing an expense
+type ExpenseShareInput struct {
+	UserId string
+	Weight *int32
+}
+
+// ReverseLedgerEntryInputGQL is the GQL string for reversing a balance update
+const ReverseLedgerEntryInputGQL = `
+
+# Information to reverse a mistaken payment or expense.
+input ReverseLedgerEntryInput {
+	forVersion: Int!
+	# the payment id of the ledger record to reverse
+	paymentId: String!
+	# why the record is reversed
+	description: String!
+}
+`
+
+// ReverseLedgerEntryInput is the go struct corresponding to the input GQL
+type ReverseLedgerEntryInput struct {
+	// Fields received from the client
+	ForVersion  int32
+	PaymentId   string
+	Description string
+
+	// Extra fields persisted with the above, the amount and direction undo the reversed update
+	UpdateForUserId string
+	Amount          int32
+	Increase        bool
+	CreateDateTime  string
+	AuthorUserId    string
+	EventVersion    int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *ReverseLedgerEntryInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *ReverseLedgerEntryInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *ReverseLedgerEntryInput) GetForVersion() int32 {
+	return r.ForVersion
+}
@@ -12,6 +12,8 @@ input UpdateMembershipInput {
 	restrictionId: String!
 	# true == purchase membership, false == optout from membership
 	purchase: Boolean!
+	# the name of the tier purchased, required if the membership has tiers
+	tier: String
 	# the administrator is making this update for some user
 	adminUpdate: Boolean!
 	# if adminUpdate is true, then a comment is required
@@ -25,10 +27,13 @@ type UpdateMembershipStatusInput struct {
 	UpdateForUserId string
 	RestrictionId   string
 	Purchase        bool
+	Tier            *string
 	AdminUpdate     bool
 	Comment         *string
 
 	// Extra fields persisted with the above
+	// ChargedAmount is the amount charged for a purchase, pro-rated or not, nil for older events
+	ChargedAmount  *int32
 	CreateDateTime string
 	AuthorUserId   string
 	EventVersion   int32
@@ -15,7 +15,16 @@ type NewNotificationInput struct {
 	CreateDateTime     string
 	AuthorUserId       string
 	EventVersion       int32
-	EmailSent          bool
+	// EmailSent is true if an email is to be sent for the notification
+	EmailSent bool
+	// DeliveryTracked is true if the delivery of the email is recorded with NotificationDeliveryInput events,
+	// older notifications do not track delivery
+	DeliveryTracked bool
+	// DigestUserIds are the notified users who want the notification in a digest email instead of the To or Cc lists
+	DigestUserIds []string
+	// ReplyToken is the secret in the reply address of the notification email,
+	// older notifications have none and do not receive replies
+	ReplyToken string
 }
 
 // GetEventVersion returns the version of the mutation event
@@ -28,8 +37,49 @@ func (r *NewNotificationInput) SetEventVersion(Version int) {
 	r.EventVersion = int32(Version)
 }
 
-// BUG(bjorge): read notification code has not been completed
+// NotificationDeliveryInput is created by the service to record an attempt to email a notification
+type NotificationDeliveryInput struct {
+	NotificationId string
+	Delivered      bool
+	// Error is the send error if the attempt failed
+	Error          string
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *NotificationDeliveryInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *NotificationDeliveryInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// NotificationCommentInput is created by the service when a user replies to a notification email
+type NotificationCommentInput struct {
+	NotificationId string
+	Comment        string
+	// MessageId is the id of the reply email, used to ignore a reply that is received twice
+	MessageId      string
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
 
+// GetEventVersion returns the version of the mutation event
+func (r *NotificationCommentInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *NotificationCommentInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// NotificationReadInput marks a notification as read by the author
 type NotificationReadInput struct {
 	// Fields received from the client
 	ForVersion     int32
@@ -55,3 +105,95 @@ func (r *NotificationReadInput) SetEventVersion(Version int) {
 func (r *NotificationReadInput) GetForVersion() int32 {
 	return r.ForVersion
 }
+
+// AllNotificationsReadInput marks all the notifications up to this event as read by the author
+type AllNotificationsReadInput struct {
+	// Fields received from the client
+	ForVersion int32
+
+	// Extra fields persisted with the above
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *AllNotificationsReadInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *AllNotificationsReadInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *AllNotificationsReadInput) GetForVersion() int32 {
+	return r.ForVersion
+}
+
+// NotificationPreference is how a user wants to receive a type of notification
+type NotificationPreference string
+
+// Notification preferences
+const (
+	EMAIL_IMMEDIATELY NotificationPreference = "EMAIL_IMMEDIATELY"
+	EMAIL_DIGEST      NotificationPreference = "EMAIL_DIGEST"
+	IN_APP_ONLY       NotificationPreference = "IN_APP_ONLY"
+)
+
+// NotificationTemplateNameGQL is the GQL string for the notifications a user can set a preference for,
+// also used by the settings
+const NotificationTemplateNameGQL = `
+# the notifications a user can set a preference for
+enum NotificationTemplateName {
+	NEW_RESERVATION
+	CANCEL_RESERVATION
+	BALANCE_INCREASE
+	BALANCE_NOTIFICATION
+}
+`
+
+// UpdateNotificationPreferenceInputGQL is the GQL string for updating a notification preference
+const UpdateNotificationPreferenceInputGQL = `
+enum NotificationPreference {
+	EMAIL_IMMEDIATELY
+	EMAIL_DIGEST
+	IN_APP_ONLY
+}
+
+# Update how I receive a type of notification
+input UpdateNotificationPreferenceInput {
+	forVersion: Int!
+	templateName: NotificationTemplateName!
+	preference: NotificationPreference!
+}
+`
+
+// UpdateNotificationPreferenceInput sets how the author receives a type of notification
+type UpdateNotificationPreferenceInput struct {
+	// Fields received from the client
+	ForVersion   int32
+	TemplateName templates.TemplateName
+	Preference   NotificationPreference
+
+	// Extra fields persisted with the above
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *UpdateNotificationPreferenceInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *UpdateNotificationPreferenceInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *UpdateNotificationPreferenceInput) GetForVersion() int32 {
+	return r.ForVersion
+}
@@ -0,0 +1,88 @@
+package models
+
+// PaymentPlanInputGQL is the GQL string for creating and canceling payment plans
+const PaymentPlanInputGQL = `
+# A schedule of payments for a user to pay a membership or a negative balance over time
+input NewPaymentPlanInput {
+	forVersion: Int!
+	# the plan is for this user
+	userId: String!
+	description: String!
+	# the payments due, in due date order
+	installments: [InstallmentInput!]!
+}
+
+input InstallmentInput {
+	dueDate: String!
+	amount: Int!
+}
+
+input CancelPaymentPlanInput {
+	forVersion: Int!
+	paymentPlanId: String!
+}
+`
+
+// Installment is a payment due in a payment plan
+type Installment struct {
+	DueDate string
+	Amount  int32
+}
+
+// NewPaymentPlanInput is the go struct corresponding to the input GQL
+type NewPaymentPlanInput struct {
+	// Fields received from the client
+	ForVersion   int32
+	UserId       string
+	Description  string
+	Installments []Installment
+
+	// Extra fields persisted with the above
+	PaymentPlanId  string
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *NewPaymentPlanInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *NewPaymentPlanInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *NewPaymentPlanInput) GetForVersion() int32 {
+	return r.ForVersion
+}
+
+// CancelPaymentPlanInput is the go struct corresponding to the input GQL
+type CancelPaymentPlanInput struct {
+	// Fields received from the client
+	ForVersion    int32
+	PaymentPlanId string
+
+	// Extra fields persisted with the above
+	UserId         string
+	CreateDateTime string
+	AuthorUserId   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the mutation event
+func (r *CancelPaymentPlanInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *CancelPaymentPlanInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *CancelPaymentPlanInput) GetForVersion() int32 {
+	return r.ForVersion
+}
@@ -68,6 +68,33 @@ input MembershipRestrictionInput {
 	gracePeriodOutDate: String!
 	# Payment amount for membership period
 	amount: Int!
+	# Kinds of membership sold for the period, if set a purchase must choose one
+	tiers: [MembershipTierInput!]
+	# Charge less for a purchase after the membership period starts, full amount if not set
+	proration: MembershipProrationInput
+}
+
+# How a purchase after the start of the membership period is charged
+input MembershipProrationInput {
+	period: ProrationPeriod!
+	# Least amount charged for a purchase
+	minimumAmount: Int!
+}
+
+# A kind of membership, ex. weekday only
+input MembershipTierInput {
+	# Unique name of the tier in the membership period
+	name: String!
+	# Payment amount for the tier, replaces the membership amount
+	amount: Int!
+	# Replaces the member nightly rate of the settings
+	nightlyRate: Int
+	# Max days ahead the checkout of a stay can be, limits the settings max out days further
+	bookingWindowDays: Int
+	# Max nights of a single stay
+	maxNights: Int
+	# The nights of the week that can be booked, all nights if not set
+	nights: [Weekday!]
 }
 `
 
@@ -77,4 +104,48 @@ type MembershipRestriction struct {
 	OutDate            string
 	GracePeriodOutDate string
 	Amount             int32
+	Tiers              *[]MembershipTier
+	Proration          *MembershipProration
+}
+
+// ProrationPeriod is the unit used to pro-rate a membership purchased after the period starts
+type ProrationPeriod string
+
+// ProrationPeriod values
+const (
+	DAILY   ProrationPeriod = "DAILY"
+	MONTHLY ProrationPeriod = "MONTHLY"
+)
+
+// MembershipProration is how a membership purchased after its period starts is charged
+type MembershipProration struct {
+	Period        ProrationPeriod
+	MinimumAmount int32
+}
+
+// Weekday is a day of the week, for a booking it is the night starting on that day
+type Weekday string
+
+// Weekday values in the order of time.Weekday
+const (
+	SUNDAY    Weekday = "SUNDAY"
+	MONDAY    Weekday = "MONDAY"
+	TUESDAY   Weekday = "TUESDAY"
+	WEDNESDAY Weekday = "WEDNESDAY"
+	THURSDAY  Weekday = "THURSDAY"
+	FRIDAY    Weekday = "FRIDAY"
+	SATURDAY  Weekday = "SATURDAY"
+)
+
+// AllWeekdays is indexed by time.Weekday
+var AllWeekdays = []Weekday{SUNDAY, MONDAY, TUESDAY, WEDNESDAY, THURSDAY, FRIDAY, SATURDAY}
+
+// MembershipTier is a kind of membership sold for a membership period, nil fields use the settings
+type MembershipTier struct {
+	Name              string
+	Amount            int32
+	NightlyRate       *int32
+	BookingWindowDays *int32
+	MaxNights         *int32
+	Nights            *[]Weekday
 }
@@ -22,3 +22,16 @@ const (
 	DISABLED       UserState = "DISABLED"
 	DECLINED       UserState = "DECLINED"
 )
+
+// UserRole is a role granted to a user in addition to being an admin or member
+type UserRole string
+
+const (
+	// TREASURER can update balances, manage memberships and export csv files
+	TREASURER UserRole = "TREASURER"
+	// SCHEDULER can make and cancel reservations on behalf of members, within the constraints of the member
+	SCHEDULER UserRole = "SCHEDULER"
+)
+
+// AllUserRoles is the list of roles that can be granted to a user
+var AllUserRoles = []UserRole{TREASURER, SCHEDULER}
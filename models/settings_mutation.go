@@ -1,5 +1,9 @@
 package models
 
+import (
+	"github.com/bjorge/friendlyreservations/templates"
+)
+
 // UpdateSettingsInputGQL is the GQL string for updating settings
 const UpdateSettingsInputGQL = `
 input UpdateSettingsInput {
@@ -15,6 +19,12 @@ input UpdateSettingsInput {
 	minInDays: Int!
 	reservationReminderDaysBefore: Int!
 	balanceReminderIntervalDays: Int!
+	# days before a membership starts or its grace period ends to remind members, unchanged if not set
+	membershipReminderDaysBefore: Int
+	# how amounts are formatted, ex. en-US or de-DE, unchanged if not set
+	locale: String
+	# notifications always emailed immediately, whatever the preference of a member, unchanged if not set
+	mandatoryNotifications: [NotificationTemplateName!]
 }
 `
 
@@ -33,6 +43,9 @@ type UpdateSettingsInput struct {
 	MinInDays                     int32
 	ReservationReminderDaysBefore int32
 	BalanceReminderIntervalDays   int32
+	MembershipReminderDaysBefore  *int32
+	Locale                        *string
+	MandatoryNotifications        *[]templates.TemplateName
 
 	// Extra fields persisted with the above
 	CreateDateTime string
@@ -9,6 +9,7 @@ input NewUserInput {
 	isAdmin: Boolean!
 	isMember: Boolean!
 	nickname: String!
+	roles: [UserRole!]
 }
 `
 
@@ -21,6 +22,7 @@ type NewUserInput struct {
 	IsAdmin    bool
 	IsMember   bool
 	Nickname   string
+	Roles      *[]UserRole
 
 	// Extra fields persisted with the above
 	IsSystem       bool
@@ -57,6 +59,8 @@ input UpdateUserInput {
 	isMember: Boolean!
 	nickname: String!
 	state: UserState!
+	# roles, if not set then the current roles are kept
+	roles: [UserRole!]
 }
 `
 
@@ -70,6 +74,7 @@ type UpdateUserInput struct {
 	IsMember   bool
 	Nickname   string
 	State      UserState
+	Roles      *[]UserRole
 
 	// Extra fields persisted with the above
 	IsSystem       bool
@@ -171,3 +176,39 @@ func (r *UpdateSystemUserInput) SetEventVersion(Version int) {
 func (r *UpdateSystemUserInput) GetForVersion() int32 {
 	return r.ForVersion
 }
+
+// EraseUserInputGQL is the GQL string for erasing a user
+const EraseUserInputGQL = `
+# Erase the personal information of a user
+input EraseUserInput {
+	forVersion: Int!
+	userId: String!
+}
+`
+
+// EraseUserInput is the GQL structure for erasing a user, it is persisted as a tombstone event
+type EraseUserInput struct {
+	// Fields received from the client
+	ForVersion int32
+	UserID     string
+
+	// Extra fields persisted with the above
+	CreateDateTime string
+	AuthorUserID   string
+	EventVersion   int32
+}
+
+// GetEventVersion returns the version of the event
+func (r *EraseUserInput) GetEventVersion() int {
+	return int(r.EventVersion)
+}
+
+// SetEventVersion is called by the persist code to set the event version
+func (r *EraseUserInput) SetEventVersion(Version int) {
+	r.EventVersion = int32(Version)
+}
+
+// GetForVersion is called for duplicate suppression
+func (r *EraseUserInput) GetForVersion() int32 {
+	return r.ForVersion
+}
@@ -0,0 +1,68 @@
+package notifierplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/config"
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// SMSConfig holds the SMS gateway settings
+type SMSConfig struct {
+	// URL receives a POST with a JSON body {"from": ..., "to": ..., "body": ...}
+	URL string
+	// Token, if set, is sent as a bearer token
+	Token string
+	// From is the sender number or id registered with the gateway
+	From string
+}
+
+// SMSConfigFromSettings reads the SMS_GATEWAY_* config values, returns nil if SMS_GATEWAY_URL is not set
+func SMSConfigFromSettings() *SMSConfig {
+	url := config.GetConfig("SMS_GATEWAY_URL")
+	if url == "" {
+		return nil
+	}
+	return &SMSConfig{
+		URL:   url,
+		Token: config.GetConfig("SMS_GATEWAY_TOKEN"),
+		From:  config.GetConfig("SMS_FROM"),
+	}
+}
+
+// PushConfig holds the web push settings
+type PushConfig struct {
+	// PrivateKey is the base64url encoded P-256 VAPID private key, see GenerateVAPIDKeys
+	PrivateKey string
+	// Subject is a mailto: or https: contact for the push services, ex. mailto:admin@example.com
+	Subject string
+}
+
+// PushConfigFromSettings reads the VAPID_* config values, returns nil if VAPID_PRIVATE_KEY is not set
+func PushConfigFromSettings() *PushConfig {
+	privateKey := config.GetConfig("VAPID_PRIVATE_KEY")
+	if privateKey == "" {
+		return nil
+	}
+	return &PushConfig{
+		PrivateKey: privateKey,
+		Subject:    config.GetConfig("VAPID_SUBJECT"),
+	}
+}
+
+// NotifiersFromSettings creates the notifiers for the configured channels, the push public key is empty if push is not configured
+func NotifiersFromSettings() ([]platform.Notifier, string, error) {
+	notifiers := []platform.Notifier{}
+	if smsConfig := SMSConfigFromSettings(); smsConfig != nil {
+		notifiers = append(notifiers, NewSMSNotifier(smsConfig))
+	}
+
+	publicKey := ""
+	if pushConfig := PushConfigFromSettings(); pushConfig != nil {
+		pushNotifier, err := NewPushNotifier(pushConfig)
+		if err != nil {
+			return nil, "", err
+		}
+		notifiers = append(notifiers, pushNotifier)
+		publicKey = pushNotifier.PublicKey()
+	}
+	return notifiers, publicKey, nil
+}
@@ -0,0 +1,7 @@
+/*
+Package notifierplatform - implementations of platform.Notifier
+
+SMSNotifier posts text messages to an HTTP SMS gateway, PushNotifier sends web push
+messages signed with VAPID (RFC 8292) and encrypted with aes128gcm (RFC 8291).
+*/
+package notifierplatform
@@ -0,0 +1,7 @@
+package notifierplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/logger"
+)
+
+var logging = logger.New()
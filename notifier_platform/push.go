@@ -0,0 +1,307 @@
+package notifierplatform
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// pushTTL is how long the push service keeps a message for an offline browser
+const pushTTL = 24 * time.Hour
+
+// vapidExpiration is the lifetime of the VAPID token, at most 24 hours is allowed
+const vapidExpiration = 12 * time.Hour
+
+// recordSize is the aes128gcm record size, the whole payload fits in one record
+const recordSize = 4096
+
+// PushSubscription is the JSON of a browser PushSubscription, the contact of the push channel
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// ParsePushSubscription parses and checks the JSON of a browser PushSubscription
+func ParsePushSubscription(subscriptionJSON string) (*PushSubscription, error) {
+	subscription := &PushSubscription{}
+	if err := json.Unmarshal([]byte(subscriptionJSON), subscription); err != nil {
+		return nil, fmt.Errorf("invalid push subscription: %v", err)
+	}
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, errors.New("invalid push subscription: the endpoint must be an https url")
+	}
+	if _, err := subscription.userAgentKey(); err != nil {
+		return nil, err
+	}
+	if auth, err := decodeBase64URL(subscription.Keys.Auth); err != nil || len(auth) != 16 {
+		return nil, errors.New("invalid push subscription: bad auth secret")
+	}
+	return subscription, nil
+}
+
+func (r *PushSubscription) userAgentKey() ([]byte, error) {
+	key, err := decodeBase64URL(r.Keys.P256dh)
+	if err != nil {
+		return nil, errors.New("invalid push subscription: bad p256dh key")
+	}
+	if x, _ := elliptic.Unmarshal(elliptic.P256(), key); x == nil {
+		return nil, errors.New("invalid push subscription: p256dh is not a P-256 public key")
+	}
+	return key, nil
+}
+
+type pushNotifier struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string
+	subject    string
+	client     *http.Client
+}
+
+// PushNotifier sends web push messages
+type PushNotifier interface {
+	platform.Notifier
+	// PublicKey is the base64url VAPID public key, the applicationServerKey browsers subscribe with
+	PublicKey() string
+}
+
+// NewPushNotifier is the factory method to create a web push notifier
+func NewPushNotifier(config *PushConfig) (PushNotifier, error) {
+	d, err := decodeBase64URL(config.PrivateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("invalid VAPID_PRIVATE_KEY: expected a base64url P-256 private key")
+	}
+	curve := elliptic.P256()
+	privateKey := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	privateKey.PublicKey.Curve = curve
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(d)
+
+	return &pushNotifier{
+		privateKey: privateKey,
+		publicKey:  base64.RawURLEncoding.EncodeToString(elliptic.Marshal(curve, privateKey.X, privateKey.Y)),
+		subject:    config.Subject,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// GenerateVAPIDKeys creates a new base64url encoded VAPID key pair, the private key is the VAPID_PRIVATE_KEY config value
+func GenerateVAPIDKeys() (privateKey string, publicKey string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	privateKey = base64.RawURLEncoding.EncodeToString(paddedBytes(key.D, 32))
+	publicKey = base64.RawURLEncoding.EncodeToString(elliptic.Marshal(key.Curve, key.X, key.Y))
+	return privateKey, publicKey, nil
+}
+
+func (r *pushNotifier) Channel() platform.NotificationChannel {
+	return platform.PushChannel
+}
+
+func (r *pushNotifier) ValidateContact(contact string) error {
+	_, err := ParsePushSubscription(contact)
+	return err
+}
+
+func (r *pushNotifier) PublicKey() string {
+	return r.publicKey
+}
+
+func (r *pushNotifier) Notify(ctx context.Context, notification *platform.Notification) error {
+	subscription, err := ParsePushSubscription(notification.To)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"title": notification.Title,
+		"body":  notification.Body,
+		"url":   notification.URL,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := encryptPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+
+	token, err := r.vapidToken(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if ctx != nil {
+		request = request.WithContext(ctx)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, r.publicKey))
+	request.Header.Set("Content-Encoding", "aes128gcm")
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.Header.Set("TTL", fmt.Sprintf("%d", int(pushTTL.Seconds())))
+	request.Header.Set("Urgency", "high")
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone:
+		return platform.ErrSubscriptionExpired
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("push service returned %v: %s", response.Status, message)
+	}
+	logging.LogDebugf("%v notification sent", platform.PushChannel)
+	return nil
+}
+
+// vapidToken returns the ES256 JWT identifying the server to the push service of the endpoint (RFC 8292)
+func (r *pushNotifier) vapidToken(endpoint string) (string, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": time.Now().Add(vapidExpiration).Unix(),
+		"sub": r.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sigR, sigS, err := ecdsa.Sign(rand.Reader, r.privateKey, hash[:])
+	if err != nil {
+		return "", err
+	}
+	signature := append(paddedBytes(sigR, 32), paddedBytes(sigS, 32)...)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// encryptPayload encrypts the payload for the subscription with aes128gcm (RFC 8188 and RFC 8291)
+func encryptPayload(subscription *PushSubscription, payload []byte) ([]byte, error) {
+	userAgentKey, err := decodeBase64URL(subscription.Keys.P256dh)
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := elliptic.P256()
+	userAgentX, userAgentY := elliptic.Unmarshal(curve, userAgentKey)
+	if userAgentX == nil {
+		return nil, errors.New("invalid push subscription: p256dh is not a P-256 public key")
+	}
+
+	// a new key pair and salt for every message
+	serverKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicKey := elliptic.Marshal(curve, serverKey.X, serverKey.Y)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	sharedX, _ := curve.ScalarMult(userAgentX, userAgentY, paddedBytes(serverKey.D, 32))
+	contentKey, nonce := contentKeys(paddedBytes(sharedX, 32), authSecret, userAgentKey, serverPublicKey, salt)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 is the delimiter of the last record, no padding
+	ciphertext := gcm.Seal(nil, nonce, append(payload, 0x02), nil)
+
+	// header: salt, record size, key id length and the key id (the server public key)
+	header := make([]byte, 0, 16+4+1+len(serverPublicKey))
+	header = append(header, salt...)
+	header = append(header, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(header[16:20], recordSize)
+	header = append(header, byte(len(serverPublicKey)))
+	header = append(header, serverPublicKey...)
+
+	return append(header, ciphertext...), nil
+}
+
+// contentKeys derives the aes128gcm content encryption key and nonce (RFC 8291 section 3.4)
+func contentKeys(sharedSecret []byte, authSecret []byte, userAgentKey []byte, serverPublicKey []byte, salt []byte) ([]byte, []byte) {
+	keyInfo := append([]byte("WebPush: info\x00"), userAgentKey...)
+	keyInfo = append(keyInfo, serverPublicKey...)
+	inputKey := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	contentKey := hkdf(salt, inputKey, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, inputKey, []byte("Content-Encoding: nonce\x00"), 12)
+	return contentKey, nonce
+}
+
+// hkdf is HKDF-SHA-256 (RFC 5869) for outputs of at most one hash length
+func hkdf(salt []byte, secret []byte, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	pseudoRandomKey := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, pseudoRandomKey)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// paddedBytes returns the big endian bytes of the number left padded with zeros to size
+func paddedBytes(number *big.Int, size int) []byte {
+	raw := number.Bytes()
+	if len(raw) >= size {
+		return raw
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(raw):], raw)
+	return padded
+}
+
+// decodeBase64URL decodes base64url with or without padding, browsers omit the padding
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,185 @@
+package notifierplatform
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// testBrowser is the browser side of a push subscription
+type testBrowser struct {
+	key        *ecdsa.PrivateKey
+	authSecret []byte
+}
+
+func newTestBrowser(t *testing.T) *testBrowser {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+	return &testBrowser{key: key, authSecret: authSecret}
+}
+
+func (r *testBrowser) publicKey() []byte {
+	return elliptic.Marshal(r.key.Curve, r.key.X, r.key.Y)
+}
+
+func (r *testBrowser) subscription(endpoint string) string {
+	subscription := &PushSubscription{Endpoint: endpoint}
+	subscription.Keys.P256dh = base64.RawURLEncoding.EncodeToString(r.publicKey())
+	subscription.Keys.Auth = base64.RawURLEncoding.EncodeToString(r.authSecret)
+	subscriptionJSON, _ := json.Marshal(subscription)
+	return string(subscriptionJSON)
+}
+
+// decrypt reverses encryptPayload the way a browser does
+func (r *testBrowser) decrypt(t *testing.T, body []byte) []byte {
+	salt := body[:16]
+	if binary.BigEndian.Uint32(body[16:20]) != recordSize {
+		t.Fatal("unexpected record size")
+	}
+	keyLength := int(body[20])
+	serverPublicKey := body[21 : 21+keyLength]
+	ciphertext := body[21+keyLength:]
+
+	curve := elliptic.P256()
+	serverX, serverY := elliptic.Unmarshal(curve, serverPublicKey)
+	sharedX, _ := curve.ScalarMult(serverX, serverY, paddedBytes(r.key.D, 32))
+	contentKey, nonce := contentKeys(paddedBytes(sharedX, 32), r.authSecret, r.publicKey(), serverPublicKey, salt)
+
+	block, _ := aes.NewCipher(contentKey)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatal("expected the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+// checkVAPID checks the vapid authorization header is signed by the public key
+func checkVAPID(t *testing.T, authorization string, publicKey string) map[string]interface{} {
+	parts := strings.Split(strings.TrimPrefix(authorization, "vapid "), ", ")
+	if len(parts) != 2 || parts[1] != "k="+publicKey {
+		t.Fatalf("unexpected authorization %v", authorization)
+	}
+	token := strings.Split(strings.TrimPrefix(parts[0], "t="), ".")
+	if len(token) != 3 {
+		t.Fatalf("unexpected token %v", parts[0])
+	}
+
+	keyBytes, _ := base64.RawURLEncoding.DecodeString(publicKey)
+	x, y := elliptic.Unmarshal(elliptic.P256(), keyBytes)
+	signature, _ := base64.RawURLEncoding.DecodeString(token[2])
+	hash := sha256.Sum256([]byte(token[0] + "." + token[1]))
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:],
+		new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		t.Fatal("bad vapid signature")
+	}
+
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(token[1])
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestPushNotify(t *testing.T) {
+	var authorization, encoding string
+	var body []byte
+	pushService := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/expired" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		encoding = r.Header.Get("Content-Encoding")
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer pushService.Close()
+
+	privateKey, publicKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifier, err := NewPushNotifier(&PushConfig{PrivateKey: privateKey, Subject: "mailto:admin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notifier.PublicKey() != publicKey {
+		t.Fatalf("expected public key %v but got %v", publicKey, notifier.PublicKey())
+	}
+	notifier.(*pushNotifier).client = pushService.Client()
+
+	browser := newTestBrowser(t)
+	err = notifier.Notify(context.Background(), &platform.Notification{
+		To:    browser.subscription(pushService.URL + "/push/abc"),
+		Title: "Reservation cancelled",
+		Body:  "Check in tomorrow",
+		URL:   "https://example.com/reservations",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoding != "aes128gcm" {
+		t.Fatalf("unexpected content encoding %v", encoding)
+	}
+	claims := checkVAPID(t, authorization, publicKey)
+	if claims["aud"] != pushService.URL || claims["sub"] != "mailto:admin@example.com" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+
+	payload := map[string]string{}
+	if err := json.Unmarshal(browser.decrypt(t, body), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["title"] != "Reservation cancelled" || payload["body"] != "Check in tomorrow" || payload["url"] != "https://example.com/reservations" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+
+	t.Log("an expired subscription is reported")
+	err = notifier.Notify(context.Background(), &platform.Notification{To: browser.subscription(pushService.URL + "/expired"), Title: "Title"})
+	if err != platform.ErrSubscriptionExpired {
+		t.Fatalf("expected an expired subscription but got %v", err)
+	}
+}
+
+func TestParsePushSubscription(t *testing.T) {
+	browser := newTestBrowser(t)
+	if _, err := ParsePushSubscription(browser.subscription("https://push.example.com/abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []string{
+		"not json",
+		browser.subscription("http://push.example.com/abc"),
+		`{"endpoint": "https://push.example.com/abc", "keys": {"p256dh": "abc", "auth": "abc"}}`,
+	}
+	for _, subscription := range invalid {
+		if _, err := ParsePushSubscription(subscription); err == nil {
+			t.Fatalf("expected subscription %v to be invalid", subscription)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package notifierplatform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// maxSMSLength keeps a message within a few SMS segments
+const maxSMSLength = 320
+
+// phonePattern matches an E.164 phone number, ex. +15555550100
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type smsNotifier struct {
+	config *SMSConfig
+	client *http.Client
+}
+
+// NewSMSNotifier is the factory method to create a notifier that sends text messages through the gateway
+func NewSMSNotifier(config *SMSConfig) platform.Notifier {
+	return &smsNotifier{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (r *smsNotifier) Channel() platform.NotificationChannel {
+	return platform.SMSChannel
+}
+
+func (r *smsNotifier) ValidateContact(contact string) error {
+	if !phonePattern.MatchString(contact) {
+		return errors.New("phone number must be in international format, ex. +15555550100")
+	}
+	return nil
+}
+
+func (r *smsNotifier) Notify(ctx context.Context, notification *platform.Notification) error {
+	text := notification.Title + ": " + notification.Body
+	if runes := []rune(text); len(runes) > maxSMSLength {
+		text = string(runes[:maxSMSLength-3]) + "..."
+	}
+	if notification.URL != "" {
+		text += " " + notification.URL
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"from": r.config.From,
+		"to":   notification.To,
+		"body": text,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, r.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if ctx != nil {
+		request = request.WithContext(ctx)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if r.config.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+r.config.Token)
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("sms gateway returned %v: %s", response.Status, message)
+	}
+	logging.LogDebugf("%v notification sent", platform.SMSChannel)
+	return nil
+}
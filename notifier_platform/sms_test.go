@@ -0,0 +1,78 @@
+package notifierplatform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+func TestSMSNotify(t *testing.T) {
+	received := map[string]string{}
+	var authorization string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if received["to"] == "+15555550199" {
+			http.Error(w, "unknown number", http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	notifier := NewSMSNotifier(&SMSConfig{URL: gateway.URL, Token: "secret", From: "+15555550111"})
+	if notifier.Channel() != platform.SMSChannel {
+		t.Fatalf("expected the sms channel but got %v", notifier.Channel())
+	}
+
+	err := notifier.Notify(context.Background(), &platform.Notification{
+		To:    "+15555550100",
+		Title: "Reservation cancelled",
+		Body:  "Check in tomorrow",
+		URL:   "https://example.com/reservations",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer secret" {
+		t.Fatalf("expected the bearer token but got %v", authorization)
+	}
+	if received["to"] != "+15555550100" || received["from"] != "+15555550111" {
+		t.Fatalf("unexpected sender or recipient %+v", received)
+	}
+	if received["body"] != "Reservation cancelled: Check in tomorrow https://example.com/reservations" {
+		t.Fatalf("unexpected body %v", received["body"])
+	}
+
+	t.Log("long messages are shortened")
+	notifier.Notify(context.Background(), &platform.Notification{To: "+15555550100", Title: "Title", Body: strings.Repeat("x", 1000)})
+	if len(received["body"]) != maxSMSLength {
+		t.Fatalf("expected a body of %v characters but got %v", maxSMSLength, len(received["body"]))
+	}
+
+	t.Log("gateway errors are returned")
+	err = notifier.Notify(context.Background(), &platform.Notification{To: "+15555550199", Title: "Title", Body: "Body"})
+	if err == nil || !strings.Contains(err.Error(), "unknown number") {
+		t.Fatalf("expected the gateway error but got %v", err)
+	}
+}
+
+func TestSMSValidateContact(t *testing.T) {
+	notifier := NewSMSNotifier(&SMSConfig{URL: "https://sms.example.com/messages"})
+	if err := notifier.ValidateContact("+15555550100"); err != nil {
+		t.Fatal(err)
+	}
+	for _, phone := range []string{"555-0100", "15555550100", "+0555550100", "+1555"} {
+		if err := notifier.ValidateContact(phone); err == nil {
+			t.Fatalf("expected phone number %v to be invalid", phone)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package paymentplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/config"
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// defaultStripeURL is the Stripe API
+const defaultStripeURL = "https://api.stripe.com"
+
+// StripeConfig holds the Stripe settings
+type StripeConfig struct {
+	// URL is the API base url, https://api.stripe.com if not set
+	URL string
+	// SecretKey is the API secret key, ex. sk_live_...
+	SecretKey string
+	// WebhookSecret is the signing secret of the webhook endpoint, ex. whsec_...
+	WebhookSecret string
+}
+
+// StripeConfigFromSettings reads the STRIPE_* config values, returns nil if STRIPE_SECRET_KEY is not set
+func StripeConfigFromSettings() *StripeConfig {
+	secretKey := config.GetConfig("STRIPE_SECRET_KEY")
+	if secretKey == "" {
+		return nil
+	}
+	url := config.GetConfig("STRIPE_API_URL")
+	if url == "" {
+		url = defaultStripeURL
+	}
+	return &StripeConfig{
+		URL:           url,
+		SecretKey:     secretKey,
+		WebhookSecret: config.GetConfig("STRIPE_WEBHOOK_SECRET"),
+	}
+}
+
+// ProviderFromSettings creates the configured payment provider, nil if online payments are not configured
+func ProviderFromSettings() platform.PaymentProvider {
+	if stripeConfig := StripeConfigFromSettings(); stripeConfig != nil {
+		return NewStripeProvider(stripeConfig)
+	}
+	return nil
+}
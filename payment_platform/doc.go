@@ -0,0 +1,8 @@
+/*
+Package paymentplatform - implementations of platform.PaymentProvider
+
+StripeProvider creates Stripe Checkout sessions and verifies the Stripe-Signature of
+checkout.session.completed webhook events, any service with the same API can be used
+by setting STRIPE_API_URL.
+*/
+package paymentplatform
@@ -0,0 +1,7 @@
+package paymentplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/logger"
+)
+
+var logging = logger.New()
@@ -0,0 +1,187 @@
+package paymentplatform
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// stripeSignatureHeader holds the timestamp and signatures of a webhook call
+const stripeSignatureHeader = "Stripe-Signature"
+
+// signatureTolerance is the max age of a webhook call, older calls may be replays
+const signatureTolerance = 5 * time.Minute
+
+// now is replaced by tests
+var now = time.Now
+
+type stripeProvider struct {
+	config *StripeConfig
+	client *http.Client
+}
+
+// NewStripeProvider is the factory method to create a payment provider for the Stripe API
+func NewStripeProvider(config *StripeConfig) platform.PaymentProvider {
+	return &stripeProvider{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (r *stripeProvider) CreateCheckoutSession(ctx context.Context, request *platform.CheckoutRequest) (*platform.CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("success_url", request.SuccessURL)
+	form.Set("cancel_url", request.CancelURL)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", strings.ToLower(request.Currency))
+	form.Set("line_items[0][price_data][unit_amount]", strconv.Itoa(int(request.Amount)))
+	form.Set("line_items[0][price_data][product_data][name]", request.Description)
+	if request.CustomerEmail != "" {
+		form.Set("customer_email", request.CustomerEmail)
+	}
+	for key, value := range request.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, r.config.URL+"/v1/checkout/sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		httpRequest = httpRequest.WithContext(ctx)
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpRequest.Header.Set("Authorization", "Bearer "+r.config.SecretKey)
+
+	response, err := r.client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	session := &struct {
+		ID    string `json:"id"`
+		URL   string `json:"url"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, session); err != nil {
+		return nil, fmt.Errorf("stripe returned %v: %v", response.Status, err)
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if session.Error != nil {
+			return nil, fmt.Errorf("stripe returned %v: %v", response.Status, session.Error.Message)
+		}
+		return nil, fmt.Errorf("stripe returned %v", response.Status)
+	}
+	if session.ID == "" || session.URL == "" {
+		return nil, errors.New("stripe returned a checkout session without an id or url")
+	}
+
+	logging.LogDebugf("stripe checkout session %v created", session.ID)
+	return &platform.CheckoutSession{ID: session.ID, URL: session.URL}, nil
+}
+
+func (r *stripeProvider) VerifyWebhook(payload []byte, header http.Header) (*platform.Payment, error) {
+	if err := r.verifySignature(payload, header.Get(stripeSignatureHeader)); err != nil {
+		return nil, err
+	}
+
+	event := &struct {
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID            string            `json:"id"`
+				PaymentIntent string            `json:"payment_intent"`
+				PaymentStatus string            `json:"payment_status"`
+				AmountTotal   int32             `json:"amount_total"`
+				Currency      string            `json:"currency"`
+				Metadata      map[string]string `json:"metadata"`
+			} `json:"object"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+
+	// delayed payment methods are paid when the async payment succeeds
+	if event.Type != "checkout.session.completed" && event.Type != "checkout.session.async_payment_succeeded" {
+		return nil, nil
+	}
+	session := event.Data.Object
+	if session.PaymentStatus != "paid" {
+		return nil, nil
+	}
+
+	id := session.PaymentIntent
+	if id == "" {
+		id = session.ID
+	}
+	return &platform.Payment{
+		ID:       id,
+		Amount:   session.AmountTotal,
+		Currency: strings.ToUpper(session.Currency),
+		Metadata: session.Metadata,
+	}, nil
+}
+
+// verifySignature checks a Stripe-Signature header, ex. t=1492774577,v1=5257a869...
+func (r *stripeProvider) verifySignature(payload []byte, signatureHeader string) error {
+	if r.config.WebhookSecret == "" {
+		return errors.New("STRIPE_WEBHOOK_SECRET is not set")
+	}
+
+	timestamp := ""
+	signatures := []string{}
+	for _, item := range strings.Split(signatureHeader, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "t":
+			timestamp = parts[1]
+		case "v1":
+			signatures = append(signatures, parts[1])
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return errors.New("missing webhook signature")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook timestamp %v", timestamp)
+	}
+	if age := now().Sub(time.Unix(seconds, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return errors.New("webhook timestamp is outside the tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(r.config.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("invalid webhook signature")
+}
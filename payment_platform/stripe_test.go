@@ -0,0 +1,114 @@
+package paymentplatform
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+func TestStripeCheckoutSession(t *testing.T) {
+	var authorization string
+	var form map[string]string
+	stripe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		form = map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		if form["line_items[0][price_data][unit_amount]"] == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "amount must be positive"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id": "cs_test_1", "url": "https://checkout.example.com/cs_test_1"}`)
+	}))
+	defer stripe.Close()
+
+	provider := NewStripeProvider(&StripeConfig{URL: stripe.URL, SecretKey: "sk_test"})
+	session, err := provider.CreateCheckoutSession(context.Background(), &platform.CheckoutRequest{
+		Amount:      2500,
+		Currency:    "EUR",
+		Description: "Balance top-up",
+		SuccessURL:  "https://example.com/ledger",
+		CancelURL:   "https://example.com/ledger",
+		Metadata:    map[string]string{"propertyId": "1", "userId": "abc"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID != "cs_test_1" || session.URL != "https://checkout.example.com/cs_test_1" {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	if authorization != "Bearer sk_test" {
+		t.Fatalf("expected the secret key but got %v", authorization)
+	}
+	if form["line_items[0][price_data][currency]"] != "eur" || form["line_items[0][price_data][unit_amount]"] != "2500" ||
+		form["metadata[userId]"] != "abc" || form["mode"] != "payment" {
+		t.Fatalf("unexpected form %+v", form)
+	}
+
+	t.Log("stripe errors are returned")
+	_, err = provider.CreateCheckoutSession(context.Background(), &platform.CheckoutRequest{Amount: 0, Currency: "EUR"})
+	if err == nil || !strings.Contains(err.Error(), "amount must be positive") {
+		t.Fatalf("expected the stripe error but got %v", err)
+	}
+}
+
+func TestStripeWebhook(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(1600000000, 0) }
+
+	sign := func(payload string, timestamp int64, secret string) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, payload)))
+		header := http.Header{}
+		header.Set(stripeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil))))
+		return header
+	}
+
+	provider := NewStripeProvider(&StripeConfig{URL: defaultStripeURL, SecretKey: "sk_test", WebhookSecret: "whsec_test"})
+	completed := `{"type": "checkout.session.completed", "data": {"object": {"id": "cs_test_1", "payment_intent": "pi_1",
+		"payment_status": "paid", "amount_total": 2500, "currency": "eur", "metadata": {"userId": "abc"}}}}`
+
+	payment, err := provider.VerifyWebhook([]byte(completed), sign(completed, 1600000000, "whsec_test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.ID != "pi_1" || payment.Amount != 2500 || payment.Currency != "EUR" || payment.Metadata["userId"] != "abc" {
+		t.Fatalf("unexpected payment %+v", payment)
+	}
+
+	t.Log("bad signatures are rejected")
+	if _, err := provider.VerifyWebhook([]byte(completed), sign(completed, 1600000000, "whsec_other")); err == nil {
+		t.Fatal("expected an error for the wrong secret")
+	}
+	if _, err := provider.VerifyWebhook([]byte(completed), sign(completed, 1600000000-3600, "whsec_test")); err == nil {
+		t.Fatal("expected an error for an old timestamp")
+	}
+	if _, err := provider.VerifyWebhook([]byte(completed), http.Header{}); err == nil {
+		t.Fatal("expected an error for a missing signature")
+	}
+
+	t.Log("other events are ignored")
+	unpaid := strings.Replace(completed, `"paid"`, `"unpaid"`, 1)
+	if payment, err := provider.VerifyWebhook([]byte(unpaid), sign(unpaid, 1600000000, "whsec_test")); err != nil || payment != nil {
+		t.Fatalf("expected an unpaid session to be ignored: %+v %v", payment, err)
+	}
+	expired := strings.Replace(completed, "checkout.session.completed", "checkout.session.expired", 1)
+	if payment, err := provider.VerifyWebhook([]byte(expired), sign(expired, 1600000000, "whsec_test")); err != nil || payment != nil {
+		t.Fatalf("expected an expired session to be ignored: %+v %v", payment, err)
+	}
+}
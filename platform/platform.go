@@ -1,6 +1,10 @@
 package platform
 
-import "context"
+import (
+	"context"
+	"errors"
+	"net/http"
+)
 
 // PersistedPropertyList is the interface for managing property ids in the system
 type PersistedPropertyList interface {
@@ -41,6 +45,14 @@ type PersistedEmailStore interface {
 
 	EmailExists(ctx context.Context, propertyID string, email string) (*bool, error)
 	DeleteEmails(ctx context.Context, propertyID string) error
+	// DeleteEmail deletes a single email by id, deleting an unknown id is not an error
+	DeleteEmail(ctx context.Context, propertyID string, emailID string) error
+
+	// SetContact stores the contact (ex. a phone number) of an email id for a notification channel,
+	// an empty contact deletes it, contacts are deleted with the email
+	SetContact(ctx context.Context, propertyID string, emailID string, channel NotificationChannel, contact string) error
+	// GetContacts returns the contacts of an email id by notification channel
+	GetContacts(ctx context.Context, propertyID string, emailID string) (map[NotificationChannel]string, error)
 }
 
 // An EmailAttachment represents an email attachment.
@@ -73,6 +85,84 @@ type SendMail interface {
 	Send(ctx context.Context, msg *EmailMessage) error
 }
 
+// NotificationChannel is a way to reach a user other than email
+type NotificationChannel string
+
+// Notification channels
+const (
+	// SMSChannel contacts are phone numbers in E.164 format, ex. +15555550100
+	SMSChannel NotificationChannel = "SMS"
+	// PushChannel contacts are web push subscriptions in the JSON format of the browser PushSubscription
+	PushChannel NotificationChannel = "PUSH"
+)
+
+// A Notification is a short message sent on a notification channel
+type Notification struct {
+	// To is the contact for the channel
+	To    string
+	Title string
+	Body  string
+	// URL is opened when the user selects the notification, may be empty
+	URL string
+}
+
+// ErrSubscriptionExpired is returned by Notify when the contact no longer exists, ex. the browser unsubscribed
+var ErrSubscriptionExpired = errors.New("push subscription has expired")
+
+// Notifier is the interface for sending notifications on a channel
+type Notifier interface {
+	// Channel is the channel the notifier sends on
+	Channel() NotificationChannel
+	// ValidateContact returns an error if the contact is not a valid address for the channel
+	ValidateContact(contact string) error
+	// Notify sends a notification.
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// A CheckoutRequest asks a payment provider for a hosted page to pay an amount
+type CheckoutRequest struct {
+	// Amount is in the minor units of the currency, ex. cents
+	Amount int32
+	// Currency is the ISO 4217 code, ex. USD
+	Currency    string
+	Description string
+	// CustomerEmail prefills the payment page, may be empty
+	CustomerEmail string
+	// SuccessURL and CancelURL are where the payment page returns to
+	SuccessURL string
+	CancelURL  string
+	// Metadata is returned with the completed payment
+	Metadata map[string]string
+}
+
+// A CheckoutSession is a hosted payment page
+type CheckoutSession struct {
+	ID string
+	// URL is where the user is sent to pay
+	URL string
+}
+
+// A Payment is a completed payment received by a payment provider webhook
+type Payment struct {
+	// ID is the unique id of the payment at the provider
+	ID string
+	// Amount is in the minor units of the currency
+	Amount int32
+	// Currency is the ISO 4217 code, ex. USD
+	Currency string
+	// Metadata is the metadata of the checkout request
+	Metadata map[string]string
+}
+
+// PaymentProvider is the interface for collecting online payments
+type PaymentProvider interface {
+	// CreateCheckoutSession creates a hosted payment page.
+	CreateCheckoutSession(ctx context.Context, request *CheckoutRequest) (*CheckoutSession, error)
+	// VerifyWebhook checks the signature of a webhook call and returns the completed payment,
+	// the payment is nil if the webhook event is not a completed payment
+	VerifyWebhook(payload []byte, header http.Header) (*Payment, error)
+}
+
 // Logger is the interface for logging
 type Logger interface {
 	LogDebugf(format string, args ...interface{})
@@ -91,6 +91,47 @@ func TestDeleteEmail(ctx context.Context, t *testing.T, persistedEmailStore plat
 
 }
 
+// TestDeleteSingleEmail is called by the platform implementation testing code
+func TestDeleteSingleEmail(ctx context.Context, t *testing.T, persistedEmailStore platform.PersistedEmailStore) {
+
+	propertyID := "id12345"
+	email1 := "test1@testing.com"
+	email2 := "test2@testing.com"
+
+	emailID1, err := persistedEmailStore.CreateEmail(ctx, propertyID, email1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := persistedEmailStore.CreateEmail(ctx, propertyID, email2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := persistedEmailStore.DeleteEmail(ctx, propertyID, emailID1); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := persistedEmailStore.EmailExists(ctx, propertyID, email1); *exists {
+		t.Fatal(errors.New("deleted email should not exist"))
+	}
+
+	if exists, _ := persistedEmailStore.EmailExists(ctx, propertyID, email2); !*exists {
+		t.Fatal(errors.New("other email should still exist"))
+	}
+
+	ids, err := persistedEmailStore.GetPropertiesByEmail(ctx, email1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Fatal(errors.New("deleted email should not be found by GetPropertiesByEmail"))
+	}
+
+	// deleting again is not an error
+	if err := persistedEmailStore.DeleteEmail(ctx, propertyID, emailID1); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestRestoreEmail is called by the platform implementation testing code
 func TestRestoreEmail(ctx context.Context, t *testing.T, persistedEmailStore platform.PersistedEmailStore) {
 
@@ -144,3 +185,56 @@ func TestRestoreEmail(ctx context.Context, t *testing.T, persistedEmailStore pla
 		t.Fatal(errors.New("email does not match after restore"))
 	}
 }
+
+// TestContacts is called by the platform implementation testing code
+func TestContacts(ctx context.Context, t *testing.T, persistedEmailStore platform.PersistedEmailStore) {
+
+	propertyID := "id123456"
+	email := "test@testing.com"
+	phone := "+15555550100"
+
+	emailID, err := persistedEmailStore.CreateEmail(ctx, propertyID, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := persistedEmailStore.SetContact(ctx, propertyID, "unknown", platform.SMSChannel, phone); err == nil {
+		t.Fatal(errors.New("contact of an unknown email id should fail"))
+	}
+
+	if err := persistedEmailStore.SetContact(ctx, propertyID, emailID, platform.SMSChannel, phone); err != nil {
+		t.Fatal(err)
+	}
+	if err := persistedEmailStore.SetContact(ctx, propertyID, emailID, platform.PushChannel, "{}"); err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := persistedEmailStore.GetContacts(ctx, propertyID, emailID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 2 || contacts[platform.SMSChannel] != phone {
+		t.Fatalf("expected 2 contacts but got %+v", contacts)
+	}
+
+	t.Log("an empty contact deletes the contact")
+	if err := persistedEmailStore.SetContact(ctx, propertyID, emailID, platform.PushChannel, ""); err != nil {
+		t.Fatal(err)
+	}
+	contacts, _ = persistedEmailStore.GetContacts(ctx, propertyID, emailID)
+	if len(contacts) != 1 {
+		t.Fatalf("expected 1 contact but got %+v", contacts)
+	}
+
+	t.Log("contacts are deleted with the email")
+	if err := persistedEmailStore.DeleteEmail(ctx, propertyID, emailID); err != nil {
+		t.Fatal(err)
+	}
+	contacts, err = persistedEmailStore.GetContacts(ctx, propertyID, emailID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 0 {
+		t.Fatalf("expected no contacts after delete but got %+v", contacts)
+	}
+}
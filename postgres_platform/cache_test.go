@@ -0,0 +1,14 @@
+package postgresplatform
+
+import (
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func TestCache(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	platformtesting.TestCache(ctx, t, NewPersistedVersionedEvents(db))
+}
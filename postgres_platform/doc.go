@@ -0,0 +1,15 @@
+/*
+Package postgresplatform - implementation for platform code backed by a PostgreSQL database
+
+Events are stored one row per event keyed by (property, version). The primary key plays the
+role of the datastore transaction in gaeplatform: a writer that is not up to date with the
+latest version either fails the expected next id check or collides with the row written by
+the other writer.
+
+Call Open (or Migrate on an existing connection) before using the stores, the schema
+migrations are applied in order and only once.
+
+Tests run against the database in the POSTGRES_TEST_DSN environment variable and are
+skipped if it is not set. The tests drop and recreate the tables, so use a scratch database.
+*/
+package postgresplatform
@@ -0,0 +1,165 @@
+package postgresplatform
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	uuid "github.com/satori/go.uuid"
+)
+
+type postgresEmailImpl struct {
+	db *sql.DB
+}
+
+// NewPersistedEmailStore is the factory method to create an email store
+func NewPersistedEmailStore(db *sql.DB) platform.PersistedEmailStore {
+	return &postgresEmailImpl{db: db}
+}
+
+// normalizeEmail is the form an email is stored and looked up in, so that an address differing
+// only in case or surrounding spaces is the same user
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (r *postgresEmailImpl) CreateEmail(ctx context.Context, propertyID string, email string) (string, error) {
+	existingEmailID, err := r.GetEmail(ctx, propertyID, email)
+	if err != nil || existingEmailID != "" {
+		return existingEmailID, err
+	}
+
+	emailID := uuid.Must(uuid.NewV4()).String()
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO emails (email_id, property_id, email) VALUES ($1, $2, $3)`, emailID, propertyID, normalizeEmail(email))
+	if err != nil {
+		return "", err
+	}
+	return emailID, nil
+}
+
+func (r *postgresEmailImpl) GetPropertiesByEmail(ctx context.Context, email string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT property_id FROM emails WHERE email = $1`, normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	properties := []string{}
+	for rows.Next() {
+		var propertyID string
+		if err := rows.Scan(&propertyID); err != nil {
+			return nil, err
+		}
+		properties = append(properties, propertyID)
+	}
+	return properties, rows.Err()
+}
+
+func (r *postgresEmailImpl) GetEmailMap(ctx context.Context, propertyID string) (map[string]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT email_id, email FROM emails WHERE property_id = $1`, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emailMap := make(map[string]string)
+	for rows.Next() {
+		var emailID, email string
+		if err := rows.Scan(&emailID, &email); err != nil {
+			return nil, err
+		}
+		emailMap[emailID] = email
+	}
+	return emailMap, rows.Err()
+}
+
+func (r *postgresEmailImpl) RestoreEmails(ctx context.Context, propertyID string, persistedEmails map[string]string) error {
+	return withTransaction(ctx, r.db, func(tx *sql.Tx) error {
+		for emailID, email := range persistedEmails {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO emails (email_id, property_id, email) VALUES ($1, $2, $3)
+				ON CONFLICT (email_id) DO UPDATE SET property_id = EXCLUDED.property_id, email = EXCLUDED.email`,
+				emailID, propertyID, normalizeEmail(email))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postgresEmailImpl) GetEmail(ctx context.Context, propertyID string, email string) (string, error) {
+	var emailID string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT email_id FROM emails WHERE property_id = $1 AND email = $2 LIMIT 1`, propertyID, normalizeEmail(email)).Scan(&emailID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return emailID, err
+}
+
+func (r *postgresEmailImpl) EmailExists(ctx context.Context, propertyID string, email string) (*bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM emails WHERE property_id = $1 AND email = $2)`, propertyID, normalizeEmail(email)).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	return &exists, nil
+}
+
+func (r *postgresEmailImpl) DeleteEmails(ctx context.Context, propertyID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM emails WHERE property_id = $1`, propertyID)
+	return err
+}
+
+func (r *postgresEmailImpl) DeleteEmail(ctx context.Context, propertyID string, emailID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM emails WHERE property_id = $1 AND email_id = $2`, propertyID, emailID)
+	return err
+}
+
+func (r *postgresEmailImpl) SetContact(ctx context.Context, propertyID string, emailID string, channel platform.NotificationChannel, contact string) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM emails WHERE property_id = $1 AND email_id = $2)`, propertyID, emailID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("email does not exist")
+	}
+
+	if contact == "" {
+		_, err = r.db.ExecContext(ctx, `DELETE FROM contacts WHERE email_id = $1 AND channel = $2`, emailID, string(channel))
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO contacts (email_id, channel, contact) VALUES ($1, $2, $3)
+		ON CONFLICT (email_id, channel) DO UPDATE SET contact = EXCLUDED.contact`,
+		emailID, string(channel), contact)
+	return err
+}
+
+func (r *postgresEmailImpl) GetContacts(ctx context.Context, propertyID string, emailID string) (map[platform.NotificationChannel]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT contacts.channel, contacts.contact FROM contacts
+		JOIN emails ON emails.email_id = contacts.email_id
+		WHERE emails.property_id = $1 AND contacts.email_id = $2`, propertyID, emailID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := make(map[platform.NotificationChannel]string)
+	for rows.Next() {
+		var channel, contact string
+		if err := rows.Scan(&channel, &contact); err != nil {
+			return nil, err
+		}
+		contacts[platform.NotificationChannel(channel)] = contact
+	}
+	return contacts, rows.Err()
+}
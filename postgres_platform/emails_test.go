@@ -0,0 +1,41 @@
+package postgresplatform
+
+import (
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func TestEmail(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	platformtesting.TestCreateEmail(ctx, t, NewPersistedEmailStore(db))
+	platformtesting.TestDeleteSingleEmail(ctx, t, NewPersistedEmailStore(db))
+	platformtesting.TestDeleteEmail(ctx, t, NewPersistedEmailStore(db))
+	platformtesting.TestRestoreEmail(ctx, t, NewPersistedEmailStore(db))
+	platformtesting.TestContacts(ctx, t, NewPersistedEmailStore(db))
+}
+
+func TestEmailCase(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	store := NewPersistedEmailStore(db)
+	emailID, err := store.CreateEmail(ctx, "case", " Mixed@Test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := store.CreateEmail(ctx, "case", "mixed@test.com"); err != nil || again != emailID {
+		t.Fatalf("expected the same email id but got %+v %+v", again, err)
+	}
+	if found, err := store.GetEmail(ctx, "case", "MIXED@test.com "); err != nil || found != emailID {
+		t.Fatalf("expected the email to be found but got %+v %+v", found, err)
+	}
+	if exists, err := store.EmailExists(ctx, "case", "Mixed@Test.Com"); err != nil || !*exists {
+		t.Fatal("expected the email to exist")
+	}
+	if ids, err := store.GetPropertiesByEmail(ctx, "MIXED@TEST.COM"); err != nil || len(ids) != 1 {
+		t.Fatalf("expected the property to be found but got %+v %+v", ids, err)
+	}
+}
@@ -0,0 +1,242 @@
+package postgresplatform
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/lib/pq"
+)
+
+// the postgres error code for a unique or primary key violation
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == uniqueViolation
+}
+
+type postgresEventsImpl struct {
+	db *sql.DB
+}
+
+// NewPersistedVersionedEvents is the factory method to create a persisted events store
+func NewPersistedVersionedEvents(db *sql.DB) platform.PersistedVersionedEvents {
+	return &postgresEventsImpl{db: db}
+}
+
+// gobFromEvent encodes a single event, the event is wrapped in a slice so the gob holds the concrete type
+func gobFromEvent(event platform.VersionedEvent) ([]byte, error) {
+	stream := &bytes.Buffer{}
+	en := gob.NewEncoder(stream)
+	if err := en.Encode([]platform.VersionedEvent{event}); err != nil {
+		return nil, err
+	}
+	return stream.Bytes(), nil
+}
+
+func eventFromGob(gobData []byte) (platform.VersionedEvent, error) {
+	dec := gob.NewDecoder(bytes.NewBuffer(gobData))
+	events := []platform.VersionedEvent{}
+	if err := dec.Decode(&events); err != nil {
+		return nil, err
+	}
+	if len(events) != 1 {
+		return nil, fmt.Errorf("expected 1 event but got %+v", len(events))
+	}
+	return events[0], nil
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func nextEventID(ctx context.Context, q queryer, propertyID string) (int, error) {
+	var nextID int
+	err := q.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version) + 1, 0) FROM property_events WHERE property_id = $1`, propertyID).Scan(&nextID)
+	return nextID, err
+}
+
+// insertEvents sets the versions starting at transactionKey and inserts the events,
+// the primary key rejects the insert if another writer already used one of the versions
+func insertEvents(ctx context.Context, tx *sql.Tx, propertyID string, transactionKey int, events []platform.VersionedEvent) error {
+	for index, event := range events {
+		event.SetEventVersion(transactionKey + index)
+		gobData, err := gobFromEvent(event)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO property_events (property_id, version, event) VALUES ($1, $2, $3)`,
+			propertyID, transactionKey+index, gobData)
+		if isUniqueViolation(err) {
+			return fmt.Errorf("expected id: %+v is already in use", transactionKey+index)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *postgresEventsImpl) CreateProperty(ctx context.Context, propertyID string, events []platform.VersionedEvent, persistedPropertyList platform.PersistedPropertyList, nextPropertyListIndex int) (int, error) {
+	err := withTransaction(ctx, r.db, func(tx *sql.Tx) error {
+		// list the property in the same transaction when both stores share the database
+		if _, ok := persistedPropertyList.(*postgresListImpl); ok {
+			if err := createListedProperty(ctx, tx, propertyID, nextPropertyListIndex); err != nil {
+				return err
+			}
+		} else if persistedPropertyList != nil {
+			if err := persistedPropertyList.CreateProperty(ctx, propertyID, nextPropertyListIndex); err != nil {
+				return err
+			}
+		}
+
+		nextID, err := nextEventID(ctx, tx, propertyID)
+		if err != nil {
+			return err
+		}
+		if nextID != 0 {
+			return fmt.Errorf("property %v already exists", propertyID)
+		}
+		return insertEvents(ctx, tx, propertyID, 0, events)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+func (r *postgresEventsImpl) DeleteProperty(ctx context.Context, propertyID string, persistedPropertyList platform.PersistedPropertyList) error {
+	return withTransaction(ctx, r.db, func(tx *sql.Tx) error {
+		if _, ok := persistedPropertyList.(*postgresListImpl); ok {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM property_list WHERE property_id = $1`, propertyID); err != nil {
+				return err
+			}
+		} else if persistedPropertyList != nil {
+			if err := persistedPropertyList.DeleteProperty(ctx, propertyID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM property_events WHERE property_id = $1`, propertyID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM cache WHERE property_id = $1`, propertyID)
+		return err
+	})
+}
+
+func (r *postgresEventsImpl) NewPropertyEvents(ctx context.Context, propertyID string, transactionKey int, events []platform.VersionedEvent, inTransaction bool) (int, error) {
+	err := withTransaction(ctx, r.db, func(tx *sql.Tx) error {
+		// test that the caller is up to date with the latest id
+		nextID, err := nextEventID(ctx, tx, propertyID)
+		if err != nil {
+			return err
+		}
+		if transactionKey != nextID {
+			return fmt.Errorf("expected id: %+v but got %+v", transactionKey, nextID)
+		}
+		return insertEvents(ctx, tx, propertyID, transactionKey, events)
+	})
+	if err != nil {
+		return -1, err
+	}
+	return transactionKey + len(events), nil
+}
+
+func (r *postgresEventsImpl) GetNextEventID(ctx context.Context, propertyID string, inTransaction bool) (int, error) {
+	return nextEventID(ctx, r.db, propertyID)
+}
+
+func (r *postgresEventsImpl) NumRecords(ctx context.Context, propertyID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM property_events WHERE property_id = $1`, propertyID).Scan(&count)
+	return count, err
+}
+
+func (r *postgresEventsImpl) GetEvents(ctx context.Context, propertyID string) ([]platform.VersionedEvent, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT event FROM property_events WHERE property_id = $1 ORDER BY version`, propertyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []platform.VersionedEvent{}
+	for rows.Next() {
+		var gobData []byte
+		if err := rows.Scan(&gobData); err != nil {
+			return nil, err
+		}
+		event, err := eventFromGob(gobData)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("property %v does not exist", propertyID)
+	}
+	return events, nil
+}
+
+func (r *postgresEventsImpl) CacheWrite(ctx context.Context, propertyID string, version int, key string, value []byte) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO cache (property_id, cache_key, version, value) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (property_id, cache_key) DO UPDATE SET version = EXCLUDED.version, value = EXCLUDED.value`,
+		propertyID, key, version, value)
+	return err
+}
+
+func (r *postgresEventsImpl) CacheDelete(ctx context.Context, propertyID string, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cache WHERE property_id = $1 AND cache_key = $2`, propertyID, key)
+	return err
+}
+
+func (r *postgresEventsImpl) CacheRead(ctx context.Context, propertyID string, keys []string) (int, map[string][]byte, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT cache_key, version, value FROM cache WHERE property_id = $1 AND cache_key = ANY($2)`,
+		propertyID, pq.Array(keys))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	version := 0
+	data := make(map[int]map[string][]byte)
+
+	// default to empty set for default version 0
+	data[version] = make(map[string][]byte)
+
+	for rows.Next() {
+		var key string
+		var recordVersion int
+		var value []byte
+		if err := rows.Scan(&key, &recordVersion, &value); err != nil {
+			return 0, nil, err
+		}
+
+		if _, ok := data[recordVersion]; !ok {
+			data[recordVersion] = make(map[string][]byte)
+		}
+		data[recordVersion][key] = value
+
+		if version < recordVersion {
+			version = recordVersion
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	return version, data[version], nil
+}
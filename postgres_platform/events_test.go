@@ -0,0 +1,96 @@
+package postgresplatform
+
+import (
+	"database/sql"
+	"encoding/gob"
+	"strings"
+	"testing"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	"github.com/bjorge/friendlyreservations/platform_testing"
+)
+
+func TestEvents(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	platformtesting.TestEventsCreate(ctx, t, NewPersistedVersionedEvents(db), NewPersistedPropertyList(db))
+	platformtesting.TestEventsDelete(ctx, t, NewPersistedVersionedEvents(db), NewPersistedPropertyList(db))
+}
+
+func TestMigrateTwice(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	if err := Migrate(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) {
+		t.Fatalf("expected %+v migrations but got %+v", len(migrations), count)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	ctx, db := openTestDB(t)
+	defer db.Close()
+
+	events := NewPersistedVersionedEvents(db)
+	list := NewPersistedPropertyList(db)
+	next, err := events.CreateProperty(ctx, "conflict", []platform.VersionedEvent{&conflictEvent{}}, list, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("a writer that is not up to date is rejected")
+	if _, err := events.NewPropertyEvents(ctx, "conflict", next-1, []platform.VersionedEvent{&conflictEvent{}}, false); err == nil {
+		t.Fatal("expected a stale transaction key to fail")
+	}
+
+	t.Log("a writer that passed the check but lost the race hits the primary key")
+	err = withTransaction(ctx, db, func(tx *sql.Tx) error {
+		return insertEvents(ctx, tx, "conflict", 0, []platform.VersionedEvent{&conflictEvent{}})
+	})
+	if err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("expected the version to be in use but got %+v", err)
+	}
+	if count, _ := events.NumRecords(ctx, "conflict"); count != next {
+		t.Fatalf("expected the failed transaction to be rolled back but got %+v events", count)
+	}
+
+	t.Log("a property cannot be listed twice")
+	err = withTransaction(ctx, db, func(tx *sql.Tx) error {
+		return createListedProperty(ctx, tx, "conflict", 1)
+	})
+	if err == nil || err.Error() != "new property index not correct" {
+		t.Fatalf("expected the listed property to be rejected but got %+v", err)
+	}
+
+	t.Log("a property that already has events cannot be created again")
+	if _, err := events.CreateProperty(ctx, "conflict", []platform.VersionedEvent{&conflictEvent{}}, list, 1); err == nil {
+		t.Fatal("expected creating an existing property to fail")
+	}
+	if ids, _ := list.GetProperties(ctx); len(ids) != 1 {
+		t.Fatalf("expected the failed create to be rolled back but got %+v", ids)
+	}
+}
+
+type conflictEvent struct {
+	EventVersion int
+}
+
+func (r *conflictEvent) GetEventVersion() int {
+	return r.EventVersion
+}
+
+func (r *conflictEvent) SetEventVersion(Version int) {
+	r.EventVersion = Version
+}
+
+func init() {
+	gob.Register(&conflictEvent{})
+}
@@ -0,0 +1,31 @@
+package postgresplatform
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// openTestDB connects to a scratch database and recreates the schema, skips the test if none is configured
+func openTestDB(t *testing.T) (context.Context, *sql.DB) {
+	dataSourceName := os.Getenv("POSTGRES_TEST_DSN")
+	if dataSourceName == "" {
+		t.Skip("POSTGRES_TEST_DSN is not set")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dataSourceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx,
+		`DROP TABLE IF EXISTS schema_migrations, property_list, property_events, contacts, emails, cache`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	return ctx, db
+}
@@ -0,0 +1,7 @@
+package postgresplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/logger"
+)
+
+var logging = logger.New()
@@ -0,0 +1,114 @@
+package postgresplatform
+
+import (
+	"context"
+	"database/sql"
+
+	// register the postgres database/sql driver
+	_ "github.com/lib/pq"
+)
+
+// migrations are applied in order, never edit or reorder an existing migration, only append
+var migrations = []string{
+	// 1: initial schema
+	`
+	CREATE TABLE property_list (
+		list_index INTEGER PRIMARY KEY,
+		property_id TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE property_events (
+		property_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		event BYTEA NOT NULL,
+		PRIMARY KEY (property_id, version)
+	);
+	CREATE TABLE emails (
+		email_id TEXT PRIMARY KEY,
+		property_id TEXT NOT NULL,
+		email TEXT NOT NULL
+	);
+	CREATE INDEX emails_property_id ON emails (property_id);
+	CREATE INDEX emails_email ON emails (email);
+	CREATE TABLE cache (
+		property_id TEXT NOT NULL,
+		cache_key TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		value BYTEA NOT NULL,
+		PRIMARY KEY (property_id, cache_key)
+	);
+	`,
+	// 2: notification channel contacts, deleted with the email
+	`
+	CREATE TABLE contacts (
+		email_id TEXT NOT NULL REFERENCES emails (email_id) ON DELETE CASCADE,
+		channel TEXT NOT NULL,
+		contact TEXT NOT NULL,
+		PRIMARY KEY (email_id, channel)
+	);
+	`,
+}
+
+// an arbitrary key so that servers starting at the same time do not migrate twice
+const migrationLockKey = 7070701
+
+// Open connects to the database and applies any missing schema migrations
+func Open(ctx context.Context, dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate applies the schema migrations that have not been applied yet
+func Migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	for index, migration := range migrations {
+		version := index + 1
+		if err := withTransaction(ctx, db, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+				return err
+			}
+
+			var applied bool
+			err := tx.QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied)
+			if err != nil || applied {
+				return err
+			}
+
+			logging.LogInfof("apply schema migration %v", version)
+			if _, err := tx.ExecContext(ctx, migration); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTransaction runs f in a transaction, committing if f returns nil and rolling back otherwise
+func withTransaction(ctx context.Context, db *sql.DB, f func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
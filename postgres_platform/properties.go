@@ -0,0 +1,78 @@
+package postgresplatform
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+type postgresListImpl struct {
+	db *sql.DB
+}
+
+// NewPersistedPropertyList is the factory method to create a property list store
+func NewPersistedPropertyList(db *sql.DB) platform.PersistedPropertyList {
+	return &postgresListImpl{db: db}
+}
+
+func nextListIndex(ctx context.Context, q queryer) (int, error) {
+	var nextIndex int
+	err := q.QueryRowContext(ctx, `SELECT COALESCE(MAX(list_index) + 1, 0) FROM property_list`).Scan(&nextIndex)
+	return nextIndex, err
+}
+
+// createListedProperty adds the property to the list if idx is still the next index
+func createListedProperty(ctx context.Context, tx *sql.Tx, propertyID string, idx int) error {
+	nextIndex, err := nextListIndex(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	// if the index does not match, either the client has the wrong index or two clients
+	// have simultaneously tried to create a property, the client should refresh and try again
+	if idx != nextIndex {
+		return errors.New("new property index not correct")
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO property_list (list_index, property_id) VALUES ($1, $2)`, idx, propertyID)
+	if isUniqueViolation(err) {
+		return errors.New("new property index not correct")
+	}
+	return err
+}
+
+func (r *postgresListImpl) CreateProperty(ctx context.Context, propertyID string, idx int) error {
+	return withTransaction(ctx, r.db, func(tx *sql.Tx) error {
+		return createListedProperty(ctx, tx, propertyID, idx)
+	})
+}
+
+func (r *postgresListImpl) DeleteProperty(ctx context.Context, propertyID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM property_list WHERE property_id = $1`, propertyID)
+	return err
+}
+
+func (r *postgresListImpl) GetNextVersion(ctx context.Context) (int, error) {
+	return nextListIndex(ctx, r.db)
+}
+
+// GetProperties returns a list of all the properties
+func (r *postgresListImpl) GetProperties(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT property_id FROM property_list ORDER BY list_index`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,68 @@
+package smtpplatform
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bjorge/friendlyreservations/config"
+)
+
+// Security is how the connection to the SMTP server is secured
+type Security string
+
+const (
+	// StartTLS connects in plain text and upgrades the connection with STARTTLS (usually port 587)
+	StartTLS Security = "starttls"
+	// ImplicitTLS connects with TLS from the start (usually port 465)
+	ImplicitTLS Security = "tls"
+	// NoTLS never encrypts the connection, only for local testing
+	NoTLS Security = "none"
+)
+
+// Config holds the SMTP server settings
+type Config struct {
+	Host     string
+	Port     int
+	Security Security
+	Username string
+	Password string
+	// From, if set, replaces the sender of every message since most servers only relay
+	// mail from verified addresses, the original sender becomes the reply to address
+	From string
+}
+
+// ConfigFromSettings reads the SMTP_* config values, returns nil if SMTP_HOST is not set
+func ConfigFromSettings() (*Config, error) {
+	host := config.GetConfig("SMTP_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	smtpConfig := &Config{
+		Host:     host,
+		Port:     587,
+		Security: StartTLS,
+		Username: config.GetConfig("SMTP_USERNAME"),
+		Password: config.GetConfig("SMTP_PASSWORD"),
+		From:     config.GetConfig("SMTP_FROM"),
+	}
+
+	if value := config.GetConfig("SMTP_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %v", value)
+		}
+		smtpConfig.Port = port
+	}
+
+	if value := config.GetConfig("SMTP_SECURITY"); value != "" {
+		switch Security(value) {
+		case StartTLS, ImplicitTLS, NoTLS:
+			smtpConfig.Security = Security(value)
+		default:
+			return nil, fmt.Errorf("invalid SMTP_SECURITY: %v", value)
+		}
+	}
+
+	return smtpConfig, nil
+}
@@ -0,0 +1,7 @@
+/*
+Package smtpplatform - implementation of platform.SendMail for any SMTP server
+
+Messages are sent as MIME: a text body, an html alternative if HTMLBody is set, inline
+attachments (those with a ContentID) related to the html, and regular attachments.
+*/
+package smtpplatform
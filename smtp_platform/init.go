@@ -0,0 +1,7 @@
+package smtpplatform
+
+import (
+	"github.com/bjorge/friendlyreservations/logger"
+)
+
+var logging = logger.New()
@@ -0,0 +1,177 @@
+package smtpplatform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+	uuid "github.com/satori/go.uuid"
+)
+
+// mimePart is a single part of a message, either a leaf with a body or a multipart container
+type mimePart struct {
+	header   textproto.MIMEHeader
+	body     []byte
+	subtype  string
+	children []*mimePart
+}
+
+func textPart(contentType string, text string) *mimePart {
+	buffer := &bytes.Buffer{}
+	writer := quotedprintable.NewWriter(buffer)
+	writer.Write([]byte(text))
+	writer.Close()
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return &mimePart{header: header, body: buffer.Bytes()}
+}
+
+func attachmentPart(attachment platform.EmailAttachment) *mimePart {
+	// the type by extension may already include params, example a charset for text types
+	contentType, params, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(attachment.Name)))
+	if err != nil {
+		contentType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = attachment.Name
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, params))
+	header.Set("Content-Transfer-Encoding", "base64")
+	disposition := "attachment"
+	if attachment.ContentID != "" {
+		disposition = "inline"
+		header.Set("Content-ID", "<"+strings.Trim(attachment.ContentID, "<>")+">")
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}))
+
+	return &mimePart{header: header, body: base64Lines(attachment.Data)}
+}
+
+// base64Lines encodes the data with lines no longer than the 76 characters allowed by mime
+func base64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	buffer := &bytes.Buffer{}
+	for len(encoded) > 76 {
+		buffer.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buffer.WriteString(encoded)
+	return buffer.Bytes()
+}
+
+func multipartOf(subtype string, children ...*mimePart) *mimePart {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return &mimePart{subtype: subtype, children: children}
+}
+
+// write writes the part headers and body, multipart containers get a fresh boundary
+func (r *mimePart) write(buffer *bytes.Buffer, header textproto.MIMEHeader) error {
+	if r.children == nil {
+		for key, values := range r.header {
+			header[key] = values
+		}
+		writeHeader(buffer, header)
+		buffer.Write(r.body)
+		return nil
+	}
+
+	// only the random boundary is used from the multipart writer, each child writes its own headers
+	boundary := multipart.NewWriter(nil).Boundary()
+	body := &bytes.Buffer{}
+	for _, child := range r.children {
+		fmt.Fprintf(body, "--%s\r\n", boundary)
+		if err := child.write(body, textproto.MIMEHeader{}); err != nil {
+			return err
+		}
+		body.WriteString("\r\n")
+	}
+	fmt.Fprintf(body, "--%s--\r\n", boundary)
+
+	header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%s", r.subtype, boundary))
+	writeHeader(buffer, header)
+	buffer.Write(body.Bytes())
+	return nil
+}
+
+func writeHeader(buffer *bytes.Buffer, header textproto.MIMEHeader) {
+	for key, values := range header {
+		for _, value := range values {
+			fmt.Fprintf(buffer, "%s: %s\r\n", key, value)
+		}
+	}
+	buffer.WriteString("\r\n")
+}
+
+// headerAddresses formats the addresses for a header, the display names are quoted and encoded as needed
+func headerAddresses(addresses ...string) (string, error) {
+	formatted := []string{}
+	for _, address := range addresses {
+		parsed, err := parseAddress(address)
+		if err != nil {
+			return "", err
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// buildMessage returns the RFC 822 message for the email
+func buildMessage(msg *platform.EmailMessage, from string, replyTo string, now time.Time) ([]byte, error) {
+	body := textPart("text/plain", msg.Body)
+	if msg.HTMLBody != "" {
+		body = multipartOf("alternative", body, textPart("text/html", msg.HTMLBody))
+	}
+
+	inline := []*mimePart{body}
+	attachments := []*mimePart{}
+	for _, attachment := range msg.Attachments {
+		if attachment.ContentID != "" {
+			inline = append(inline, attachmentPart(attachment))
+		} else {
+			attachments = append(attachments, attachmentPart(attachment))
+		}
+	}
+	content := multipartOf("mixed", append([]*mimePart{multipartOf("related", inline...)}, attachments...)...)
+
+	header := textproto.MIMEHeader{}
+	for _, field := range []struct {
+		key       string
+		addresses []string
+	}{
+		{"From", []string{from}},
+		{"Reply-To", []string{replyTo}},
+		{"To", msg.To},
+		{"Cc", msg.Cc},
+	} {
+		if len(field.addresses) == 0 || field.addresses[0] == "" {
+			continue
+		}
+		value, err := headerAddresses(field.addresses...)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(field.key, value)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", now.Format(time.RFC1123Z))
+	header.Set("Message-Id", fmt.Sprintf("<%s@friendlyreservations>", uuid.Must(uuid.NewV4()).String()))
+	header.Set("Mime-Version", "1.0")
+
+	buffer := &bytes.Buffer{}
+	if err := content.write(buffer, header); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,166 @@
+package smtpplatform
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// the longest time allowed to send a message when the context has no deadline
+const sendTimeout = 60 * time.Second
+
+type sendMailImpl struct {
+	config *Config
+	// tlsConfig is only replaced by tests
+	tlsConfig *tls.Config
+}
+
+// NewEmailSender is the factory method to create an email sender
+func NewEmailSender(config *Config) platform.SendMail {
+	logging.LogDebugf("Email sender using smtp server %v:%v", config.Host, config.Port)
+	return &sendMailImpl{config: config, tlsConfig: &tls.Config{ServerName: config.Host}}
+}
+
+// parseAddress parses an address with an optional display name
+func parseAddress(address string) (*mail.Address, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address %v: %v", address, err)
+	}
+	return parsed, nil
+}
+
+// addressOnly strips the display name, the SMTP envelope only takes the address
+func addressOnly(address string) (string, error) {
+	parsed, err := parseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
+
+func (r *sendMailImpl) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(r.config.Host, strconv.Itoa(r.config.Port))
+	dialer := &net.Dialer{}
+
+	var conn net.Conn
+	var err error
+	if r.config.Security == ImplicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: r.tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(sendTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, r.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if r.config.Security == StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(r.tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if r.config.Username != "" {
+		auth := smtp.PlainAuth("", r.config.Username, r.config.Password, r.config.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (r *sendMailImpl) Send(ctx context.Context, emailMessage *platform.EmailMessage) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	from := emailMessage.Sender
+	replyTo := emailMessage.ReplyTo
+	if r.config.From != "" {
+		from = r.config.From
+		if replyTo == "" {
+			replyTo = emailMessage.Sender
+		}
+	}
+
+	envelopeFrom, err := addressOnly(from)
+	if err != nil {
+		return err
+	}
+	recipients := []string{}
+	for _, list := range [][]string{emailMessage.To, emailMessage.Cc, emailMessage.Bcc} {
+		for _, address := range list {
+			recipient, err := addressOnly(address)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, recipient)
+		}
+	}
+	if len(recipients) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	data, err := buildMessage(emailMessage, from, replyTo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := r.dial(ctx)
+	if err != nil {
+		logging.LogErrorf("Error connecting to smtp server: %+v", err)
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(envelopeFrom); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	logging.LogDebugf("Success sending email: %+v", emailMessage.Subject)
+	return client.Quit()
+}
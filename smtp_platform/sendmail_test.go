@@ -0,0 +1,362 @@
+package smtpplatform
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjorge/friendlyreservations/platform"
+)
+
+// fakeServer is a minimal SMTP server that records the last message it received
+type fakeServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool
+	received  chan *fakeMessage
+}
+
+type fakeMessage struct {
+	auth    string
+	from    string
+	to      []string
+	data    string
+	secured bool
+}
+
+func newTLSConfig(t *testing.T) (*tls.Config, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}, pool
+}
+
+func startFakeServer(t *testing.T, security Security) (*fakeServer, *sendMailImpl) {
+	tlsConfig, pool := newTLSConfig(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if security == ImplicitTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	server := &fakeServer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		startTLS:  security == StartTLS,
+		received:  make(chan *fakeMessage, 1),
+	}
+	go server.serve()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	sender := NewEmailSender(&Config{
+		Host:     "127.0.0.1",
+		Port:     port,
+		Security: security,
+		Username: "user",
+		Password: "secret",
+	}).(*sendMailImpl)
+	sender.tlsConfig = &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+	return server, sender
+}
+
+func (r *fakeServer) serve() {
+	conn, err := r.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, secured := conn.(*tls.Conn)
+	message := &fakeMessage{secured: secured}
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 fake smtp")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			reply("250-fake")
+			if r.startTLS && !message.secured {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, r.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			reader = bufio.NewReader(conn)
+			message.secured = true
+		case "AUTH":
+			fields := strings.Fields(line)
+			decoded, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			message.auth = string(decoded)
+			reply("235 ok")
+		case "MAIL":
+			message.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			message.to = append(message.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data := &strings.Builder{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			message.data = data.String()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			r.received <- message
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (r *fakeServer) wait(t *testing.T) *fakeMessage {
+	defer r.listener.Close()
+	select {
+	case message := <-r.received:
+		return message
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the message")
+	}
+	return nil
+}
+
+func testMessage() *platform.EmailMessage {
+	return &platform.EmailMessage{
+		Sender:   "Admin <admin@test.com>",
+		To:       []string{"Member One <one@test.com>"},
+		Cc:       []string{"two@test.com"},
+		Bcc:      []string{"three@test.com"},
+		Subject:  "Réservation confirmed",
+		Body:     "plain body",
+		HTMLBody: `<p>html body <img src="cid:logo"></p>`,
+		Attachments: []platform.EmailAttachment{
+			{Name: "logo.png", Data: []byte("png data"), ContentID: "logo"},
+			{Name: "export.csv", Data: []byte("a,b\n1,2\n")},
+		},
+	}
+}
+
+// collectParts returns the leaf parts of the message body by content type
+func collectParts(t *testing.T, contentType string, body io.Reader, parts map[string]*multipart.Part, data map[string][]byte) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("expected a multipart body but got %v", mediaType)
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(partType, "multipart/") {
+			collectParts(t, partType, part, parts, data)
+			continue
+		}
+		partMediaType, _, _ := mime.ParseMediaType(partType)
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, err = base64.StdEncoding.DecodeString(strings.Replace(string(content), "\r\n", "", -1))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		parts[partMediaType] = part
+		data[partMediaType] = content
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	server, sender := startFakeServer(t, NoTLS)
+
+	if err := sender.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	message := server.wait(t)
+
+	if message.auth != "\x00user\x00secret" {
+		t.Fatalf("expected plain auth but got %q", message.auth)
+	}
+	if message.from != "admin@test.com" {
+		t.Fatalf("expected envelope from admin but got %v", message.from)
+	}
+	if strings.Join(message.to, ",") != "one@test.com,two@test.com,three@test.com" {
+		t.Fatalf("expected to, cc and bcc recipients but got %v", message.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := (&mime.WordDecoder{}).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "Réservation confirmed" {
+		t.Fatalf("wrong subject %v", subject)
+	}
+	if parsed.Header.Get("Bcc") != "" {
+		t.Fatal("bcc should not be in the headers")
+	}
+
+	parts := make(map[string]*multipart.Part)
+	data := make(map[string][]byte)
+	collectParts(t, parsed.Header.Get("Content-Type"), parsed.Body, parts, data)
+
+	if string(data["text/plain"]) != "plain body" {
+		t.Fatalf("wrong text body %q", data["text/plain"])
+	}
+	if !strings.Contains(string(data["text/html"]), "cid:logo") {
+		t.Fatalf("wrong html body %q", data["text/html"])
+	}
+	if parts["image/png"].Header.Get("Content-Id") != "<logo>" || string(data["image/png"]) != "png data" {
+		t.Fatal("expected an inline logo part")
+	}
+	if !strings.HasPrefix(parts["text/csv"].Header.Get("Content-Disposition"), "attachment") || string(data["text/csv"]) != "a,b\n1,2\n" {
+		t.Fatal("expected a csv attachment")
+	}
+}
+
+func TestSendMailStartTLS(t *testing.T) {
+	server, sender := startFakeServer(t, StartTLS)
+
+	if err := sender.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if message := server.wait(t); !message.secured {
+		t.Fatal("expected the connection to be upgraded")
+	}
+}
+
+func TestSendMailImplicitTLS(t *testing.T) {
+	server, sender := startFakeServer(t, ImplicitTLS)
+
+	sender.config.From = "System <noreply@test.com>"
+	if err := sender.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	message := server.wait(t)
+	if !message.secured {
+		t.Fatal("expected a tls connection")
+	}
+	if message.from != "noreply@test.com" {
+		t.Fatalf("expected the configured from address but got %v", message.from)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyTo, err := parsed.Header.AddressList("Reply-To")
+	if err != nil || len(replyTo) != 1 || replyTo[0].Name != "Admin" || replyTo[0].Address != "admin@test.com" {
+		t.Fatal("expected the original sender as the reply to address")
+	}
+}
+
+func TestSendMailDisplayNames(t *testing.T) {
+	server, sender := startFakeServer(t, NoTLS)
+
+	msg := testMessage()
+	msg.To = []string{(&mail.Address{Name: "Smith, John", Address: "one@test.com"}).String()}
+	msg.Cc = []string{(&mail.Address{Name: "Zoë \"Z\" Müller", Address: "two@test.com"}).String()}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	message := server.wait(t)
+	if strings.Join(message.to, ",") != "one@test.com,two@test.com,three@test.com" {
+		t.Fatalf("expected to, cc and bcc recipients but got %v", message.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(parsed.Header.Get("Cc"), "ëü") {
+		t.Fatalf("expected an encoded cc header but got %v", parsed.Header.Get("Cc"))
+	}
+	for key, expected := range map[string]mail.Address{
+		"To": {Name: "Smith, John", Address: "one@test.com"},
+		"Cc": {Name: "Zoë \"Z\" Müller", Address: "two@test.com"},
+	} {
+		addresses, err := parsed.Header.AddressList(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(addresses) != 1 || *addresses[0] != expected {
+			t.Fatalf("expected %v %+v but got %+v", key, expected, addresses)
+		}
+	}
+
+	t.Log("unparsable addresses are rejected")
+	msg.To = []string{"Smith, John <one@test.com>"}
+	if err := sender.Send(context.Background(), msg); err == nil {
+		t.Fatal("expected an error for an unquoted display name")
+	}
+}
@@ -0,0 +1,103 @@
+package templates
+
+// htmlLayout wraps the content of every html notification with the property branding and a link to the site
+const htmlLayout = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:0;background-color:#f4f4f4;font-family:Helvetica,Arial,sans-serif;color:#333333;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background-color:#f4f4f4;">
+<tr><td align="center" style="padding:24px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" style="background-color:#ffffff;border-radius:4px;">
+<tr><td style="background-color:#3f51b5;color:#ffffff;padding:16px 24px;font-size:20px;font-weight:bold;border-radius:4px 4px 0 0;">{{.Settings.PropertyName}}</td></tr>
+<tr><td style="padding:24px;font-size:15px;line-height:1.5;">
+{{template "content" .}}
+</td></tr>
+<tr><td style="padding:16px 24px;font-size:12px;color:#777777;border-top:1px solid #eeeeee;">
+<a href="{{.Links.Home}}" style="color:#3f51b5;">Go to {{.Settings.PropertyName}}</a>
+</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+{{define "reservation"}}
+<table role="presentation" cellpadding="6" cellspacing="0" style="border-collapse:collapse;margin:16px 0;">
+<tr><td style="border:1px solid #dddddd;font-weight:bold;">Check in</td><td style="border:1px solid #dddddd;">{{.Reservation.StartDate}}</td></tr>
+<tr><td style="border:1px solid #dddddd;font-weight:bold;">Check out</td><td style="border:1px solid #dddddd;">{{.Reservation.EndDate}}</td></tr>
+<tr><td style="border:1px solid #dddddd;font-weight:bold;">Reserved for</td><td style="border:1px solid #dddddd;">{{.Reservation.ReservedFor.Nickname}}</td></tr>
+{{if not .Reservation.Member}}<tr><td style="border:1px solid #dddddd;font-weight:bold;">Guest</td><td style="border:1px solid #dddddd;">{{.Reservation.NonMemberName}}</td></tr>{{end}}
+</table>
+<p><a href="{{.Links.Reservation}}" style="display:inline-block;background-color:#3f51b5;color:#ffffff;padding:10px 16px;border-radius:4px;text-decoration:none;">View the reservation</a></p>
+{{end}}
+`
+
+// GetNotificationHTMLTemplate returns the html body template for a notification, or "" if the
+// notification only has a text body, the html has the same params as the text plus Links
+func GetNotificationHTMLTemplate(version int, name TemplateName) string {
+	content := ""
+	switch name {
+	case LowBalanceNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>Just a reminder that you have a negative balance of <strong>{{.Ledger.Balance .Amount}}</strong>.</p>
+<p>Please submit a payment to cover your negative balance soon.</p>
+<p><a href="{{.Links.Ledger}}" style="color:#3f51b5;">View your balance</a></p>
+<p>Thanks!</p>`
+	case NewPropertyNotification:
+		content = `<p>New property created</p>`
+	case NewReservationNotification:
+		content = `<p>Hi {{.Settings.PropertyName}} Members!</p>
+<p>A new reservation has been made.</p>
+{{template "reservation" .}}`
+	case CancelReservationNotification:
+		content = `<p>Hi {{.Settings.PropertyName}} Members!</p>
+<p>The following reservation has been canceled.</p>
+{{template "reservation" .}}`
+	case BalanceChangeNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>Your balance has been changed by <strong>{{.Ledger.Amount .Amount}}</strong> to a new balance of <strong>{{.Ledger.Balance .Amount}}</strong>.</p>
+<p><a href="{{.Links.Ledger}}" style="color:#3f51b5;">View your balance</a></p>`
+	case DigestNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>Here is what happened at {{.Settings.PropertyName}} this past week.</p>
+<p>Your balance is <strong>{{.Ledger.Balance .Amount}}</strong>.</p>
+<h3>Reservation changes</h3>
+{{if .Digest.ReservationChanges}}<ul>{{range .Digest.ReservationChanges}}<li>{{if .Canceled}}Canceled{{else}}New{{end}}: {{.Nickname}}, {{.StartDate}} to {{.EndDate}}</li>{{end}}</ul>{{else}}<p>None</p>{{end}}
+<h3>Upcoming stays</h3>
+{{if .Digest.UpcomingStays}}<ul>{{range .Digest.UpcomingStays}}<li>{{.Nickname}}, {{.StartDate}} to {{.EndDate}}</li>{{end}}</ul>{{else}}<p>None</p>{{end}}
+<h3>Unread notifications: {{.Digest.UnreadCount}}</h3>
+{{if .Digest.UnreadSubjects}}<ul>{{range .Digest.UnreadSubjects}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<p><a href="{{.Links.Reservation}}" style="color:#3f51b5;">View the reservations</a></p>`
+	case MembershipPrePayNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>The <strong>{{.Membership.Description}}</strong> membership for {{.Membership.InDate}} to {{.Membership.OutDate}} can now be purchased.</p>
+<p>Purchase it before {{.Membership.InDate}} to be ready for the new membership period.</p>
+<p><a href="{{.Links.Membership}}" style="color:#3f51b5;">View your memberships</a></p>
+<p>Thanks!</p>`
+	case MembershipRenewalNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>Just a reminder that the <strong>{{.Membership.Description}}</strong> membership starts on <strong>{{.Membership.InDate}}</strong> and you have not purchased it or opted out yet.</p>
+<p><a href="{{.Links.Membership}}" style="color:#3f51b5;">View your memberships</a></p>
+<p>Thanks!</p>`
+	case MembershipGraceNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>The grace period to purchase the <strong>{{.Membership.Description}}</strong> membership ends on <strong>{{.Membership.GracePeriodOutDate}}</strong>.</p>
+<p>Please purchase the membership or opt out before then.</p>
+<p><a href="{{.Links.Membership}}" style="color:#3f51b5;">View your memberships</a></p>
+<p>Thanks!</p>`
+	case PaymentPlanMissedNotification:
+		content = `<p>Hi {{.User.Nickname}},</p>
+<p>The installment of <strong>{{.PaymentPlan.Amount}}</strong> due on <strong>{{.PaymentPlan.DueDate}}</strong> for the payment plan {{.PaymentPlan.Description}} has not been paid.</p>
+<p>So far {{.PaymentPlan.Paid}} of {{.PaymentPlan.Total}} has been paid. Reservations are not allowed with a balance under the minimum until the plan is current again.</p>
+<p><a href="{{.Links.Ledger}}" style="color:#3f51b5;">View your balance</a></p>
+<p>Thanks!</p>`
+	default:
+		return ""
+	}
+
+	return htmlLayout + `{{define "content"}}` + content + `{{end}}`
+}
+
+// GetCustomNotificationHTMLTemplate returns the html body template for a notification with an admin
+// provided text body, the rendered text is passed in the Body param
+func GetCustomNotificationHTMLTemplate() string {
+	return htmlLayout + `{{define "content"}}<div style="white-space:pre-line;">{{.Body}}</div>{{end}}`
+}
@@ -15,6 +15,11 @@ const (
 	BalanceChangeNotification     TemplateName = "BALANCE_INCREASE"
 	HomePageContents              TemplateName = "HOME_PAGE"
 	LowBalanceNotification        TemplateName = "BALANCE_NOTIFICATION"
+	DigestNotification            TemplateName = "DIGEST"
+	MembershipPrePayNotification  TemplateName = "MEMBERSHIP_PREPAY_OPEN"
+	MembershipRenewalNotification TemplateName = "MEMBERSHIP_RENEWAL_REMINDER"
+	MembershipGraceNotification   TemplateName = "MEMBERSHIP_GRACE_PERIOD_ENDING"
+	PaymentPlanMissedNotification TemplateName = "PAYMENT_PLAN_MISSED"
 )
 
 // TemplateParamGroup is the type used for template group names
@@ -26,7 +31,13 @@ const (
 	Reservation TemplateParamGroup = "Reservation"
 	Ledger      TemplateParamGroup = "Ledger"
 	Me          TemplateParamGroup = "Me"
+	// Decimal is kept for admin contents written before Amount
 	Decimal     TemplateParamGroup = "Decimal"
+	Amount      TemplateParamGroup = "Amount"
+	Links       TemplateParamGroup = "Links"
+	Digest      TemplateParamGroup = "Digest"
+	Membership  TemplateParamGroup = "Membership"
+	PaymentPlan TemplateParamGroup = "PaymentPlan"
 )
 
 // GetNotificationTemplate returns two templates (ex. subject+body notification, or member+admin page)
@@ -35,14 +46,14 @@ func GetNotificationTemplate(version int, name TemplateName) (string, string, []
 	case LowBalanceNotification:
 		return `{{.Settings.PropertyName}}: Negative balance reminder`,
 			`Hi {{.User.Nickname}},
-Just a reminder that you have a negative balance of {{.Ledger.Balance .Decimal}}.
+Just a reminder that you have a negative balance of {{.Ledger.Balance .Amount}}.
 		
 Please submit a payment to cover your negative balance soon.
 		
 Thanks!
 {{.Settings.PropertyName}}
 `,
-			[]TemplateParamGroup{Me, Ledger, Settings, Decimal}
+			[]TemplateParamGroup{Me, Ledger, Settings, Decimal, Amount}
 
 	case TestTemplate:
 		return "",
@@ -51,9 +62,9 @@ Thanks!
 	case HomePageContents:
 		return `Welcome {{.Settings.PropertyName}} Member!
 Hi {{.Me.Nickname}},
-Your balance is {{.Ledger.Balance .Decimal}} today.
+Your balance is {{.Ledger.Balance .Amount}} today.
 
-If your balance drops below {{.Settings.MinBalance .Decimal }} then you cannot make new reservations.
+If your balance drops below {{.Settings.MinBalance .Amount}} then you cannot make new reservations.
 
 You can view balance details, make a reservation, etc. by selecting the menu button on the upper left.
 
@@ -63,7 +74,7 @@ Have Fun!
 
 Select administrative options by pressing the menu button on the upper left.
 `,
-			[]TemplateParamGroup{Me, Ledger, Settings, Decimal}
+			[]TemplateParamGroup{Me, Ledger, Settings, Decimal, Amount}
 	case NewPropertyNotification:
 		return `{{.Settings.PropertyName}}: New property created`,
 			`New property created`,
@@ -90,11 +101,79 @@ The reservation with checkin date {{.Reservation.StartDate}} has been canceled.
 		return `{{.Settings.PropertyName}}: Balance change for {{.User.Nickname}}`,
 			`Hi {{.User.Nickname}},
 	
-Your balance has been changed by {{.Ledger.Amount .Decimal}} to a new balance of {{.Ledger.Balance .Decimal}}.
+Your balance has been changed by {{.Ledger.Amount .Amount}} to a new balance of {{.Ledger.Balance .Amount}}.
 
 {{.Settings.PropertyName}}
 `,
-			[]TemplateParamGroup{Me, Ledger, Settings, Decimal}
+			[]TemplateParamGroup{Me, Ledger, Settings, Decimal, Amount}
+	case DigestNotification:
+		return `{{.Settings.PropertyName}}: Weekly summary for {{.User.Nickname}}`,
+			`Hi {{.User.Nickname}},
+
+Here is what happened at {{.Settings.PropertyName}} this past week.
+
+Your balance is {{.Ledger.Balance .Amount}}.
+
+Reservation changes:
+{{range .Digest.ReservationChanges}}{{if .Canceled}}Canceled{{else}}New{{end}}: {{.Nickname}} check in {{.StartDate}}, check out {{.EndDate}}
+{{else}}None
+{{end}}
+Upcoming stays:
+{{range .Digest.UpcomingStays}}{{.Nickname}} check in {{.StartDate}}, check out {{.EndDate}}
+{{else}}None
+{{end}}
+Unread notifications: {{.Digest.UnreadCount}}
+{{range .Digest.UnreadSubjects}}{{.}}
+{{end}}
+{{.Settings.PropertyName}}
+`,
+			[]TemplateParamGroup{Me, Ledger, Settings, Decimal, Amount, Digest}
+	case MembershipPrePayNotification:
+		return `{{.Settings.PropertyName}}: {{.Membership.Description}} membership now available`,
+			`Hi {{.User.Nickname}},
+
+The {{.Membership.Description}} membership for {{.Membership.InDate}} to {{.Membership.OutDate}} can now be purchased.
+
+Purchase it before {{.Membership.InDate}} to be ready for the new membership period.
+
+Thanks!
+{{.Settings.PropertyName}}
+`,
+			[]TemplateParamGroup{Me, Ledger, Settings, Membership}
+	case MembershipRenewalNotification:
+		return `{{.Settings.PropertyName}}: {{.Membership.Description}} membership starts on {{.Membership.InDate}}`,
+			`Hi {{.User.Nickname}},
+
+Just a reminder that the {{.Membership.Description}} membership starts on {{.Membership.InDate}} and you have not purchased it or opted out yet.
+
+Thanks!
+{{.Settings.PropertyName}}
+`,
+			[]TemplateParamGroup{Me, Ledger, Settings, Membership}
+	case MembershipGraceNotification:
+		return `{{.Settings.PropertyName}}: {{.Membership.Description}} membership grace period ends on {{.Membership.GracePeriodOutDate}}`,
+			`Hi {{.User.Nickname}},
+
+The grace period to purchase the {{.Membership.Description}} membership ends on {{.Membership.GracePeriodOutDate}}.
+
+Please purchase the membership or opt out before then.
+
+Thanks!
+{{.Settings.PropertyName}}
+`,
+			[]TemplateParamGroup{Me, Ledger, Settings, Membership}
+	case PaymentPlanMissedNotification:
+		return `{{.Settings.PropertyName}}: Missed payment plan installment for {{.User.Nickname}}`,
+			`Hi {{.User.Nickname}},
+
+The installment of {{.PaymentPlan.Amount}} due on {{.PaymentPlan.DueDate}} for the payment plan {{.PaymentPlan.Description}} has not been paid.
+
+So far {{.PaymentPlan.Paid}} of {{.PaymentPlan.Total}} has been paid. Reservations are not allowed with a balance under the minimum until the plan is current again.
+
+Thanks!
+{{.Settings.PropertyName}}
+`,
+			[]TemplateParamGroup{Me, Ledger, Settings, PaymentPlan}
 	default:
 		return "",
 			"",
@@ -1,6 +1,7 @@
 package utilities
 
 import (
+	"strings"
 	"time"
 
 	"github.com/bjorge/friendlyreservations/config"
@@ -36,6 +37,12 @@ var AllowCrossDomainRequests bool
 // TrialDuration is the duration for trial accounts, deleted at the end of the trial
 var TrialDuration time.Duration
 
+// DigestWeekday is the day of the week the weekly digest emails are sent
+var DigestWeekday time.Weekday
+
+// InboundEmailAddress receives replies to notification emails, replies are not received if empty
+var InboundEmailAddress string
+
 func init() {
 	SystemEmail = config.GetConfig("DEFAULT_SYSTEM_EMAIL")
 	SystemName = config.GetConfig("DEFAULT_SYSTEM_NAME")
@@ -45,6 +52,7 @@ func init() {
 	AllowExportBackup = config.GetConfig("ALLOW_EXPORT_BACKUP") == "true"
 	AllowDeleteProperty = config.GetConfig("ALLOW_DELETE_PROPERTY") == "true"
 	ImportFileName = config.GetConfig("IMPORT_FILE_NAME")
+	InboundEmailAddress = config.GetConfig("INBOUND_EMAIL_ADDRESS")
 	SendMailDisabled = config.GetConfig("SEND_MAIL_DISABLED") == "true"
 	AllowCrossDomainRequests = config.GetConfig("ALLOW_CROSS_DOMAIN_REQUESTS") == "true"
 	value := config.GetConfig("TRIAL_DURATION")
@@ -54,4 +62,12 @@ func init() {
 	} else {
 		TrialDuration, _ = time.ParseDuration(value)
 	}
+	// default sunday
+	DigestWeekday = time.Sunday
+	value = strings.ToLower(config.GetConfig("DIGEST_WEEKDAY"))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == value {
+			DigestWeekday = day
+		}
+	}
 }